@@ -5,24 +5,54 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 
 	"github.com/aykay76/projectflow/internal/mcp"
+	"github.com/aykay76/projectflow/internal/models"
 	"github.com/aykay76/projectflow/internal/storage"
 )
 
 func main() {
-	// Initialize storage
 	storageDir := getEnv("STORAGE_DIR", "./data")
-	store, err := storage.NewFileStorage(storageDir)
+
+	// Load the project's workflow before any task is read or written
+	workflow, err := models.LoadWorkflow(getEnv("WORKFLOW_FILE", filepath.Join(storageDir, models.WorkflowFileName)))
+	if err != nil {
+		log.Fatalf("Failed to load workflow: %v", err)
+	}
+	models.SetWorkflow(workflow)
+
+	// Initialize storage
+	storageBackend := getEnv("STORAGE_BACKEND", storage.BackendFile)
+	store, err := storage.NewStorage(storageBackend, storageDir)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
+	if getEnv("STORAGE_CACHE", "true") != "false" {
+		store = storage.NewCachedStorage(store)
+	}
 	defer store.Close()
 
 	// Initialize MCP server
 	mcpServer := mcp.NewMCPServer(store)
 
+	// Delete the attachments of tasks deleted over MCP
+	attachments, err := storage.NewFileAttachmentStore(storageDir, attachmentMaxSize())
+	if err != nil {
+		log.Fatalf("Failed to initialize attachments: %v", err)
+	}
+	mcpServer.SetAttachments(attachments)
+
+	// Act as the user named by MCP_USER_ID, usually one registered for the agent
+	if userID := os.Getenv("MCP_USER_ID"); userID != "" {
+		if _, err := store.GetUser(userID); err != nil {
+			log.Fatalf("Failed to load MCP user: %v", err)
+		}
+		mcpServer.SetUserID(userID)
+	}
+
 	// Setup context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -46,6 +76,15 @@ func main() {
 	log.Println("ProjectFlow MCP server stopped")
 }
 
+// attachmentMaxSize reads the attachment size limit from ATTACHMENT_MAX_MB
+func attachmentMaxSize() int64 {
+	mb, err := strconv.Atoi(getEnv("ATTACHMENT_MAX_MB", "10"))
+	if err != nil || mb <= 0 {
+		log.Fatalf("ATTACHMENT_MAX_MB must be a positive number of megabytes")
+	}
+	return int64(mb) << 20
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+const usage = `Usage: projectflow-admin <command> [flags]
+
+Commands:
+  fsck    Check a storage directory for integrity problems and optionally repair them
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "fsck":
+		os.Exit(runFsck(os.Args[2:]))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// runFsck implements the fsck command. It exits 0 when the data is clean or
+// was repaired, 1 when problems were found but not repaired and 2 on error.
+func runFsck(args []string) int {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	storageDir := flags.String("dir", getEnv("STORAGE_DIR", "./data"), "storage directory to check")
+	storageBackend := flags.String("backend", getEnv("STORAGE_BACKEND", storage.BackendFile), "storage backend (file or sqlite)")
+	repair := flags.Bool("repair", false, "fix the problems found")
+	reportPath := flags.String("report", "", "write a JSON report to this file (default with --repair: STORAGE_DIR/fsck-report-<time>.json)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if _, err := os.Stat(*storageDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open storage directory: %v\n", err)
+		return 2
+	}
+
+	// Statuses are checked against the project's workflow
+	workflow, err := models.LoadWorkflow(getEnv("WORKFLOW_FILE", filepath.Join(*storageDir, models.WorkflowFileName)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load workflow: %v\n", err)
+		return 2
+	}
+	models.SetWorkflow(workflow)
+
+	// Only a repair opens the storage normally, which can itself write to the
+	// directory; a check leaves it exactly as it was
+	var report *storage.FsckReport
+	if *repair {
+		report, err = repairStorage(*storageBackend, *storageDir)
+	} else {
+		report, err = storage.CheckReadOnly(*storageBackend, *storageDir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fsck failed: %v\n", err)
+		return 2
+	}
+
+	printReport(report)
+
+	// A repair run always leaves a record of what it changed
+	if *reportPath == "" && *repair && len(report.Problems) > 0 {
+		name := fmt.Sprintf("fsck-report-%s.json", report.CheckedAt.Format("20060102-150405"))
+		*reportPath = filepath.Join(*storageDir, name)
+	}
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+			return 2
+		}
+		fmt.Printf("Report written to %s\n", *reportPath)
+	}
+
+	if len(report.Problems) > 0 && !report.Repaired {
+		return 1
+	}
+	return 0
+}
+
+// repairStorage opens the storage in dataDir and repairs it
+func repairStorage(backend, dataDir string) (*storage.FsckReport, error) {
+	store, err := storage.NewStorage(backend, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	checker, ok := store.(storage.Checker)
+	if !ok {
+		return nil, fmt.Errorf("storage backend %s does not support fsck", backend)
+	}
+	return checker.Fsck(true)
+}
+
+func printReport(report *storage.FsckReport) {
+	fmt.Printf("Scanned %d tasks at %s\n", report.TasksScanned, report.CheckedAt.Format(time.RFC3339))
+	if len(report.Problems) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	action := "would repair"
+	if report.Repaired {
+		action = "repaired"
+	}
+	for _, problem := range report.Problems {
+		fmt.Printf("%-16s %s: %s (%s: %s)\n", problem.Kind, problem.TaskID, problem.Detail, action, problem.Repair)
+	}
+
+	if report.Repaired {
+		fmt.Printf("%d problems repaired\n", len(report.Problems))
+	} else {
+		fmt.Printf("%d problems found; run with --repair to fix them\n", len(report.Problems))
+	}
+}
+
+func writeReport(path string, report *storage.FsckReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
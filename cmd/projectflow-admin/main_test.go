@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// snapshotDir reads every file under dir, keyed by its path relative to dir;
+// directories map to nil
+func snapshotDir(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			files[rel] = nil
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = data
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestRunFsck_CheckLeavesDirectoryUnchanged(t *testing.T) {
+	for _, backend := range []string{storage.BackendFile, storage.BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			dataDir := t.TempDir()
+			store, err := storage.NewStorage(backend, dataDir)
+			if err != nil {
+				t.Fatalf("Failed to create storage: %v", err)
+			}
+			epic := models.NewTask("Epic", "")
+			epic.Type = models.TypeEpic
+			if err := store.CreateTask(epic); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			story := models.NewTask("Story", "")
+			story.Type = models.TypeStory
+			story.ParentID = epic.ID
+			if err := store.CreateTask(story); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			store.Close()
+
+			if backend == storage.BackendFile {
+				// Leave what opening the storage would clean up: a journal
+				// to replay, a temporary file and a missing key index
+				pending, err := json.Marshal(map[string]any{"writes": []*models.Task{epic}})
+				if err != nil {
+					t.Fatalf("Setup failed: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(dataDir, "journal.json"), pending, 0644); err != nil {
+					t.Fatalf("Setup failed: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(dataDir, "tasks", ".task.json.tmp123"), []byte("{"), 0644); err != nil {
+					t.Fatalf("Setup failed: %v", err)
+				}
+				if err := os.RemoveAll(filepath.Join(dataDir, "keys")); err != nil {
+					t.Fatalf("Setup failed: %v", err)
+				}
+			}
+
+			before := snapshotDir(t, dataDir)
+			if code := runFsck([]string{"-dir", dataDir, "-backend", backend}); code == 2 {
+				t.Fatalf("runFsck() = %d, want the check to run", code)
+			}
+			after := snapshotDir(t, dataDir)
+
+			for path, data := range before {
+				got, ok := after[path]
+				if !ok {
+					t.Errorf("%s was removed", path)
+				} else if !bytes.Equal(got, data) {
+					t.Errorf("%s was changed", path)
+				}
+			}
+			for path := range after {
+				if _, ok := before[path]; !ok {
+					t.Errorf("%s was created", path)
+				}
+			}
+		})
+	}
+}
@@ -5,22 +5,43 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/aykay76/projectflow/internal/handlers"
+	"github.com/aykay76/projectflow/internal/models"
 	"github.com/aykay76/projectflow/internal/storage"
 )
 
 func main() {
-	// Initialize storage
 	storageDir := getEnv("STORAGE_DIR", "./data")
-	store, err := storage.NewFileStorage(storageDir)
+
+	// Load the project's workflow before any task is read or written
+	workflow, err := models.LoadWorkflow(getEnv("WORKFLOW_FILE", filepath.Join(storageDir, models.WorkflowFileName)))
+	if err != nil {
+		log.Fatalf("Failed to load workflow: %v", err)
+	}
+	models.SetWorkflow(workflow)
+
+	// Initialize storage
+	storageBackend := getEnv("STORAGE_BACKEND", storage.BackendFile)
+	store, err := storage.NewStorage(storageBackend, storageDir)
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
+	if getEnv("STORAGE_CACHE", "true") != "false" {
+		store = storage.NewCachedStorage(store)
+	}
+
+	// Attachments are kept as files under the storage directory whatever the backend
+	attachments, err := storage.NewFileAttachmentStore(storageDir, attachmentMaxSize())
+	if err != nil {
+		log.Fatalf("Failed to initialize attachments: %v", err)
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(store)
+	handler := handlers.NewHandler(store, attachments)
 
 	// Setup routes
 	mux := http.NewServeMux()
@@ -31,7 +52,10 @@ func main() {
 		path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 		parts := strings.Split(path, "/")
 
-		if len(parts) >= 2 && parts[1] == "children" {
+		if len(parts) == 1 && parts[0] == "labels" {
+			// /api/tasks/labels
+			handler.HandleBulkLabels(w, r)
+		} else if len(parts) >= 2 && parts[1] == "children" {
 			if len(parts) == 2 {
 				// /api/tasks/{id}/children
 				handler.HandleTaskChildren(w, r)
@@ -41,6 +65,52 @@ func main() {
 			} else {
 				http.Error(w, "Invalid URL path", http.StatusBadRequest)
 			}
+		} else if len(parts) >= 2 && parts[1] == "links" {
+			if len(parts) == 2 {
+				// /api/tasks/{id}/links
+				handler.HandleTaskLinks(w, r)
+			} else if len(parts) == 4 {
+				// /api/tasks/{id}/links/{type}/{task_id}
+				handler.HandleTaskLink(w, r)
+			} else {
+				http.Error(w, "Invalid URL path", http.StatusBadRequest)
+			}
+		} else if len(parts) >= 2 && parts[1] == "labels" {
+			if len(parts) == 2 {
+				// /api/tasks/{id}/labels
+				handler.HandleTaskLabels(w, r)
+			} else if len(parts) == 3 {
+				// /api/tasks/{id}/labels/{name}
+				handler.HandleTaskLabel(w, r)
+			} else {
+				http.Error(w, "Invalid URL path", http.StatusBadRequest)
+			}
+		} else if len(parts) >= 2 && parts[1] == "comments" {
+			if len(parts) == 2 {
+				// /api/tasks/{id}/comments
+				handler.HandleTaskComments(w, r)
+			} else if len(parts) == 3 {
+				// /api/tasks/{id}/comments/{comment_id}
+				handler.HandleTaskComment(w, r)
+			} else {
+				http.Error(w, "Invalid URL path", http.StatusBadRequest)
+			}
+		} else if len(parts) >= 2 && parts[1] == "attachments" {
+			if len(parts) == 2 {
+				// /api/tasks/{id}/attachments
+				handler.HandleTaskAttachments(w, r)
+			} else if len(parts) == 3 {
+				// /api/tasks/{id}/attachments/{attachment_id}
+				handler.HandleTaskAttachment(w, r)
+			} else {
+				http.Error(w, "Invalid URL path", http.StatusBadRequest)
+			}
+		} else if len(parts) == 2 && parts[1] == "history" {
+			// /api/tasks/{id}/history
+			handler.HandleTaskHistory(w, r)
+		} else if len(parts) == 2 && parts[1] == "time" {
+			// /api/tasks/{id}/time
+			handler.HandleTaskTime(w, r)
 		} else if len(parts) >= 2 && parts[1] == "move" {
 			// /api/tasks/{id}/move
 			handler.HandleTaskMove(w, r)
@@ -52,6 +122,40 @@ func main() {
 		}
 	})
 	mux.HandleFunc("/api/hierarchy", handler.HandleHierarchy)
+	mux.HandleFunc("/api/search", handler.HandleSearch)
+	mux.HandleFunc("/api/workflow", handler.HandleWorkflow)
+	mux.HandleFunc("/api/users", handler.HandleUsers)
+	mux.HandleFunc("/api/users/", handler.HandleUser)
+	mux.HandleFunc("/api/labels", handler.HandleLabels)
+	mux.HandleFunc("/api/labels/", handler.HandleLabel)
+	mux.HandleFunc("/api/sprints", handler.HandleSprints)
+	mux.HandleFunc("/api/sprints/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/sprints/")
+		parts := strings.Split(path, "/")
+
+		if len(parts) == 2 && parts[1] == "start" {
+			// /api/sprints/{id}/start
+			handler.HandleStartSprint(w, r)
+		} else if len(parts) == 2 && parts[1] == "close" {
+			// /api/sprints/{id}/close
+			handler.HandleCloseSprint(w, r)
+		} else if len(parts) == 1 {
+			// /api/sprints/{id}
+			handler.HandleSprint(w, r)
+		} else {
+			http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("/api/milestones", handler.HandleMilestones)
+	mux.HandleFunc("/api/milestones/", handler.HandleMilestone)
+	mux.HandleFunc("/api/timeline", handler.HandleTimeline)
+	mux.HandleFunc("/api/activity", handler.HandleActivity)
+	mux.HandleFunc("/api/reports/estimates", handler.HandleEstimateReport)
+	mux.HandleFunc("/api/worklogs", handler.HandleWorkLogs)
+	mux.HandleFunc("/api/worklogs/", handler.HandleWorkLog)
+	mux.HandleFunc("/api/timers", handler.HandleTimers)
+	mux.HandleFunc("/api/timers/start", handler.HandleStartTimer)
+	mux.HandleFunc("/api/timers/stop", handler.HandleStopTimer)
 
 	// Static files
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
@@ -64,6 +168,15 @@ func main() {
 	log.Fatal(http.ListenAndServe(":"+port, mux))
 }
 
+// attachmentMaxSize reads the attachment size limit from ATTACHMENT_MAX_MB
+func attachmentMaxSize() int64 {
+	mb, err := strconv.Atoi(getEnv("ATTACHMENT_MAX_MB", "10"))
+	if err != nil || mb <= 0 {
+		log.Fatalf("ATTACHMENT_MAX_MB must be a positive number of megabytes")
+	}
+	return int64(mb) << 20
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
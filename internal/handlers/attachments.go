@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// multipartOverhead is allowed on top of the attachment size limit for the
+// multipart boundaries and headers around the file
+const multipartOverhead = 64 << 10
+
+// inlineContentTypes can be shown in the browser; anything else, notably
+// HTML and SVG, which could run scripts, is only offered as a download
+var inlineContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"text/plain": true,
+}
+
+// HandleTaskAttachments handles /api/tasks/{id}/attachments endpoint
+func (h *Handler) HandleTaskAttachments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "attachments" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID := parts[0]
+
+	if taskID == "" {
+		http.Error(w, "Task ID required", http.StatusBadRequest)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+	if !h.storage.TaskExists(taskID) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		attachments, err := h.attachments.ListAttachments(taskID)
+		if err != nil {
+			http.Error(w, "Failed to list attachments", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(attachments)
+	case http.MethodPost:
+		h.uploadAttachments(w, r, taskID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTaskAttachment handles /api/tasks/{id}/attachments/{attachment_id}
+// endpoint. GET downloads the file.
+func (h *Handler) HandleTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	// Extract the task and attachment from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[1] != "attachments" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID, attachmentID := parts[0], parts[2]
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.attachments.GetAttachment(attachmentID)
+	if err == nil && attachment.TaskID != taskID {
+		// Attachments are only reachable through their own task
+		err = storage.ErrAttachmentNotFound
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrAttachmentNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.downloadAttachment(w, r, attachment)
+	case http.MethodDelete:
+		if err := h.attachments.DeleteAttachment(attachmentID); err != nil {
+			http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// uploadAttachments stores every file sent in the multipart form's "file"
+// fields, streaming each one to the attachment store. The upload is kept
+// whole or not at all: if a later file fails, the ones before it are deleted.
+func (h *Handler) uploadAttachments(w http.ResponseWriter, r *http.Request, taskID string) {
+	maxSize := h.attachments.MaxSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Upload files as multipart/form-data in a field named file", http.StatusBadRequest)
+		return
+	}
+
+	created := []*models.Attachment{}
+	fail := func(err error) {
+		for _, attachment := range created {
+			if err := h.attachments.DeleteAttachment(attachment.ID); err != nil {
+				log.Printf("Error deleting attachment: %v", err)
+			}
+		}
+		writeUploadError(w, err, maxSize)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+
+		attachment := models.NewAttachment(taskID, part.FileName(), part.Header.Get("Content-Type"))
+		if err := h.attachments.CreateAttachment(attachment, part); err != nil {
+			fail(err)
+			return
+		}
+		created = append(created, attachment)
+	}
+
+	if len(created) == 0 {
+		http.Error(w, "No file uploaded; send it in a field named file", http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// downloadAttachment sends an attachment's content with its content type.
+// Only types in inlineContentTypes are shown inline, and the sandbox policy
+// stops anything else the browser renders from running scripts.
+func (h *Handler) downloadAttachment(w http.ResponseWriter, r *http.Request, attachment *models.Attachment) {
+	etag := `"` + attachment.SHA256 + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	_, content, err := h.attachments.OpenAttachment(attachment.ID)
+	if err != nil {
+		http.Error(w, "Failed to open attachment", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if mediaType, _, err := mime.ParseMediaType(attachment.ContentType); err == nil && inlineContentTypes[mediaType] {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("ETag", etag)
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, content)
+}
+
+// writeUploadError reports an error reading or storing an upload
+func writeUploadError(w http.ResponseWriter, err error, maxSize int64) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, storage.ErrAttachmentTooLarge), errors.As(err, &tooLarge):
+		http.Error(w, fmt.Sprintf("Attachments are limited to %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, storage.ErrInvalidAttachment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "multipart"):
+		http.Error(w, "Invalid multipart upload", http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+func TestUploadAttachments_TooLarge(t *testing.T) {
+	h := newTestHandler(t)
+	attachments, err := storage.NewFileAttachmentStore(t.TempDir(), 100)
+	if err != nil {
+		t.Fatalf("Failed to create attachment store: %v", err)
+	}
+	h.attachments = attachments
+	task := createTestTask(t, h, "Attach here")
+	path := "/api/tasks/" + task.ID + "/attachments"
+
+	tests := []struct {
+		name string
+		// extra is written after a file that fits
+		extra func(form *multipart.Writer) error
+	}{
+		{"file over the limit", func(form *multipart.Writer) error {
+			part, err := form.CreateFormFile("file", "big.txt")
+			if err != nil {
+				return err
+			}
+			_, err = part.Write(bytes.Repeat([]byte("x"), 200))
+			return err
+		}},
+		{"request over the limit", func(form *multipart.Writer) error {
+			return form.WriteField("padding", strings.Repeat("x", multipartOverhead+200))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", "small.txt")
+			if err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			part.Write([]byte("fits"))
+			if err := tt.extra(form); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			form.Close()
+
+			got := serve(h.HandleTaskAttachments, http.MethodPost, path, body.String(),
+				http.Header{"Content-Type": {form.FormDataContentType()}})
+			if got.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("upload = %d %s, want 413", got.Code, got.Body)
+			}
+
+			// The file that fitted isn't kept from the failed upload
+			kept, err := h.attachments.ListAttachments(task.ID)
+			if err != nil {
+				t.Fatalf("ListAttachments() error = %v", err)
+			}
+			if len(kept) != 0 {
+				t.Errorf("ListAttachments() = %d attachments, want none", len(kept))
+			}
+		})
+	}
+}
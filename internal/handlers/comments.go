@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleTaskComments handles /api/tasks/{id}/comments endpoint
+func (h *Handler) HandleTaskComments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "comments" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID := parts[0]
+
+	if taskID == "" {
+		http.Error(w, "Task ID required", http.StatusBadRequest)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listComments(w, taskID)
+	case http.MethodPost:
+		h.addComment(w, r, taskID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTaskComment handles /api/tasks/{id}/comments/{comment_id} endpoint
+func (h *Handler) HandleTaskComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the task and comment from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[1] != "comments" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID, commentID := parts[0], parts[2]
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	comment, err := h.storage.GetComment(commentID)
+	if err == nil && comment.TaskID != taskID {
+		// Comments are only reachable through their own task
+		err = storage.ErrCommentNotFound
+	}
+	if err != nil {
+		writeCommentError(w, err, "Failed to get comment")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(models.NewCommentThread(comment))
+	case http.MethodPut, http.MethodPatch:
+		var body struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		comment.Edit(body.Body)
+		if err := h.storage.UpdateComment(comment); err != nil {
+			writeCommentError(w, err, "Failed to update comment")
+			return
+		}
+		json.NewEncoder(w).Encode(models.NewCommentThread(comment))
+	case http.MethodDelete:
+		if err := h.storage.DeleteComment(commentID); err != nil {
+			writeCommentError(w, err, "Failed to delete comment")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// listComments writes the comments on a task as threads, oldest first
+func (h *Handler) listComments(w http.ResponseWriter, taskID string) {
+	if !h.storage.TaskExists(taskID) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	comments, err := h.storage.ListComments(taskID)
+	if err != nil {
+		http.Error(w, "Failed to list comments", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(models.ThreadComments(comments))
+}
+
+func (h *Handler) addComment(w http.ResponseWriter, r *http.Request, taskID string) {
+	var body struct {
+		AuthorID string `json:"author_id"`
+		ParentID string `json:"parent_id"`
+		Body     string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if body.AuthorID == "" {
+		http.Error(w, "author_id is required", http.StatusBadRequest)
+		return
+	}
+
+	comment := models.NewComment(taskID, body.AuthorID, body.Body)
+	comment.ParentID = body.ParentID
+	if err := h.storage.CreateComment(comment); err != nil {
+		if errors.Is(err, storage.ErrCommentNotFound) {
+			// The missing comment is the parent named in the request
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeCommentError(w, err, "Failed to add comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewCommentThread(comment))
+}
+
+// writeCommentError reports an error from a storage call on comments
+func writeCommentError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidComment), errors.Is(err, storage.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrCommentNotFound):
+		http.Error(w, "Comment not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -2,32 +2,76 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"html"
 	"html/template"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/patch"
+	"github.com/aykay76/projectflow/internal/search"
 	"github.com/aykay76/projectflow/internal/storage"
 )
 
 // Handler handles HTTP requests
 type Handler struct {
-	storage   storage.Storage
-	templates *template.Template
+	storage     storage.Storage
+	attachments storage.AttachmentStore
+	templates   *template.Template
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(storage storage.Storage) *Handler {
+func NewHandler(storage storage.Storage, attachments storage.AttachmentStore) *Handler {
 	// Load templates
 	templates := template.Must(template.ParseGlob("web/templates/*.html"))
 
 	return &Handler{
-		storage:   storage,
-		templates: templates,
+		storage:     storage,
+		attachments: attachments,
+		templates:   templates,
 	}
 }
 
+// Request headers that say who is making a change, for the task history
+const (
+	// userHeader names the user making the request
+	userHeader = "X-User-ID"
+	// clientHeader is set to "web" by the web interface; other requests
+	// come through the API
+	clientHeader = "X-ProjectFlow-Client"
+)
+
+// storageFor returns the storage to make a request's changes through, so
+// they are recorded in the task history as made by the request's actor. A
+// user ID that isn't in the user directory is rejected with 400; false is
+// returned once the response has been written.
+func (h *Handler) storageFor(w http.ResponseWriter, r *http.Request) (storage.Storage, bool) {
+	source := models.SourceAPI
+	if r.Header.Get(clientHeader) == string(models.SourceWeb) {
+		source = models.SourceWeb
+	}
+
+	userID := strings.TrimSpace(r.Header.Get(userHeader))
+	if userID != "" {
+		if _, err := h.storage.GetUser(userID); err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				http.Error(w, "Unknown user in "+userHeader+": "+userID, http.StatusBadRequest)
+			} else {
+				http.Error(w, "Failed to check user", http.StatusInternalServerError)
+			}
+			return nil, false
+		}
+	}
+
+	return storage.WithActor(h.storage, storage.Actor{UserID: userID, Source: source}), true
+}
+
 // HandleIndex serves the main web interface
 func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -40,13 +84,39 @@ func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to load tasks", http.StatusInternalServerError)
 		return
 	}
+	users, err := h.storage.ListUsers()
+	if err != nil {
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
+		return
+	}
+	userNames := make(map[string]string, len(users))
+	for _, user := range users {
+		userNames[user.ID] = user.Name
+	}
+	labels, err := h.storage.ListLabels()
+	if err != nil {
+		http.Error(w, "Failed to load labels", http.StatusInternalServerError)
+		return
+	}
+	labelColors := make(map[string]string, len(labels))
+	for _, label := range labels {
+		labelColors[label.Name] = label.Color
+	}
 
 	data := struct {
-		Tasks []*models.Task
-		Title string
+		Tasks       []*models.Task
+		Users       []*models.User
+		UserNames   map[string]string
+		LabelColors map[string]string
+		Workflow    *models.Workflow
+		Title       string
 	}{
-		Tasks: tasks,
-		Title: "ProjectFlow - Task Management",
+		Tasks:       tasks,
+		Users:       users,
+		UserNames:   userNames,
+		LabelColors: labelColors,
+		Workflow:    models.CurrentWorkflow(),
+		Title:       "ProjectFlow - Task Management",
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "index.html", data); err != nil {
@@ -73,6 +143,93 @@ func (h *Handler) HandleHierarchy(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(hierarchyTasks)
 }
 
+// HandleWorkflow handles /api/workflow endpoint
+func (h *Handler) HandleWorkflow(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.CurrentWorkflow())
+}
+
+// searchSnippetLength is the approximate length of description excerpts
+// returned by /api/search
+const searchSnippetLength = 160
+
+// searchHit is one result of /api/search. TitleHighlight and Snippet are HTML
+// with matched words wrapped in <mark> tags.
+type searchHit struct {
+	Task           *models.Task `json:"task"`
+	Score          float64      `json:"score"`
+	TitleHighlight string       `json:"title_highlight"`
+	Snippet        string       `json:"snippet"`
+}
+
+// HandleSearch handles /api/search endpoint. The list filters label,
+// assignee_id and reporter_id narrow the results.
+func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	values := r.URL.Query()
+	filter, filtered, err := storage.ParseSearchFilter(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var results []*storage.SearchResult
+	if !filtered {
+		results, err = h.storage.SearchTasks(query, limit)
+	} else {
+		// Filter every match so the limit counts only the ones kept
+		results, err = h.storage.SearchTasks(query, 0)
+		results = storage.FilterSearchResults(results, filter, limit)
+	}
+	if err != nil {
+		http.Error(w, "Failed to search tasks", http.StatusInternalServerError)
+		return
+	}
+
+	marker := search.HTMLMarker(html.EscapeString)
+	hits := make([]searchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, searchHit{
+			Task:           result.Task,
+			Score:          result.Score,
+			TitleHighlight: search.Highlight(result.Task.Title, result.TitleMatches, marker),
+			Snippet:        search.Snippet(result.Task.Description, result.DescriptionMatches, searchSnippetLength, marker),
+		})
+	}
+
+	json.NewEncoder(w).Encode(hits)
+}
+
 // HandleTasks handles /api/tasks endpoint
 func (h *Handler) HandleTasks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -100,11 +257,18 @@ func (h *Handler) HandleTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getTask(w, r, taskID)
 	case http.MethodPut:
 		h.updateTask(w, r, taskID)
+	case http.MethodPatch:
+		h.patchTask(w, r, taskID)
 	case http.MethodDelete:
 		h.deleteTask(w, r, taskID)
 	default:
@@ -112,27 +276,70 @@ func (h *Handler) HandleTask(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// resolveTaskID returns the ID of the task named by ref, which can also be
+// the task's key, such as PF-123. It writes the error response and returns
+// false when no task has the key.
+func (h *Handler) resolveTaskID(w http.ResponseWriter, ref string) (string, bool) {
+	id, err := storage.ResolveTaskID(h.storage, ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		}
+		return "", false
+	}
+	return id, true
+}
+
+// listTasks returns the tasks matching the query string as a JSON array. The
+// total match count and the cursor for the next page are sent as headers.
 func (h *Handler) listTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.storage.ListTasks()
+	values := r.URL.Query()
+	query, err := storage.ParseTaskQuery(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.storage.QueryTasks(query)
 	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(tasks)
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	json.NewEncoder(w).Encode(page.Tasks)
 }
 
 func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 	// Use a temporary struct to handle due_date and started_at as strings
 	var taskCreate struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
-		Priority    string `json:"priority"`
-		Type        string `json:"type"`
-		ParentID    string `json:"parent_id"`
-		DueDate     string `json:"due_date"`
-		StartedAt   string `json:"started_at"`
+		Title             string             `json:"title"`
+		Description       string             `json:"description"`
+		Status            string             `json:"status"`
+		Priority          string             `json:"priority"`
+		Type              string             `json:"type"`
+		ParentID          string             `json:"parent_id"`
+		AssigneeID        string             `json:"assignee_id"`
+		ReporterID        string             `json:"reporter_id"`
+		Labels            []string           `json:"labels"`
+		SprintID          string             `json:"sprint_id"`
+		CustomFields      models.FieldValues `json:"custom_fields"`
+		StoryPoints       *float64           `json:"story_points"`
+		OriginalEstimate  string             `json:"original_estimate"`
+		RemainingEstimate string             `json:"remaining_estimate"`
+		DueDate           string             `json:"due_date"`
+		StartedAt         string             `json:"started_at"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&taskCreate); err != nil {
@@ -145,6 +352,18 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 	task.Title = taskCreate.Title
 	task.Description = taskCreate.Description
 	task.ParentID = taskCreate.ParentID
+	task.AssigneeID = taskCreate.AssigneeID
+	task.ReporterID = taskCreate.ReporterID
+	task.Labels = taskCreate.Labels
+	task.SprintID = taskCreate.SprintID
+	task.CustomFields = taskCreate.CustomFields
+	if taskCreate.StoryPoints != nil {
+		task.StoryPoints = *taskCreate.StoryPoints
+	}
+	if err := setEstimates(&task, taskCreate.OriginalEstimate, taskCreate.RemainingEstimate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Handle due_date
 	if taskCreate.DueDate != "" {
@@ -170,12 +389,13 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 
 	// Set defaults if not provided
 	if taskCreate.Status == "" {
-		task.Status = models.StatusTodo
+		task.Status = models.CurrentWorkflow().Initial
 	} else {
 		task.Status = models.TaskStatus(taskCreate.Status)
-		// Auto-set start date if status is in_progress and no start date provided
-		if task.Status == models.StatusInProgress && taskCreate.StartedAt == "" {
-			task.StartTask()
+		// Auto-set start date if work has started and no start date provided
+		if models.CategoryOf(task.Status) == models.CategoryInProgress && taskCreate.StartedAt == "" {
+			now := time.Now()
+			task.StartedAt = &now
 		}
 	}
 	if taskCreate.Priority == "" {
@@ -202,6 +422,14 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid type", http.StatusBadRequest)
 		return
 	}
+	if err := models.CurrentWorkflow().CheckCustomFields(&task); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if task.StoryPoints < 0 {
+		http.Error(w, "Invalid story points", http.StatusBadRequest)
+		return
+	}
 
 	// Set timestamps
 	now := time.Now()
@@ -213,11 +441,29 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 		task.Children = []string{}
 	}
 
-	if err := h.storage.CreateTask(&task); err != nil {
-		http.Error(w, "Failed to create task", http.StatusInternalServerError)
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.CreateTask(&task); err != nil {
+		if errors.Is(err, storage.ErrParentNotFound) {
+			http.Error(w, "Parent task not found", http.StatusBadRequest)
+		} else if errors.Is(err, storage.ErrInvalidParentType) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		} else if errors.Is(err, storage.ErrUserNotFound) ||
+			errors.Is(err, storage.ErrInvalidLabel) ||
+			errors.Is(err, storage.ErrLabelNotFound) ||
+			errors.Is(err, storage.ErrSprintNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else if errors.Is(err, storage.ErrSprintState) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to create task", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	w.Header().Set("ETag", taskETag(&task))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(task)
 }
@@ -233,6 +479,7 @@ func (h *Handler) getTask(w http.ResponseWriter, r *http.Request, taskID string)
 		return
 	}
 
+	w.Header().Set("ETag", taskETag(task))
 	json.NewEncoder(w).Encode(task)
 }
 
@@ -247,17 +494,29 @@ func (h *Handler) updateTask(w http.ResponseWriter, r *http.Request, taskID stri
 		}
 		return
 	}
+	if !ifMatch(r, existingTask) {
+		http.Error(w, "Task has been modified; get it again and reapply your changes", http.StatusPreconditionFailed)
+		return
+	}
 
 	// Use a temporary struct to handle due_date and started_at as strings
 	var taskUpdate struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
-		Priority    string `json:"priority"`
-		Type        string `json:"type"`
-		ParentID    string `json:"parent_id"`
-		DueDate     string `json:"due_date"`
-		StartedAt   string `json:"started_at"`
+		Title             string             `json:"title"`
+		Description       string             `json:"description"`
+		Status            string             `json:"status"`
+		Priority          string             `json:"priority"`
+		Type              string             `json:"type"`
+		ParentID          string             `json:"parent_id"`
+		AssigneeID        string             `json:"assignee_id"`
+		ReporterID        string             `json:"reporter_id"`
+		Labels            []string           `json:"labels"`
+		SprintID          *string            `json:"sprint_id"`
+		CustomFields      models.FieldValues `json:"custom_fields"`
+		StoryPoints       *float64           `json:"story_points"`
+		OriginalEstimate  string             `json:"original_estimate"`
+		RemainingEstimate string             `json:"remaining_estimate"`
+		DueDate           string             `json:"due_date"`
+		StartedAt         string             `json:"started_at"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&taskUpdate); err != nil {
@@ -285,6 +544,28 @@ func (h *Handler) updateTask(w http.ResponseWriter, r *http.Request, taskID stri
 	if taskUpdate.ParentID != "" {
 		task.ParentID = taskUpdate.ParentID
 	}
+	if taskUpdate.AssigneeID != "" {
+		task.AssigneeID = taskUpdate.AssigneeID
+	}
+	if taskUpdate.ReporterID != "" {
+		task.ReporterID = taskUpdate.ReporterID
+	}
+	if taskUpdate.Labels != nil {
+		task.Labels = taskUpdate.Labels
+	}
+	// An empty sprint moves the task back to the backlog
+	if taskUpdate.SprintID != nil {
+		task.SprintID = *taskUpdate.SprintID
+	}
+	// Custom fields are merged in, and a null value removes one
+	task.SetCustomFields(taskUpdate.CustomFields)
+	if taskUpdate.StoryPoints != nil {
+		task.StoryPoints = *taskUpdate.StoryPoints
+	}
+	if err := setEstimates(&task, taskUpdate.OriginalEstimate, taskUpdate.RemainingEstimate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Handle due_date
 	if taskUpdate.DueDate != "" {
@@ -302,8 +583,8 @@ func (h *Handler) updateTask(w http.ResponseWriter, r *http.Request, taskID stri
 		}
 	}
 
-	// Auto-set start date if status changes to in_progress and no start date provided
-	if task.Status == models.StatusInProgress && task.StartedAt == nil {
+	// Auto-set start date if status changes to an in progress status and no start date provided
+	if models.CategoryOf(task.Status) == models.CategoryInProgress && task.StartedAt == nil {
 		now := time.Now()
 		task.StartedAt = &now
 	}
@@ -325,22 +606,155 @@ func (h *Handler) updateTask(w http.ResponseWriter, r *http.Request, taskID stri
 		http.Error(w, "Invalid type", http.StatusBadRequest)
 		return
 	}
+	if taskUpdate.CustomFields != nil || taskUpdate.Type != "" {
+		if err := models.CurrentWorkflow().CheckCustomFields(&task); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if taskUpdate.StoryPoints != nil && task.StoryPoints < 0 {
+		http.Error(w, "Invalid story points", http.StatusBadRequest)
+		return
+	}
+
+	// The stored version read above guards against concurrent writes
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.UpdateTask(&task); err != nil {
+		writeRelationshipError(w, err, "Failed to update task")
+		return
+	}
+
+	w.Header().Set("ETag", taskETag(&task))
+	json.NewEncoder(w).Encode(task)
+}
 
-	if err := h.storage.UpdateTask(&task); err != nil {
+// setEstimates sets the estimates given as strings like "2h30m"; empty
+// strings leave them unchanged
+func setEstimates(task *models.Task, original, remaining string) error {
+	if original != "" {
+		estimate, err := models.ParseEstimate(original)
+		if err != nil {
+			return err
+		}
+		task.OriginalEstimate = estimate
+	}
+	if remaining != "" {
+		estimate, err := models.ParseEstimate(remaining)
+		if err != nil {
+			return err
+		}
+		task.RemainingEstimate = estimate
+	}
+	return nil
+}
+
+// patchTask applies a JSON Merge Patch or JSON Patch document to a task,
+// chosen by the Content-Type. Unlike PUT, a patch can clear fields.
+func (h *Handler) patchTask(w http.ResponseWriter, r *http.Request, taskID string) {
+	existingTask, err := h.storage.GetTask(taskID)
+	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, "Task not found", http.StatusNotFound)
 		} else {
-			http.Error(w, "Failed to update task", http.StatusInternalServerError)
+			http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !ifMatch(r, existingTask) {
+		http.Error(w, "Task has been modified; get it again and reapply your changes", http.StatusPreconditionFailed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var p patch.Patch
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case patch.MergePatchType, "application/json", "":
+		p, err = patch.ParseMergePatch(body)
+	case patch.JSONPatchType:
+		p, err = patch.ParseJSONPatch(body)
+	default:
+		w.Header().Set("Accept-Patch", patch.MergePatchType+", "+patch.JSONPatchType)
+		http.Error(w, "Unsupported patch format", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task, err := patch.Task(existingTask, p)
+	if err != nil {
+		switch {
+		case errors.Is(err, patch.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, patch.ErrTestFailed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, patch.ErrCannotApply), errors.Is(err, patch.ErrInvalidTask):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, "Failed to patch task", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.UpdateTask(task); err != nil {
+		writeRelationshipError(w, err, "Failed to update task")
+		return
+	}
+
+	w.Header().Set("ETag", taskETag(task))
 	json.NewEncoder(w).Encode(task)
 }
 
 func (h *Handler) deleteTask(w http.ResponseWriter, r *http.Request, taskID string) {
-	if err := h.storage.DeleteTask(taskID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
+	strategy, err := storage.ParseDeleteStrategy(r.URL.Query().Get("strategy"))
+	if err != nil {
+		http.Error(w, "Invalid strategy. Use cascade, reparent-to-grandparent or orphan-to-root", http.StatusBadRequest)
+		return
+	}
+
+	var expectedVersion int64
+	if r.Header.Get("If-Match") != "" {
+		task, err := h.storage.GetTask(taskID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				http.Error(w, "Task not found", http.StatusNotFound)
+			} else {
+				http.Error(w, "Failed to get task", http.StatusInternalServerError)
+			}
+			return
+		}
+		if !ifMatch(r, task) {
+			http.Error(w, "Task has been modified; get it again before deleting", http.StatusPreconditionFailed)
+			return
+		}
+		expectedVersion = task.Version
+	}
+
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	result, err := store.DeleteTaskWithStrategy(taskID, strategy, expectedVersion)
+	if err != nil {
+		if errors.Is(err, storage.ErrVersionConflict) {
+			http.Error(w, "Task has been modified; get it again before deleting", http.StatusPreconditionFailed)
+		} else if errors.Is(err, storage.ErrInvalidParentType) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		} else if strings.Contains(err.Error(), "not found") {
 			http.Error(w, "Task not found", http.StatusNotFound)
 		} else {
 			http.Error(w, "Failed to delete task", http.StatusInternalServerError)
@@ -348,7 +762,12 @@ func (h *Handler) deleteTask(w http.ResponseWriter, r *http.Request, taskID stri
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	// The tasks are gone, so a failure here only leaves files behind
+	if err := storage.DeleteAttachmentsOf(h.attachments, result); err != nil {
+		log.Printf("Error deleting attachments: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(result)
 }
 
 // HandleTaskChildren handles /api/tasks/{id}/children endpoint
@@ -368,6 +787,10 @@ func (h *Handler) HandleTaskChildren(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Parent task ID required", http.StatusBadRequest)
 		return
 	}
+	parentID, ok := h.resolveTaskID(w, parentID)
+	if !ok {
+		return
+	}
 
 	switch r.Method {
 	case http.MethodGet:
@@ -397,6 +820,14 @@ func (h *Handler) HandleTaskChildRelation(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Parent and child task IDs required", http.StatusBadRequest)
 		return
 	}
+	parentID, ok := h.resolveTaskID(w, parentID)
+	if !ok {
+		return
+	}
+	childID, ok = h.resolveTaskID(w, childID)
+	if !ok {
+		return
+	}
 
 	switch r.Method {
 	case http.MethodDelete:
@@ -423,6 +854,10 @@ func (h *Handler) HandleTaskMove(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Task ID required", http.StatusBadRequest)
 		return
 	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
 
 	switch r.Method {
 	case http.MethodPut:
@@ -460,10 +895,13 @@ func (h *Handler) addTaskChild(w http.ResponseWriter, r *http.Request, parentID
 		http.Error(w, "child_id is required", http.StatusBadRequest)
 		return
 	}
+	childID, ok := h.resolveTaskID(w, request.ChildID)
+	if !ok {
+		return
+	}
 
 	// Verify both tasks exist
-	parentTask, err := h.storage.GetTask(parentID)
-	if err != nil {
+	if _, err := h.storage.GetTask(parentID); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, "Parent task not found", http.StatusNotFound)
 		} else {
@@ -472,7 +910,7 @@ func (h *Handler) addTaskChild(w http.ResponseWriter, r *http.Request, parentID
 		return
 	}
 
-	childTask, err := h.storage.GetTask(request.ChildID)
+	childTask, err := h.storage.GetTask(childID)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			http.Error(w, "Child task not found", http.StatusNotFound)
@@ -482,34 +920,22 @@ func (h *Handler) addTaskChild(w http.ResponseWriter, r *http.Request, parentID
 		return
 	}
 
-	// Check for circular references
-	if h.wouldCreateCircularReference(parentID, request.ChildID) {
-		http.Error(w, "Operation would create circular reference", http.StatusBadRequest)
-		return
-	}
-
-	// Remove child from its current parent if it has one
-	if childTask.ParentID != "" {
-		currentParent, err := h.storage.GetTask(childTask.ParentID)
-		if err == nil {
-			currentParent.RemoveChild(request.ChildID)
-			h.storage.UpdateTask(currentParent)
-		}
-	}
-
-	// Add child to new parent
-	parentTask.AddChild(request.ChildID)
+	// Storage moves the child out of its current parent and into the new one
 	childTask.ParentID = parentID
 	childTask.UpdatedAt = time.Now()
 
-	// Update both tasks
-	if err := h.storage.UpdateTask(parentTask); err != nil {
-		http.Error(w, "Failed to update parent task", http.StatusInternalServerError)
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.UpdateTask(childTask); err != nil {
+		writeRelationshipError(w, err, "Failed to update child task")
 		return
 	}
 
-	if err := h.storage.UpdateTask(childTask); err != nil {
-		http.Error(w, "Failed to update child task", http.StatusInternalServerError)
+	parentTask, err := h.storage.GetTask(parentID)
+	if err != nil {
+		http.Error(w, "Failed to get parent task", http.StatusInternalServerError)
 		return
 	}
 
@@ -559,24 +985,27 @@ func (h *Handler) removeTaskChild(w http.ResponseWriter, r *http.Request, parent
 		}
 	}
 
-	if !childExists {
+	if !childExists || childTask.ParentID != parentID {
 		http.Error(w, "Child relationship does not exist", http.StatusBadRequest)
 		return
 	}
 
-	// Remove the relationship
-	parentTask.RemoveChild(childID)
+	// Remove the relationship; storage updates the parent's children
 	childTask.ParentID = ""
 	childTask.UpdatedAt = time.Now()
 
-	// Update both tasks
-	if err := h.storage.UpdateTask(parentTask); err != nil {
-		http.Error(w, "Failed to update parent task", http.StatusInternalServerError)
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.UpdateTask(childTask); err != nil {
+		writeRelationshipError(w, err, "Failed to update child task")
 		return
 	}
 
-	if err := h.storage.UpdateTask(childTask); err != nil {
-		http.Error(w, "Failed to update child task", http.StatusInternalServerError)
+	parentTask, err = h.storage.GetTask(parentID)
+	if err != nil {
+		http.Error(w, "Failed to get parent task", http.StatusInternalServerError)
 		return
 	}
 
@@ -615,52 +1044,37 @@ func (h *Handler) moveTask(w http.ResponseWriter, r *http.Request, taskID string
 	}
 
 	// If new parent ID is provided, verify it exists
-	var newParent *models.Task
 	if request.NewParentID != "" {
-		newParent, err = h.storage.GetTask(request.NewParentID)
-		if err != nil {
-			if strings.Contains(err.Error(), "not found") {
-				http.Error(w, "New parent task not found", http.StatusNotFound)
-			} else {
-				http.Error(w, "Failed to get new parent task", http.StatusInternalServerError)
-			}
-			return
-		}
-
-		// Check for circular references
-		if h.wouldCreateCircularReference(request.NewParentID, taskID) {
-			http.Error(w, "Operation would create circular reference", http.StatusBadRequest)
-			return
-		}
-	}
-
-	// Remove task from current parent if it has one
-	if task.ParentID != "" {
-		currentParent, err := h.storage.GetTask(task.ParentID)
-		if err == nil {
-			currentParent.RemoveChild(taskID)
-			h.storage.UpdateTask(currentParent)
-		}
-	}
-
-	// Add task to new parent if specified
-	if newParent != nil {
-		newParent.AddChild(taskID)
-		if err := h.storage.UpdateTask(newParent); err != nil {
-			http.Error(w, "Failed to update new parent task", http.StatusInternalServerError)
+		newParentID, err := storage.ResolveTaskID(h.storage, request.NewParentID)
+		if err != nil || !h.storage.TaskExists(newParentID) {
+			http.Error(w, "New parent task not found", http.StatusNotFound)
 			return
 		}
+		request.NewParentID = newParentID
 	}
 
-	// Update the task's parent ID
+	// Storage updates the old and new parents' children
 	task.ParentID = request.NewParentID
 	task.UpdatedAt = time.Now()
 
-	if err := h.storage.UpdateTask(task); err != nil {
-		http.Error(w, "Failed to update task", http.StatusInternalServerError)
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.UpdateTask(task); err != nil {
+		writeRelationshipError(w, err, "Failed to update task")
 		return
 	}
 
+	var newParent *models.Task
+	if request.NewParentID != "" {
+		newParent, err = h.storage.GetTask(request.NewParentID)
+		if err != nil {
+			http.Error(w, "Failed to get new parent task", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	response := struct {
 		Message   string       `json:"message"`
 		Task      *models.Task `json:"task"`
@@ -674,33 +1088,53 @@ func (h *Handler) moveTask(w http.ResponseWriter, r *http.Request, taskID string
 	json.NewEncoder(w).Encode(response)
 }
 
-// wouldCreateCircularReference checks if making childID a child of parentID would create a circular reference
-func (h *Handler) wouldCreateCircularReference(parentID, childID string) bool {
-	// If parent and child are the same, it's circular
-	if parentID == childID {
-		return true
+// writeRelationshipError reports an error from a storage call that may have
+// changed a task's parent
+func writeRelationshipError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrVersionConflict):
+		http.Error(w, "Task has been modified; get it again and reapply your changes", http.StatusPreconditionFailed)
+	case errors.Is(err, storage.ErrTaskBlocked),
+		errors.Is(err, storage.ErrTransitionNotAllowed),
+		errors.Is(err, storage.ErrGuardFailed),
+		errors.Is(err, storage.ErrSprintState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrCircularReference):
+		http.Error(w, "Operation would create circular reference", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrInvalidParentType):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, storage.ErrParentNotFound):
+		http.Error(w, "Parent task not found", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrInvalidLabel),
+		errors.Is(err, storage.ErrLabelNotFound),
+		errors.Is(err, storage.ErrSprintNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
 	}
+}
 
-	// Check if parentID is already a descendant of childID
-	return h.isDescendant(childID, parentID)
+// taskETag returns the entity tag for the task's current version
+func taskETag(task *models.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
 }
 
-// isDescendant checks if ancestorID is a descendant of taskID
-func (h *Handler) isDescendant(taskID, ancestorID string) bool {
-	task, err := h.storage.GetTask(taskID)
-	if err != nil {
-		return false
+// ifMatch reports whether the request's If-Match header allows writing task.
+// A missing header or "*" always matches; weak tags never do.
+func ifMatch(r *http.Request, task *models.Task) bool {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return true
 	}
 
-	// Check all children recursively
-	for _, childID := range task.Children {
-		if childID == ancestorID {
-			return true
-		}
-		if h.isDescendant(childID, ancestorID) {
+	etag := taskETag(task)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == etag {
 			return true
 		}
 	}
-
 	return false
 }
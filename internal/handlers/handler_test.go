@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/patch"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// newTestHandler returns a handler over empty file storage. The page
+// templates aren't loaded, so only the API endpoints can be served.
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	dataDir := t.TempDir()
+	store, err := storage.NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	attachments, err := storage.NewFileAttachmentStore(dataDir, 0)
+	if err != nil {
+		t.Fatalf("Failed to create attachment store: %v", err)
+	}
+	return &Handler{storage: store, attachments: attachments}
+}
+
+// createTestTask stores a new task with title
+func createTestTask(t *testing.T, h *Handler, title string) *models.Task {
+	t.Helper()
+	task := models.NewTask(title, "")
+	if err := h.storage.CreateTask(task); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	return task
+}
+
+// serve sends a request to handle and returns the recorded response
+func serve(handle http.HandlerFunc, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handle(w, r)
+	return w
+}
+
+func TestHandleTask_IfMatch(t *testing.T) {
+	h := newTestHandler(t)
+	task := createTestTask(t, h, "Original")
+	path := "/api/tasks/" + task.ID
+
+	got := serve(h.HandleTask, http.MethodGet, path, "", nil)
+	etag := got.Header().Get("ETag")
+	if got.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("GET = %d with ETag %s, want 200 with \"1\"", got.Code, etag)
+	}
+
+	// A write with the current tag succeeds and returns the next one
+	got = serve(h.HandleTask, http.MethodPut, path, `{"title": "Renamed"}`, http.Header{"If-Match": {etag}})
+	if got.Code != http.StatusOK {
+		t.Fatalf("PUT with current ETag = %d %s, want 200", got.Code, got.Body)
+	}
+	next := got.Header().Get("ETag")
+	if next != `"2"` {
+		t.Errorf("PUT ETag = %s, want \"2\"", next)
+	}
+
+	// Every write with the stale tag is refused
+	stale := http.Header{"If-Match": {etag}}
+	for _, write := range []struct {
+		method, body string
+		header       http.Header
+	}{
+		{http.MethodPut, `{"title": "Lost update"}`, stale},
+		{http.MethodPatch, `{"title": "Lost update"}`, http.Header{"If-Match": {etag}, "Content-Type": {patch.MergePatchType}}},
+		{http.MethodDelete, "", stale},
+	} {
+		if got := serve(h.HandleTask, write.method, path, write.body, write.header); got.Code != http.StatusPreconditionFailed {
+			t.Errorf("%s with stale ETag = %d, want 412", write.method, got.Code)
+		}
+	}
+
+	got = serve(h.HandleTask, http.MethodGet, path, "", nil)
+	var stored models.Task
+	if err := json.NewDecoder(got.Body).Decode(&stored); err != nil {
+		t.Fatalf("GET body error = %v", err)
+	}
+	if got.Header().Get("ETag") != next || stored.Title != "Renamed" {
+		t.Errorf("GET = %q with ETag %s, want \"Renamed\" with %s", stored.Title, got.Header().Get("ETag"), next)
+	}
+
+	// A list of tags, or *, matches the current version
+	got = serve(h.HandleTask, http.MethodPatch, path, `{"title": "Listed"}`,
+		http.Header{"If-Match": {`"7", ` + next}, "Content-Type": {patch.MergePatchType}})
+	if got.Code != http.StatusOK {
+		t.Errorf("PATCH with a matching tag in a list = %d %s, want 200", got.Code, got.Body)
+	}
+	got = serve(h.HandleTask, http.MethodPut, path, `{"title": "Any"}`, http.Header{"If-Match": {"*"}})
+	if got.Code != http.StatusOK {
+		t.Errorf("PUT with If-Match * = %d %s, want 200", got.Code, got.Body)
+	}
+}
+
+func TestHandleTask_PatchContentType(t *testing.T) {
+	h := newTestHandler(t)
+	task := createTestTask(t, h, "Original")
+	path := "/api/tasks/" + task.ID
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    int
+		wantTitle   string
+	}{
+		{"merge patch", patch.MergePatchType, `{"title": "Merged"}`, http.StatusOK, "Merged"},
+		{"plain JSON is a merge patch", "application/json; charset=utf-8", `{"title": "Plain"}`, http.StatusOK, "Plain"},
+		{"JSON patch", patch.JSONPatchType, `[{"op": "replace", "path": "/title", "value": "Replaced"}]`, http.StatusOK, "Replaced"},
+		{"JSON patch sent as a merge patch", patch.MergePatchType, `[{"op": "replace", "path": "/title", "value": "Wrong"}]`, http.StatusUnprocessableEntity, "Replaced"},
+		{"failed test operation", patch.JSONPatchType, `[{"op": "test", "path": "/title", "value": "Other"}]`, http.StatusConflict, "Replaced"},
+		{"unsupported type", "text/plain", `title=Text`, http.StatusUnsupportedMediaType, "Replaced"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(h.HandleTask, http.MethodPatch, path, tt.body, http.Header{"Content-Type": {tt.contentType}})
+			if got.Code != tt.wantCode {
+				t.Errorf("PATCH = %d %s, want %d", got.Code, got.Body, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusUnsupportedMediaType {
+				if accept := got.Header().Get("Accept-Patch"); !strings.Contains(accept, patch.MergePatchType) || !strings.Contains(accept, patch.JSONPatchType) {
+					t.Errorf("Accept-Patch = %q, want both patch types", accept)
+				}
+			}
+
+			stored, err := h.storage.GetTask(task.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if stored.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", stored.Title, tt.wantTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// defaultActivityLimit is the number of events the activity feed returns
+// when the request doesn't give a limit
+const defaultActivityLimit = 50
+
+// HandleTaskHistory handles /api/tasks/{id}/history endpoint, which lists
+// the changes to a task, newest first. The history of a deleted task can
+// still be read.
+func (h *Handler) HandleTaskHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	taskID, ok := h.resolveTaskID(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	values := r.URL.Query()
+	query, err := storage.ParseEventQuery(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.TaskID = taskID
+
+	events, err := h.storage.ListEvents(query)
+	if err != nil {
+		http.Error(w, "Failed to get task history", http.StatusInternalServerError)
+		return
+	}
+	if len(events) == 0 && !h.storage.TaskExists(taskID) {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(events)
+}
+
+// HandleActivity handles /api/activity endpoint, the feed of changes to
+// every task, newest first
+func (h *Handler) HandleActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	query, err := storage.ParseEventQuery(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !values.Has("limit") {
+		query.Limit = defaultActivityLimit
+	}
+
+	events, err := h.storage.ListEvents(query)
+	if err != nil {
+		http.Error(w, "Failed to list activity", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(events)
+}
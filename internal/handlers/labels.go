@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleLabels handles /api/labels endpoint
+func (h *Handler) HandleLabels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		labels, err := h.storage.ListLabels()
+		if err != nil {
+			http.Error(w, "Failed to list labels", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(labels)
+	case http.MethodPost:
+		h.createLabel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleLabel handles /api/labels/{name} endpoint
+func (h *Handler) HandleLabel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract label name from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/labels/")
+	name := models.NormalizeLabelName(strings.Split(path, "/")[0])
+
+	if name == "" {
+		http.Error(w, "Label name required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		label, err := h.storage.GetLabel(name)
+		if err != nil {
+			writeLabelError(w, err, "Failed to get label")
+			return
+		}
+		json.NewEncoder(w).Encode(label)
+	case http.MethodPut:
+		h.updateLabel(w, r, name)
+	case http.MethodDelete:
+		store, ok := h.storageFor(w, r)
+		if !ok {
+			return
+		}
+		if err := store.DeleteLabel(name); err != nil {
+			writeLabelError(w, err, "Failed to delete label")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) createLabel(w http.ResponseWriter, r *http.Request) {
+	var label models.Label
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if label.Color == "" {
+		label.Color = models.DefaultLabelColor
+	}
+
+	if err := h.storage.CreateLabel(&label); err != nil {
+		writeLabelError(w, err, "Failed to create label")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(label)
+}
+
+func (h *Handler) updateLabel(w http.ResponseWriter, r *http.Request, name string) {
+	label, err := h.storage.GetLabel(name)
+	if err != nil {
+		writeLabelError(w, err, "Failed to get label")
+		return
+	}
+
+	var labelUpdate struct {
+		Color       string  `json:"color"`
+		Description *string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&labelUpdate); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Only update provided fields; an empty description clears it
+	if labelUpdate.Color != "" {
+		label.Color = labelUpdate.Color
+	}
+	if labelUpdate.Description != nil {
+		label.Description = *labelUpdate.Description
+	}
+
+	if err := h.storage.UpdateLabel(label); err != nil {
+		writeLabelError(w, err, "Failed to update label")
+		return
+	}
+
+	json.NewEncoder(w).Encode(label)
+}
+
+// HandleTaskLabels handles /api/tasks/{id}/labels endpoint
+func (h *Handler) HandleTaskLabels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "labels" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID := parts[0]
+
+	if taskID == "" {
+		http.Error(w, "Task ID required", http.StatusBadRequest)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getTaskLabels(w, taskID)
+	case http.MethodPost:
+		var body struct {
+			Labels []string `json:"labels"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		h.labelTask(w, r, taskID, body.Labels, nil)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTaskLabel handles /api/tasks/{id}/labels/{name} endpoint
+func (h *Handler) HandleTaskLabel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the task and label from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[1] != "labels" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID, name := parts[0], parts[2]
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	h.labelTask(w, r, taskID, nil, []string{name})
+}
+
+// HandleBulkLabels handles /api/tasks/labels endpoint, which adds and
+// removes labels on several tasks at once
+func (h *Handler) HandleBulkLabels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		TaskIDs []string `json:"task_ids"`
+		Add     []string `json:"add"`
+		Remove  []string `json:"remove"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(body.TaskIDs) == 0 {
+		http.Error(w, "task_ids is required", http.StatusBadRequest)
+		return
+	}
+	for i, ref := range body.TaskIDs {
+		taskID, ok := h.resolveTaskID(w, ref)
+		if !ok {
+			return
+		}
+		body.TaskIDs[i] = taskID
+	}
+
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	tasks, err := store.LabelTasks(body.TaskIDs, body.Add, body.Remove)
+	if err != nil {
+		writeLabelError(w, err, "Failed to update labels")
+		return
+	}
+
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// labelTask adds and removes labels on one task and writes the task
+func (h *Handler) labelTask(w http.ResponseWriter, r *http.Request, taskID string, add, remove []string) {
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	tasks, err := store.LabelTasks([]string{taskID}, add, remove)
+	if err != nil {
+		writeLabelError(w, err, "Failed to update labels")
+		return
+	}
+
+	w.Header().Set("ETag", taskETag(tasks[0]))
+	json.NewEncoder(w).Encode(tasks[0])
+}
+
+// getTaskLabels writes the registry entries of a task's labels
+func (h *Handler) getTaskLabels(w http.ResponseWriter, taskID string) {
+	task, err := h.storage.GetTask(taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	labels := make([]*models.Label, 0, len(task.Labels))
+	for _, name := range task.Labels {
+		label, err := h.storage.GetLabel(name)
+		if err != nil {
+			// Report labels missing from the registry by name alone
+			label = &models.Label{Name: name, Color: models.DefaultLabelColor}
+		}
+		labels = append(labels, label)
+	}
+
+	json.NewEncoder(w).Encode(labels)
+}
+
+// writeLabelError reports an error from a storage call on labels
+func writeLabelError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidLabel):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrLabelExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrLabelNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
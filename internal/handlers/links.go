@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// taskLink is a link as returned by the API, with a summary of the linked task
+type taskLink struct {
+	Type   models.LinkType   `json:"type"`
+	TaskID string            `json:"task_id"`
+	Title  string            `json:"title"`
+	Status models.TaskStatus `json:"status"`
+}
+
+// HandleTaskLinks handles /api/tasks/{id}/links endpoint
+func (h *Handler) HandleTaskLinks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[1] != "links" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID := parts[0]
+
+	if taskID == "" {
+		http.Error(w, "Task ID required", http.StatusBadRequest)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeTaskLinks(w, taskID, http.StatusOK)
+	case http.MethodPost:
+		h.addTaskLink(w, r, taskID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTaskLink handles /api/tasks/{id}/links/{type}/{task_id} endpoint
+func (h *Handler) HandleTaskLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract the task, link type and linked task from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 4 || parts[1] != "links" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	taskID, linkType, targetID := parts[0], models.LinkType(parts[2]), parts[3]
+
+	if taskID == "" || targetID == "" {
+		http.Error(w, "Task IDs required", http.StatusBadRequest)
+		return
+	}
+	taskID, ok := h.resolveTaskID(w, taskID)
+	if !ok {
+		return
+	}
+	targetID, ok = h.resolveTaskID(w, targetID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		store, ok := h.storageFor(w, r)
+		if !ok {
+			return
+		}
+		if err := store.UnlinkTasks(taskID, linkType, targetID); err != nil {
+			writeLinkError(w, err, "Failed to remove link")
+			return
+		}
+		h.writeTaskLinks(w, taskID, http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) addTaskLink(w http.ResponseWriter, r *http.Request, taskID string) {
+	var request struct {
+		Type   string `json:"type"`
+		TaskID string `json:"task_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if request.TaskID == "" {
+		http.Error(w, "task_id is required", http.StatusBadRequest)
+		return
+	}
+	if !models.IsValidLinkType(request.Type) {
+		http.Error(w, "Invalid type. Use blocks, blocked_by, relates_to, duplicates or duplicated_by", http.StatusBadRequest)
+		return
+	}
+	targetID, ok := h.resolveTaskID(w, request.TaskID)
+	if !ok {
+		return
+	}
+
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	if err := store.LinkTasks(taskID, models.LinkType(request.Type), targetID); err != nil {
+		writeLinkError(w, err, "Failed to create link")
+		return
+	}
+
+	h.writeTaskLinks(w, taskID, http.StatusCreated)
+}
+
+// writeTaskLinks responds with the links of taskID
+func (h *Handler) writeTaskLinks(w http.ResponseWriter, taskID string, status int) {
+	task, err := h.storage.GetTask(taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get task", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	links := make([]taskLink, 0, len(task.Links))
+	for _, link := range task.Links {
+		linked, err := h.storage.GetTask(link.TaskID)
+		if err != nil {
+			continue
+		}
+		links = append(links, taskLink{
+			Type:   link.Type,
+			TaskID: link.TaskID,
+			Title:  linked.Title,
+			Status: linked.Status,
+		})
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(links)
+}
+
+// writeLinkError reports an error from LinkTasks or UnlinkTasks
+func writeLinkError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrDependencyCycle):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrInvalidLink):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrLinkNotFound):
+		http.Error(w, "Link not found", http.StatusNotFound)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleMilestones handles /api/milestones endpoint
+func (h *Handler) HandleMilestones(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		reports, err := h.milestoneReports()
+		if err != nil {
+			http.Error(w, "Failed to list milestones", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(reports)
+	case http.MethodPost:
+		h.createMilestone(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleMilestone handles /api/milestones/{id} endpoint
+func (h *Handler) HandleMilestone(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/milestones/")
+	id := strings.Split(path, "/")[0]
+	if id == "" {
+		http.Error(w, "Milestone ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		milestone, err := h.storage.GetMilestone(id)
+		if err != nil {
+			writeMilestoneError(w, err, "Failed to get milestone")
+			return
+		}
+		h.writeMilestoneReport(w, milestone)
+	case http.MethodPut:
+		h.updateMilestone(w, r, id)
+	case http.MethodDelete:
+		if err := h.storage.DeleteMilestone(id); err != nil {
+			writeMilestoneError(w, err, "Failed to delete milestone")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) createMilestone(w http.ResponseWriter, r *http.Request) {
+	var milestoneCreate struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		TargetDate  string   `json:"target_date"`
+		TaskIDs     []string `json:"task_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&milestoneCreate); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	milestone := models.NewMilestone(milestoneCreate.Name, milestoneCreate.Description, time.Time{})
+	if err := milestone.SetTargetDate(milestoneCreate.TargetDate); err != nil {
+		http.Error(w, "Invalid target date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	milestone.TaskIDs = milestoneCreate.TaskIDs
+
+	if err := h.storage.CreateMilestone(milestone); err != nil {
+		writeMilestoneError(w, err, "Failed to create milestone")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	h.writeMilestoneReport(w, milestone)
+}
+
+func (h *Handler) updateMilestone(w http.ResponseWriter, r *http.Request, id string) {
+	milestone, err := h.storage.GetMilestone(id)
+	if err != nil {
+		writeMilestoneError(w, err, "Failed to get milestone")
+		return
+	}
+
+	var milestoneUpdate struct {
+		Name        string    `json:"name"`
+		Description *string   `json:"description"`
+		TargetDate  string    `json:"target_date"`
+		TaskIDs     *[]string `json:"task_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&milestoneUpdate); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Only update provided fields; task_ids replaces the linked tasks
+	if milestoneUpdate.Name != "" {
+		milestone.Name = milestoneUpdate.Name
+	}
+	if milestoneUpdate.Description != nil {
+		milestone.Description = *milestoneUpdate.Description
+	}
+	if milestoneUpdate.TargetDate != "" {
+		if err := milestone.SetTargetDate(milestoneUpdate.TargetDate); err != nil {
+			http.Error(w, "Invalid target date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if milestoneUpdate.TaskIDs != nil {
+		milestone.TaskIDs = *milestoneUpdate.TaskIDs
+	}
+
+	if err := h.storage.UpdateMilestone(milestone); err != nil {
+		writeMilestoneError(w, err, "Failed to update milestone")
+		return
+	}
+
+	h.writeMilestoneReport(w, milestone)
+}
+
+// writeMilestoneReport writes a milestone with its progress
+func (h *Handler) writeMilestoneReport(w http.ResponseWriter, milestone *models.Milestone) {
+	tasks, err := h.storage.ListTasks()
+	if err != nil {
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(models.NewMilestoneReport(milestone, tasks, time.Now()))
+}
+
+// milestoneReports returns every milestone with its progress, ordered by
+// target date
+func (h *Handler) milestoneReports() ([]*models.MilestoneReport, error) {
+	milestones, err := h.storage.ListMilestones()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := h.storage.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reports := make([]*models.MilestoneReport, 0, len(milestones))
+	for _, milestone := range milestones {
+		reports = append(reports, models.NewMilestoneReport(milestone, tasks, now))
+	}
+	return reports, nil
+}
+
+// writeMilestoneError reports an error from a storage call on milestones
+func writeMilestoneError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidMilestone):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrMilestoneNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleEstimateReport handles /api/reports/estimates endpoint. It compares
+// the original estimates of finished tasks with the time they took; the
+// GET /api/tasks filters choose the tasks to include.
+func (h *Handler) HandleEstimateReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	query, err := storage.ParseTaskQuery(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.storage.QueryTasks(query)
+	if err != nil {
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.NewAccuracyReport(page.Tasks))
+}
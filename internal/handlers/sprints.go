@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleSprints handles /api/sprints endpoint
+func (h *Handler) HandleSprints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		state := r.URL.Query().Get("state")
+		if state != "" && !models.IsValidSprintState(state) {
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+		h.listSprints(w, models.SprintState(state))
+	case http.MethodPost:
+		h.createSprint(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleSprint handles /api/sprints/{id} endpoint
+func (h *Handler) HandleSprint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := sprintID(r)
+	if id == "" {
+		http.Error(w, "Sprint ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sprint, err := h.storage.GetSprint(id)
+		if err != nil {
+			writeSprintError(w, err, "Failed to get sprint")
+			return
+		}
+		json.NewEncoder(w).Encode(sprint)
+	case http.MethodPut:
+		h.updateSprint(w, r, id)
+	case http.MethodDelete:
+		store, ok := h.storageFor(w, r)
+		if !ok {
+			return
+		}
+		if err := store.DeleteSprint(id); err != nil {
+			writeSprintError(w, err, "Failed to delete sprint")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleStartSprint handles /api/sprints/{id}/start endpoint
+func (h *Handler) HandleStartSprint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sprint, err := h.storage.StartSprint(sprintID(r))
+	if err != nil {
+		writeSprintError(w, err, "Failed to start sprint")
+		return
+	}
+	json.NewEncoder(w).Encode(sprint)
+}
+
+// HandleCloseSprint handles /api/sprints/{id}/close endpoint. The body may
+// name the sprint to carry unfinished tasks over to.
+func (h *Handler) HandleCloseSprint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		NextSprintID string `json:"next_sprint_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, ok := h.storageFor(w, r)
+	if !ok {
+		return
+	}
+	sprint, err := store.CloseSprint(sprintID(r), body.NextSprintID)
+	if err != nil {
+		writeSprintError(w, err, "Failed to close sprint")
+		return
+	}
+	json.NewEncoder(w).Encode(sprint)
+}
+
+func (h *Handler) listSprints(w http.ResponseWriter, state models.SprintState) {
+	sprints, err := h.storage.ListSprints()
+	if err != nil {
+		http.Error(w, "Failed to list sprints", http.StatusInternalServerError)
+		return
+	}
+
+	if state != "" {
+		filtered := []*models.Sprint{}
+		for _, sprint := range sprints {
+			if sprint.State == state {
+				filtered = append(filtered, sprint)
+			}
+		}
+		sprints = filtered
+	}
+	json.NewEncoder(w).Encode(sprints)
+}
+
+func (h *Handler) createSprint(w http.ResponseWriter, r *http.Request) {
+	var sprintCreate struct {
+		Name      string `json:"name"`
+		Goal      string `json:"goal"`
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&sprintCreate); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	sprint := models.NewSprint(sprintCreate.Name, sprintCreate.Goal, time.Time{}, time.Time{})
+	if err := sprint.SetStartDate(sprintCreate.StartDate); err != nil {
+		http.Error(w, "Invalid start date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if err := sprint.SetEndDate(sprintCreate.EndDate); err != nil {
+		http.Error(w, "Invalid end date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.storage.CreateSprint(sprint); err != nil {
+		writeSprintError(w, err, "Failed to create sprint")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sprint)
+}
+
+func (h *Handler) updateSprint(w http.ResponseWriter, r *http.Request, id string) {
+	sprint, err := h.storage.GetSprint(id)
+	if err != nil {
+		writeSprintError(w, err, "Failed to get sprint")
+		return
+	}
+
+	var sprintUpdate struct {
+		Name      string  `json:"name"`
+		Goal      *string `json:"goal"`
+		StartDate string  `json:"start_date"`
+		EndDate   string  `json:"end_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&sprintUpdate); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Only update provided fields; an empty goal clears it
+	if sprintUpdate.Name != "" {
+		sprint.Name = sprintUpdate.Name
+	}
+	if sprintUpdate.Goal != nil {
+		sprint.Goal = *sprintUpdate.Goal
+	}
+	if sprintUpdate.StartDate != "" {
+		if err := sprint.SetStartDate(sprintUpdate.StartDate); err != nil {
+			http.Error(w, "Invalid start date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if sprintUpdate.EndDate != "" {
+		if err := sprint.SetEndDate(sprintUpdate.EndDate); err != nil {
+			http.Error(w, "Invalid end date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.storage.UpdateSprint(sprint); err != nil {
+		writeSprintError(w, err, "Failed to update sprint")
+		return
+	}
+
+	json.NewEncoder(w).Encode(sprint)
+}
+
+// sprintID extracts the sprint ID from a /api/sprints/{id} URL
+func sprintID(r *http.Request) string {
+	path := strings.TrimPrefix(r.URL.Path, "/api/sprints/")
+	return strings.Split(path, "/")[0]
+}
+
+// writeSprintError reports an error from a storage call on sprints
+func writeSprintError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidSprint):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrSprintState),
+		errors.Is(err, storage.ErrSprintActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrSprintNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleTimeline handles /api/timeline endpoint. It returns the tasks to
+// plot, chosen by the GET /api/tasks filters, and every milestone with its
+// progress to mark on the timeline.
+func (h *Handler) HandleTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	query, err := storage.ParseTaskQuery(func(name string) (string, bool) {
+		return values.Get(name), values.Has(name)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.storage.QueryTasks(query)
+	if err != nil {
+		http.Error(w, "Failed to list tasks", http.StatusInternalServerError)
+		return
+	}
+	milestones, err := h.milestoneReports()
+	if err != nil {
+		http.Error(w, "Failed to list milestones", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		Tasks      []*models.Task            `json:"tasks"`
+		Milestones []*models.MilestoneReport `json:"milestones"`
+	}{page.Tasks, milestones})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleUsers handles /api/users endpoint
+func (h *Handler) HandleUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listUsers(w)
+	case http.MethodPost:
+		h.createUser(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleUser handles /api/users/{id} endpoint
+func (h *Handler) HandleUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract user ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	userID := strings.Split(path, "/")[0]
+
+	if userID == "" {
+		http.Error(w, "User ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getUser(w, userID)
+	case http.MethodPut:
+		h.updateUser(w, r, userID)
+	case http.MethodDelete:
+		h.deleteUser(w, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// userFields are the fields a client may set on a user
+type userFields struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Kind  string `json:"kind"`
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter) {
+	users, err := h.storage.ListUsers()
+	if err != nil {
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(users)
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var fields userFields
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(fields.Name) == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if fields.Kind == "" {
+		fields.Kind = string(models.UserHuman)
+	}
+	if !models.IsValidUserKind(fields.Kind) {
+		http.Error(w, "Invalid kind. Use human or agent", http.StatusBadRequest)
+		return
+	}
+
+	user := models.NewUser(fields.Name, models.UserKind(fields.Kind))
+	user.Email = fields.Email
+
+	if err := h.storage.CreateUser(user); err != nil {
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, userID string) {
+	user, err := h.storage.GetUser(userID)
+	if err != nil {
+		writeUserError(w, err, "Failed to get user")
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request, userID string) {
+	user, err := h.storage.GetUser(userID)
+	if err != nil {
+		writeUserError(w, err, "Failed to get user")
+		return
+	}
+
+	var fields userFields
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Only update provided fields
+	if strings.TrimSpace(fields.Name) != "" {
+		user.Name = fields.Name
+	}
+	if fields.Email != "" {
+		user.Email = fields.Email
+	}
+	if fields.Kind != "" {
+		if !models.IsValidUserKind(fields.Kind) {
+			http.Error(w, "Invalid kind. Use human or agent", http.StatusBadRequest)
+			return
+		}
+		user.Kind = models.UserKind(fields.Kind)
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := h.storage.UpdateUser(user); err != nil {
+		writeUserError(w, err, "Failed to update user")
+		return
+	}
+
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) deleteUser(w http.ResponseWriter, userID string) {
+	if err := h.storage.DeleteUser(userID); err != nil {
+		writeUserError(w, err, "Failed to delete user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError reports an error from a storage call on a user
+func writeUserError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrUserInUse):
+		http.Error(w, "User is still assigned to or reported tasks; reassign them first", http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/storage"
+)
+
+// HandleWorkLogs handles /api/worklogs endpoint
+func (h *Handler) HandleWorkLogs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		values := r.URL.Query()
+		query := storage.WorkLogQuery{
+			TaskID: values.Get("task_id"),
+			UserID: values.Get("user_id"),
+		}
+		if query.TaskID != "" {
+			taskID, ok := h.resolveTaskID(w, query.TaskID)
+			if !ok {
+				return
+			}
+			query.TaskID = taskID
+		}
+		if values.Has("running") {
+			running, err := strconv.ParseBool(values.Get("running"))
+			if err != nil {
+				http.Error(w, "running must be true or false", http.StatusBadRequest)
+				return
+			}
+			query.Running = &running
+		}
+		h.listWorkLogs(w, query)
+	case http.MethodPost:
+		h.logWork(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleWorkLog handles /api/worklogs/{id} endpoint
+func (h *Handler) HandleWorkLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract work log ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/worklogs/")
+	id := strings.Split(path, "/")[0]
+
+	if id == "" {
+		http.Error(w, "Work log ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		log, err := h.storage.GetWorkLog(id)
+		if err != nil {
+			writeWorkLogError(w, err, "Failed to get work log")
+			return
+		}
+		json.NewEncoder(w).Encode(log)
+	case http.MethodDelete:
+		if err := h.storage.DeleteWorkLog(id); err != nil {
+			writeWorkLogError(w, err, "Failed to delete work log")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTimers handles /api/timers endpoint, which lists running timers
+func (h *Handler) HandleTimers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	running := true
+	h.listWorkLogs(w, storage.WorkLogQuery{UserID: r.URL.Query().Get("user_id"), Running: &running})
+}
+
+// HandleStartTimer handles /api/timers/start endpoint
+func (h *Handler) HandleStartTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		TaskID string `json:"task_id"`
+		UserID string `json:"user_id"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if body.TaskID == "" || body.UserID == "" {
+		http.Error(w, "task_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	taskID, ok := h.resolveTaskID(w, body.TaskID)
+	if !ok {
+		return
+	}
+
+	timer := models.NewWorkLog(taskID, body.UserID)
+	timer.Note = body.Note
+	if err := h.storage.CreateWorkLog(timer); err != nil {
+		writeWorkLogError(w, err, "Failed to start timer")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(timer)
+}
+
+// HandleStopTimer handles /api/timers/stop endpoint
+func (h *Handler) HandleStopTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if body.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	timer, err := h.storage.StopTimer(body.UserID, time.Now())
+	if err != nil {
+		writeWorkLogError(w, err, "Failed to stop timer")
+		return
+	}
+
+	json.NewEncoder(w).Encode(timer)
+}
+
+// HandleTaskTime handles /api/tasks/{id}/time endpoint, which totals the
+// time logged against a task and its subtree
+func (h *Handler) HandleTaskTime(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract task ID from URL
+	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
+	taskID, ok := h.resolveTaskID(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	summary, err := storage.SummarizeTime(h.storage, taskID)
+	if err != nil {
+		writeWorkLogError(w, err, "Failed to summarize time")
+		return
+	}
+
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (h *Handler) listWorkLogs(w http.ResponseWriter, query storage.WorkLogQuery) {
+	logs, err := h.storage.ListWorkLogs(query)
+	if err != nil {
+		http.Error(w, "Failed to list work logs", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(logs)
+}
+
+// logWork records work already done. Without a start the work is taken to
+// have ended at end, or now, after duration.
+func (h *Handler) logWork(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TaskID   string          `json:"task_id"`
+		UserID   string          `json:"user_id"`
+		Start    *time.Time      `json:"start"`
+		End      *time.Time      `json:"end"`
+		Duration models.Estimate `json:"duration"`
+		Note     string          `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.TaskID == "" || body.UserID == "" {
+		http.Error(w, "task_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+	if body.Duration == 0 && (body.Start == nil || body.End == nil) {
+		http.Error(w, "duration, or start and end, is required; use /api/timers/start to time work", http.StatusBadRequest)
+		return
+	}
+
+	taskID, ok := h.resolveTaskID(w, body.TaskID)
+	if !ok {
+		return
+	}
+
+	log := models.NewWorkLog(taskID, body.UserID)
+	log.End = body.End
+	log.Duration = body.Duration
+	log.Note = body.Note
+	if body.Start != nil {
+		log.Start = *body.Start
+	} else {
+		end := log.Start
+		if body.End != nil {
+			end = *body.End
+		}
+		log.Start = end.Add(-body.Duration.Duration())
+	}
+
+	if err := h.storage.CreateWorkLog(log); err != nil {
+		writeWorkLogError(w, err, "Failed to log work")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(log)
+}
+
+// writeWorkLogError reports an error from a storage call on work logs
+func writeWorkLogError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidWorkLog), errors.Is(err, storage.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrTimerRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrWorkLogNotFound), errors.Is(err, storage.ErrNoTimerRunning):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case strings.Contains(err.Error(), "not found"):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,286 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Render converts Markdown to HTML. It supports paragraphs, ATX headings,
+// block quotes, nested lists, fenced code blocks, horizontal rules and the
+// inline syntax handled by renderInline. Raw HTML is never passed through:
+// it is escaped like any other text, and links and images are only kept for
+// safe URLs, so the output can be inserted into a page as it is.
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), false)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	rulePattern     = regexp.MustCompile(`^ {0,3}(?:(?:-[ ]*){3,}|(?:\*[ ]*){3,}|(?:_[ ]*){3,})$`)
+	fencePattern    = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ ]*([^`\\s]*)")
+	quotePattern    = regexp.MustCompile(`^ {0,3}> ?`)
+	listPattern     = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])(?:( +)(.*))?$`)
+	languagePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+)
+
+// listMarker describes the marker that starts a list item
+type listMarker struct {
+	ordered bool
+	// delimiter is the bullet character, or the character after the number
+	delimiter byte
+	start     int
+	// contentIndent is the column the item's content starts at
+	contentIndent int
+	content       string
+}
+
+// parseListMarker reports whether line starts a list item
+func parseListMarker(line string) (listMarker, bool) {
+	m := listPattern.FindStringSubmatch(line)
+	if m == nil {
+		return listMarker{}, false
+	}
+
+	marker := listMarker{content: m[4]}
+	bullet := m[2]
+	spaces := len(m[3])
+	if spaces == 0 || spaces > 4 {
+		// A blank item, or content indented like code, starts one space
+		// after the marker
+		if spaces > 4 {
+			marker.content = strings.Repeat(" ", spaces-1) + marker.content
+		}
+		spaces = 1
+	}
+	marker.contentIndent = len(m[1]) + len(bullet) + spaces
+
+	if n := len(bullet) - 1; bullet[n] == '.' || bullet[n] == ')' {
+		marker.ordered = true
+		marker.delimiter = bullet[n]
+		marker.start, _ = strconv.Atoi(bullet[:n])
+	} else {
+		marker.delimiter = bullet[0]
+	}
+	return marker, true
+}
+
+// sameList reports whether an item with marker other continues the list
+// started by m
+func (m listMarker) sameList(other listMarker) bool {
+	return m.ordered == other.ordered && m.delimiter == other.delimiter
+}
+
+// isBlank reports whether line holds only spaces
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// indentation returns the number of leading spaces on line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// startsBlock reports whether line interrupts a paragraph
+func startsBlock(line string) bool {
+	if headingPattern.MatchString(line) || rulePattern.MatchString(line) ||
+		fencePattern.MatchString(line) || quotePattern.MatchString(line) {
+		return true
+	}
+	// Only lists starting at 1 interrupt a paragraph, so a sentence that
+	// happens to start with a number stays in it
+	marker, ok := parseListMarker(line)
+	return ok && marker.content != "" && (!marker.ordered || marker.start == 1)
+}
+
+// renderBlocks renders lines as a sequence of blocks. In a tight list item
+// paragraphs are written without <p> tags.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case fencePattern.MatchString(line):
+			i = renderFence(b, lines, i)
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(strings.TrimSpace(m[2])) + "</h" + level + ">\n")
+			i++
+		case quotePattern.MatchString(line):
+			i = renderQuote(b, lines, i)
+		default:
+			if marker, ok := parseListMarker(line); ok {
+				i = renderList(b, lines, i, marker)
+			} else {
+				i = renderParagraph(b, lines, i, tight)
+			}
+		}
+	}
+}
+
+// renderFence renders the fenced code block starting at lines[i] and returns
+// the index of the line after it
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	indent, fence, language := len(m[1]), m[2], m[3]
+
+	var code []string
+	i++
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if indentation(line) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		// Content loses up to the fence's indentation
+		strip := indentation(line)
+		if strip > indent {
+			strip = indent
+		}
+		code = append(code, line[strip:])
+	}
+
+	b.WriteString("<pre><code")
+	if languagePattern.MatchString(language) {
+		b.WriteString(` class="language-` + language + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// renderQuote renders the block quote starting at lines[i] and returns the
+// index of the line after it
+func renderQuote(b *strings.Builder, lines []string, i int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if loc := quotePattern.FindStringIndex(line); loc != nil {
+			quoted = append(quoted, line[loc[1]:])
+		} else if !isBlank(line) && len(quoted) > 0 && !isBlank(quoted[len(quoted)-1]) && !startsBlock(line) {
+			// A lazy continuation of the quoted paragraph
+			quoted = append(quoted, line)
+		} else {
+			break
+		}
+	}
+
+	b.WriteString("<blockquote>\n")
+	renderBlocks(b, quoted, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// renderList renders the list starting at lines[i], whose first item has
+// marker first, and returns the index of the line after it
+func renderList(b *strings.Builder, lines []string, i int, first listMarker) int {
+	var items [][]string
+	tight := true
+	marker := first
+	for {
+		item := []string{marker.content}
+		i++
+		blankBefore := false
+		for ; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				blankBefore = true
+				item = append(item, "")
+				continue
+			}
+			if indentation(line) >= marker.contentIndent {
+				item = append(item, line[marker.contentIndent:])
+			} else if _, ok := parseListMarker(line); !ok && !blankBefore && !startsBlock(line) {
+				// A lazy continuation of the item's paragraph
+				item = append(item, strings.TrimLeft(line, " "))
+			} else {
+				break
+			}
+			if blankBefore {
+				tight = false
+			}
+			blankBefore = false
+		}
+
+		// Blank lines at the end of the item belong between items
+		for len(item) > 1 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+		}
+		items = append(items, item)
+
+		if i >= len(lines) {
+			break
+		}
+		next, ok := parseListMarker(lines[i])
+		if !ok || !first.sameList(next) || indentation(lines[i]) >= first.contentIndent {
+			break
+		}
+		if blankBefore {
+			tight = false
+		}
+		marker = next
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		var content strings.Builder
+		renderBlocks(&content, item, tight)
+		b.WriteString("<li>" + strings.TrimSuffix(content.String(), "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderParagraph renders the paragraph starting at lines[i] and returns the
+// index of the line after it
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) || (len(text) > 0 && startsBlock(line)) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	// Two trailing spaces make a hard line break, like a trailing backslash
+	for n := range text {
+		trimmed := strings.TrimRight(text[n], " ")
+		if n < len(text)-1 && len(text[n])-len(trimmed) >= 2 {
+			trimmed += "\\"
+		}
+		text[n] = trimmed
+	}
+
+	content := renderInline(strings.Join(text, "\n"))
+	if tight {
+		b.WriteString(content + "\n")
+	} else {
+		b.WriteString("<p>" + content + "</p>\n")
+	}
+	return i
+}
@@ -0,0 +1,303 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// renderInline converts the inline syntax in text to HTML: code spans,
+// strong and emphasis with * or _, ~~strikethrough~~, links, images,
+// autolinks in angle brackets, bare http(s) URLs, backslash escapes and hard
+// line breaks. Everything else is escaped.
+func renderInline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(text) && isPunctuation(text[i+1]):
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '`':
+			i = renderCodeSpan(&b, text, i)
+		case c == '*' || c == '_' || c == '~':
+			i = renderDelimited(&b, text, i)
+		case c == '!' && strings.HasPrefix(text[i+1:], "["):
+			i = renderLink(&b, text, i+1, true)
+		case c == '[':
+			i = renderLink(&b, text, i, false)
+		case c == '<':
+			i = renderAutolink(&b, text, i)
+		case (c == 'h' || c == 'H') && (i == 0 || !isWordByte(text[i-1])) && hasURLPrefix(text[i:]):
+			i = renderBareURL(&b, text, i)
+		default:
+			// Copy plain text up to the next character that might matter
+			j := i + 1
+			for j < len(text) && !strings.ContainsRune("\\`*_~![<hH", rune(text[j])) {
+				j++
+			}
+			b.WriteString(html.EscapeString(text[i:j]))
+			i = j
+		}
+	}
+	return b.String()
+}
+
+// isPunctuation reports whether c is ASCII punctuation, which a backslash
+// escapes
+func isPunctuation(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+// isWordByte reports whether c is an ASCII letter or digit
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// renderCodeSpan renders the code span opened by the run of backticks at
+// text[i] and returns the index after it. An unclosed run is literal text.
+func renderCodeSpan(b *strings.Builder, text string, i int) int {
+	n := runLength(text, i)
+	fence := text[i : i+n]
+	for j := i + n; j < len(text); {
+		k := strings.Index(text[j:], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		if runLength(text, k) != n {
+			j = k + runLength(text, k)
+			continue
+		}
+		code := strings.ReplaceAll(text[i+n:k], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+		return k + n
+	}
+	b.WriteString(fence)
+	return i + n
+}
+
+// runLength returns how many times text[i] repeats from i
+func runLength(text string, i int) int {
+	n := 1
+	for i+n < len(text) && text[i+n] == text[i] {
+		n++
+	}
+	return n
+}
+
+// renderDelimited renders the emphasis, strong emphasis or strikethrough
+// opened at text[i] and returns the index after it. A delimiter without a
+// matching closer is literal text.
+func renderDelimited(b *strings.Builder, text string, i int) int {
+	c := text[i]
+	run := runLength(text, i)
+
+	// Try strong before emphasis; strikethrough needs exactly two tildes
+	sizes := []int{2, 1}
+	if c == '~' {
+		sizes = []int{2}
+	}
+	for _, size := range sizes {
+		if run < size || !canOpen(text, i, size) {
+			continue
+		}
+		delimiter := text[i : i+size]
+		if end := findCloser(text, i+size, delimiter); end >= 0 {
+			tag := "em"
+			switch {
+			case c == '~':
+				tag = "del"
+			case size == 2:
+				tag = "strong"
+			}
+			b.WriteString("<" + tag + ">" + renderInline(text[i+size:end]) + "</" + tag + ">")
+			return end + size
+		}
+	}
+
+	b.WriteString(text[i : i+run])
+	return i + run
+}
+
+// canOpen reports whether the delimiter of size bytes at text[i] can open
+// emphasis: it must be followed by text, and underscores must not be inside
+// a word
+func canOpen(text string, i, size int) bool {
+	next := i + size
+	if next >= len(text) || text[next] == ' ' || text[next] == '\n' {
+		return false
+	}
+	return text[i] != '_' || i == 0 || !isWordByte(text[i-1])
+}
+
+// findCloser returns the index of the delimiter closing emphasis whose
+// content starts at start, or -1. Code spans are skipped over, and a closer
+// must follow text.
+func findCloser(text string, start int, delimiter string) int {
+	c := delimiter[0]
+	for j := start; j < len(text); {
+		switch text[j] {
+		case '`':
+			// Delimiters inside code spans don't count
+			var skipped strings.Builder
+			j = renderCodeSpan(&skipped, text, j)
+			continue
+		case '\\':
+			j += 2
+			continue
+		case c:
+			run := runLength(text, j)
+			followsText := j > start && text[j-1] != ' ' && text[j-1] != '\n'
+			// A run of three closes emphasis and strong together, as in ***both***
+			if followsText && (run == len(delimiter) || run == 3 && c != '~') &&
+				(c != '_' || j+run >= len(text) || !isWordByte(text[j+run])) {
+				return j + run - len(delimiter)
+			}
+			j += run
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// renderLink renders the link or image whose text opens with the bracket at
+// text[i] and returns the index after it. Links to unsafe URLs keep only
+// their text; anything that isn't a complete link is literal text.
+func renderLink(b *strings.Builder, text string, i int, image bool) int {
+	start := i
+	if image {
+		start = i - 1
+	}
+
+	end := matchingBracket(text, i, '[', ']')
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		b.WriteString(html.EscapeString(text[start : i+1]))
+		return i + 1
+	}
+	close := matchingBracket(text, end+1, '(', ')')
+	if close < 0 {
+		b.WriteString(html.EscapeString(text[start : i+1]))
+		return i + 1
+	}
+
+	label := text[i+1 : end]
+	url := strings.TrimSpace(text[end+2 : close])
+	url = strings.TrimSuffix(strings.TrimPrefix(url, "<"), ">")
+	safe := isSafeURL(url)
+
+	switch {
+	case image && safe && !strings.HasPrefix(strings.ToLower(url), "mailto:"):
+		b.WriteString(`<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(label) + `">`)
+	case image:
+		b.WriteString(html.EscapeString(label))
+	case safe:
+		b.WriteString(linkTag(url) + renderInline(label) + "</a>")
+	default:
+		b.WriteString(renderInline(label))
+	}
+	return close + 1
+}
+
+// matchingBracket returns the index of the closer matching the opener at
+// text[i], allowing nested pairs and backslash escapes, or -1
+func matchingBracket(text string, i int, opener, closer byte) int {
+	depth := 0
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case opener:
+			depth++
+		case closer:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		case '\n':
+			if opener == '(' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// renderAutolink renders a URL in angle brackets at text[i] and returns the
+// index after it. Anything else starting with < is escaped.
+func renderAutolink(b *strings.Builder, text string, i int) int {
+	end := strings.IndexAny(text[i+1:], "> \n<")
+	if end >= 0 && text[i+1+end] == '>' {
+		url := text[i+1 : i+1+end]
+		if hasURLPrefix(url) || strings.HasPrefix(strings.ToLower(url), "mailto:") {
+			b.WriteString(linkTag(url) + html.EscapeString(url) + "</a>")
+			return i + end + 2
+		}
+	}
+	b.WriteString("&lt;")
+	return i + 1
+}
+
+// renderBareURL links the http(s) URL at text[i] and returns the index after
+// it. Trailing punctuation is left out of the URL, as it usually ends the
+// sentence.
+func renderBareURL(b *strings.Builder, text string, i int) int {
+	end := i
+	for end < len(text) && !strings.ContainsRune(" \n<>\"", rune(text[end])) {
+		end++
+	}
+	for end > i && strings.ContainsRune(".,:;!?'*_~", rune(text[end-1])) {
+		end--
+	}
+	// Keep a closing parenthesis only if the URL opened one
+	for end > i && text[end-1] == ')' && strings.Count(text[i:end], "(") < strings.Count(text[i:end], ")") {
+		end--
+	}
+
+	url := text[i:end]
+	if !hasURLPrefix(url) || len(url) == len("https://") {
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		return i + 1
+	}
+	b.WriteString(linkTag(url) + html.EscapeString(url) + "</a>")
+	return end
+}
+
+// hasURLPrefix reports whether s starts with http:// or https://
+func hasURLPrefix(s string) bool {
+	lower := strings.ToLower(s[:min(len(s), len("https://"))])
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// isSafeURL reports whether a link may point at url: http, https and mailto
+// URLs, and relative ones, are allowed while schemes such as javascript: are
+// not
+func isSafeURL(url string) bool {
+	if url == "" || strings.ContainsAny(url, " \n") {
+		return false
+	}
+	colon := strings.IndexByte(url, ':')
+	if colon < 0 || strings.ContainsAny(url[:colon], "/?#") {
+		return true
+	}
+	switch strings.ToLower(url[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	default:
+		return false
+	}
+}
+
+// linkTag opens a link to url. Links leave the page with no referrer and
+// carry no weight with search engines, since anyone can write comments.
+func linkTag(url string) string {
+	return `<a href="` + html.EscapeString(url) + `" rel="nofollow noopener noreferrer">`
+}
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"paragraphs", "One\ntwo\n\nThree", "<p>One\ntwo</p>\n<p>Three</p>"},
+		{"hard break", "One  \ntwo\\\nthree", "<p>One<br>\ntwo<br>\nthree</p>"},
+		{"heading", "## Progress ##", "<h2>Progress</h2>"},
+		{"not a heading", "#hashtag", "<p>#hashtag</p>"},
+		{"emphasis", "*a* _b_ **c** __d__ ~~e~~", "<p><em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong> <del>e</del></p>"},
+		{"nested emphasis", "**bold *and* bold** ***both***", "<p><strong>bold <em>and</em> bold</strong> <strong><em>both</em></strong></p>"},
+		{"intraword underscores", "snake_case_name and 2 * 3 * 4", "<p>snake_case_name and 2 * 3 * 4</p>"},
+		{"code span", "Run `go test ./...` or `` a`b ``", "<p>Run <code>go test ./...</code> or <code>a`b</code></p>"},
+		{"no emphasis in code", "`*not em*`", "<p><code>*not em*</code></p>"},
+		{"escapes", `\*literal\* and \# and 1 \< 2`, "<p>*literal* and # and 1 &lt; 2</p>"},
+		{"link", "[the docs](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">the docs</a></p>`},
+		{"relative link", "[task](/tasks/1#top)", `<p><a href="/tasks/1#top" rel="nofollow noopener noreferrer">task</a></p>`},
+		{"image", "![chart](https://example.com/chart.png)", `<p><img src="https://example.com/chart.png" alt="chart"></p>`},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">https://example.com</a></p>`},
+		{"bare URL", "See https://example.com/x_(y). Then", `<p>See <a href="https://example.com/x_(y)" rel="nofollow noopener noreferrer">https://example.com/x_(y)</a>. Then</p>`},
+		{"not a link", "[brackets] and [half](", "<p>[brackets] and [half](</p>"},
+		{"bullet list", "- one\n- two\n  - nested\n- three", "<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul></li>\n<li>three</li>\n</ul>"},
+		{"loose list", "1. one\n\n2. two", "<ol>\n<li><p>one</p></li>\n<li><p>two</p></li>\n</ol>"},
+		{"ordered start", "3) three\n4) four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>"},
+		{"number in a paragraph", "We shipped\n2024. Nice", "<p>We shipped\n2024. Nice</p>"},
+		{"quote", "> quoted\ncontinued\n\n> again", "<blockquote>\n<p>quoted\ncontinued</p>\n</blockquote>\n<blockquote>\n<p>again</p>\n</blockquote>"},
+		{"fenced code", "```go\nif a < b {\n\t*x*\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n    *x*\n}\n</code></pre>"},
+		{"unclosed fence", "~~~\ncode", "<pre><code>code\n</code></pre>"},
+		{"rule", "above\n\n***\nbelow", "<p>above</p>\n<hr>\n<p>below</p>"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.input); got != tt.want {
+				t.Errorf("Render(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_Sanitises(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"raw HTML", `<script>alert(1)</script>`, "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"HTML attributes", `<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>"},
+		{"javascript link", "[click](javascript:alert(1))", "<p>click</p>"},
+		{"mixed case scheme", "[click](JavaScript:alert(1))", "<p>click</p>"},
+		{"data image", "![pixel](data:image/png;base64,AAAA)", "<p>pixel</p>"},
+		{"quote in URL", `[x](https://example.com/"onclick="alert(1))`, `<p><a href="https://example.com/&#34;onclick=&#34;alert(1)" rel="nofollow noopener noreferrer">x</a></p>`},
+		{"HTML in link text", "[<b>x</b>](https://example.com)", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">&lt;b&gt;x&lt;/b&gt;</a></p>`},
+		{"code language", "```\"><script>\nx\n```", "<pre><code>x\n</code></pre>"},
+		{"javascript autolink", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.input)
+			if got != tt.want {
+				t.Errorf("Render(%q) =\n%s\nwant\n%s", tt.input, got, tt.want)
+			}
+			if strings.Contains(got, "<script") || strings.Contains(strings.ToLower(got), "javascript:alert(1)\"") {
+				t.Errorf("Render(%q) let unsafe markup through: %s", tt.input, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,11 @@ package mcp
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
 )
 
 // handleResourcesList handles the resources/list request
@@ -26,6 +31,12 @@ func (s *MCPServer) handleResourcesList(request JSONRPCRequest) JSONRPCResponse
 			Description: "Summary statistics and overview of the project status",
 			MimeType:    "text/plain",
 		},
+		{
+			URI:         "projectflow://milestones",
+			Name:        "Milestones",
+			Description: "Milestones by target date with their completion, at-risk status and projected date",
+			MimeType:    "application/json",
+		},
 	}
 
 	result := ResourcesListResult{
@@ -63,6 +74,8 @@ func (s *MCPServer) handleResourcesRead(request JSONRPCRequest) JSONRPCResponse
 		contents, readErr = s.readHierarchyResource()
 	case "projectflow://summary":
 		contents, readErr = s.readSummaryResource()
+	case "projectflow://milestones":
+		contents, readErr = s.readMilestonesResource()
 	default:
 		return s.createErrorResponse(request.ID, -32602, "Unknown resource URI", nil)
 	}
@@ -118,6 +131,34 @@ func (s *MCPServer) readHierarchyResource() ([]Content, error) {
 	}}, nil
 }
 
+// readMilestonesResource reads the milestones resource
+func (s *MCPServer) readMilestonesResource() ([]Content, error) {
+	milestones, err := s.storage.ListMilestones()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	tasks, err := s.storage.ListTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	now := time.Now()
+	reports := make([]*models.MilestoneReport, 0, len(milestones))
+	for _, milestone := range milestones {
+		reports = append(reports, models.NewMilestoneReport(milestone, tasks, now))
+	}
+
+	milestonesJSON, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal milestones: %w", err)
+	}
+
+	return []Content{{
+		Type: "text",
+		Text: string(milestonesJSON),
+	}}, nil
+}
+
 // readSummaryResource reads the summary resource
 func (s *MCPServer) readSummaryResource() ([]Content, error) {
 	tasks, err := s.storage.ListTasks()
@@ -127,33 +168,34 @@ func (s *MCPServer) readSummaryResource() ([]Content, error) {
 
 	// Calculate statistics
 	stats := map[string]int{
-		"total":       len(tasks),
-		"todo":        0,
-		"in_progress": 0,
-		"done":        0,
-		"blocked":     0,
-		"epic":        0,
-		"story":       0,
-		"task":        0,
-		"subtask":     0,
-		"low":         0,
-		"medium":      0,
-		"high":        0,
-		"critical":    0,
-		"overdue":     0,
+		"total":    len(tasks),
+		"done":     0,
+		"epic":     0,
+		"story":    0,
+		"task":     0,
+		"subtask":  0,
+		"low":      0,
+		"medium":   0,
+		"high":     0,
+		"critical": 0,
+		"overdue":  0,
 	}
 
+	statusCounts := make(map[models.TaskStatus]int)
+	labelCounts := make(map[string]int)
+	var totalPoints, completedPoints float64
+	var overdue []*models.Task
+
 	for _, task := range tasks {
 		// Count by status
-		switch task.Status {
-		case "todo":
-			stats["todo"]++
-		case "in_progress":
-			stats["in_progress"]++
-		case "done":
+		statusCounts[task.Status]++
+		for _, label := range task.Labels {
+			labelCounts[label]++
+		}
+		totalPoints += task.StoryPoints
+		if models.IsDoneStatus(task.Status) {
 			stats["done"]++
-		case "blocked":
-			stats["blocked"]++
+			completedPoints += task.StoryPoints
 		}
 
 		// Count by type
@@ -183,20 +225,54 @@ func (s *MCPServer) readSummaryResource() ([]Content, error) {
 		// Count overdue
 		if task.IsOverdue() {
 			stats["overdue"]++
+			overdue = append(overdue, task)
 		}
 	}
 
+	// Statuses are listed in workflow order
+	var statusBreakdown strings.Builder
+	for _, status := range models.CurrentWorkflow().Statuses {
+		fmt.Fprintf(&statusBreakdown, "- %s: %d\n", status.Name, statusCounts[status.ID])
+	}
+
+	// Labels are listed by name, including those no task carries yet
+	labels, err := s.storage.ListLabels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	labelBreakdown := "- None\n"
+	if len(labels) > 0 {
+		var breakdown strings.Builder
+		for _, label := range labels {
+			fmt.Fprintf(&breakdown, "- %s: %d\n", label.Name, labelCounts[label.Name])
+		}
+		labelBreakdown = breakdown.String()
+	}
+
+	// Overdue tasks are named by key, oldest first
+	overdueTasks := ""
+	if len(overdue) > 0 {
+		sort.Slice(overdue, func(i, j int) bool {
+			a, b := overdue[i], overdue[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return taskRef(a) < taskRef(b)
+		})
+		refs := make([]string, len(overdue))
+		for i, task := range overdue {
+			refs[i] = taskRef(task)
+		}
+		overdueTasks = " (" + strings.Join(refs, ", ") + ")"
+	}
+
 	summary := fmt.Sprintf(`ProjectFlow Summary
 ==================
 
 Total Tasks: %d
 
 Status Breakdown:
-- To Do: %d
-- In Progress: %d
-- Done: %d
-- Blocked: %d
-
+%s
 Type Breakdown:
 - Epics: %d
 - Stories: %d
@@ -209,16 +285,20 @@ Priority Breakdown:
 - High: %d
 - Critical: %d
 
+Label Breakdown:
+%s
+Story Points:
+- Total: %g
+- Completed: %g
+- Remaining: %g
+
 Other:
-- Overdue: %d
+- Overdue: %d%s
 
 Progress: %.1f%% complete
 `,
 		stats["total"],
-		stats["todo"],
-		stats["in_progress"],
-		stats["done"],
-		stats["blocked"],
+		statusBreakdown.String(),
 		stats["epic"],
 		stats["story"],
 		stats["task"],
@@ -227,7 +307,12 @@ Progress: %.1f%% complete
 		stats["medium"],
 		stats["high"],
 		stats["critical"],
+		labelBreakdown,
+		totalPoints,
+		completedPoints,
+		totalPoints-completedPoints,
 		stats["overdue"],
+		overdueTasks,
 		float64(stats["done"])/float64(stats["total"])*100,
 	)
 
@@ -6,28 +6,48 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/aykay76/projectflow/internal/models"
 	"github.com/aykay76/projectflow/internal/storage"
 )
 
 // MCPServer represents the Model Context Protocol server
 type MCPServer struct {
-	storage storage.Storage
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
+	storage     storage.Storage
+	attachments storage.AttachmentStore
+	userID      string
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
 }
 
-// NewMCPServer creates a new MCP server instance
-func NewMCPServer(storage storage.Storage) *MCPServer {
+// NewMCPServer creates a new MCP server instance. Its changes to tasks are
+// recorded in their history as made over MCP.
+func NewMCPServer(store storage.Storage) *MCPServer {
 	return &MCPServer{
-		storage: storage,
+		storage: storage.WithActor(store, storage.Actor{Source: models.SourceMCP}),
 		stdin:   os.Stdin,
 		stdout:  os.Stdout,
 		stderr:  os.Stderr,
 	}
 }
 
+// SetUserID makes the server act as a user: tasks it creates are reported by
+// them, list_my_tasks lists the tasks assigned to them, the timer tools and
+// add_comment act as them, and task history names them as the actor. Agents
+// connecting over MCP are usually registered as users of kind agent.
+func (s *MCPServer) SetUserID(id string) {
+	s.userID = id
+	s.storage = storage.WithActor(s.storage, storage.Actor{UserID: id, Source: models.SourceMCP})
+}
+
+// SetAttachments gives the server the attachment store, so delete_task
+// deletes the attachments of the tasks it removes
+func (s *MCPServer) SetAttachments(attachments storage.AttachmentStore) {
+	s.attachments = attachments
+}
+
 // Start starts the MCP server and handles incoming requests
 func (s *MCPServer) Start(ctx context.Context) error {
 	log.Printf("Starting MCP server for ProjectFlow")
@@ -109,11 +129,78 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 	tools := []Tool{
 		{
 			Name:        "list_tasks",
-			Description: "List all tasks in the project",
+			Description: "List tasks in the project, optionally filtered, sorted and paginated. Pass next_cursor from a previous result as cursor to fetch the next page.",
 			InputSchema: map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
-				"required":   []string{},
+				"type": "object",
+				"properties": map[string]interface{}{
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks with this status; separate several with commas",
+					},
+					"priority": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks with this priority; separate several with commas",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks of this type; separate several with commas",
+					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only children of this task; an empty string selects root tasks",
+					},
+					"assignee_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks assigned to this user; an empty string selects unassigned tasks",
+					},
+					"reporter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks reported by this user",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks with any of these labels (comma-separated)",
+					},
+					"sprint_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks in this sprint; an empty string selects the backlog",
+					},
+					"has_due_date": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only tasks with (true) or without (false) a due date",
+					},
+					"overdue": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only tasks that are (true) or are not (false) overdue",
+					},
+					"created_after":  dateArgument("Only tasks created at or after this time"),
+					"created_before": dateArgument("Only tasks created before this time"),
+					"updated_after":  dateArgument("Only tasks updated at or after this time"),
+					"updated_before": dateArgument("Only tasks updated before this time"),
+					"due_after":      dateArgument("Only tasks due at or after this time"),
+					"due_before":     dateArgument("Only tasks due before this time"),
+					"q": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks whose title or description contains this text",
+					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "Sort field, prefixed with - for descending order (default: created_at)",
+						"enum": []string{
+							"created_at", "-created_at", "updated_at", "-updated_at", "due_date", "-due_date",
+							"priority", "-priority", "status", "-status", "title", "-title",
+						},
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of tasks to return",
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "Cursor returned by a previous call to continue from",
+					},
+				},
+				"required": []string{},
 			},
 		},
 		{
@@ -133,7 +220,7 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 					"status": map[string]interface{}{
 						"type":        "string",
 						"description": "The status of the task",
-						"enum":        []string{"todo", "in_progress", "done", "blocked"},
+						"enum":        models.CurrentWorkflow().StatusIDs(),
 					},
 					"priority": map[string]interface{}{
 						"type":        "string",
@@ -147,7 +234,36 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 					},
 					"parent_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The ID of the parent task (for subtasks)",
+						"description": "The key or ID of the parent task. The workflow decides which types nest: by default stories go under epics, tasks under stories and subtasks under tasks",
+					},
+					"assignee_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user to assign the task to",
+					},
+					"reporter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user reporting the task (default: the user this server acts as)",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Names of registered labels to tag the task with",
+					},
+					"sprint_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the sprint to plan the task into",
+					},
+					"story_points": map[string]interface{}{
+						"type":        "number",
+						"description": "The relative size of the task in story points",
+					},
+					"original_estimate": map[string]interface{}{
+						"type":        "string",
+						"description": "The work expected, such as 4h or 2h30m",
+					},
+					"remaining_estimate": map[string]interface{}{
+						"type":        "string",
+						"description": "The work left, such as 1h30m (default: the original estimate)",
 					},
 					"due_date": map[string]interface{}{
 						"type":        "string",
@@ -165,7 +281,7 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 				"properties": map[string]interface{}{
 					"id": map[string]interface{}{
 						"type":        "string",
-						"description": "The ID of the task to retrieve",
+						"description": "The key (such as PF-123) or ID of the task to retrieve",
 					},
 				},
 				"required": []string{"id"},
@@ -173,26 +289,26 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 		},
 		{
 			Name:        "update_task",
-			Description: "Update an existing task",
+			Description: "Update an existing task. Omitted fields are left unchanged and null clears a field",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"id": map[string]interface{}{
 						"type":        "string",
-						"description": "The ID of the task to update",
+						"description": "The key (such as PF-123) or ID of the task to update",
 					},
 					"title": map[string]interface{}{
 						"type":        "string",
 						"description": "The title of the task",
 					},
 					"description": map[string]interface{}{
-						"type":        "string",
-						"description": "The description of the task",
+						"type":        []string{"string", "null"},
+						"description": "The description of the task; null clears it",
 					},
 					"status": map[string]interface{}{
 						"type":        "string",
 						"description": "The status of the task",
-						"enum":        []string{"todo", "in_progress", "done", "blocked"},
+						"enum":        models.CurrentWorkflow().StatusIDs(),
 					},
 					"priority": map[string]interface{}{
 						"type":        "string",
@@ -205,12 +321,45 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 						"enum":        []string{"epic", "story", "task", "subtask"},
 					},
 					"parent_id": map[string]interface{}{
-						"type":        "string",
-						"description": "The ID of the parent task (for subtasks)",
+						"type":        []string{"string", "null"},
+						"description": "The key or ID of the parent task, which must have a type this task's type can nest under; null makes it a root task",
+					},
+					"assignee_id": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The ID of the user the task is assigned to; null unassigns it",
+					},
+					"reporter_id": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The ID of the user who reported the task; null clears it",
+					},
+					"labels": map[string]interface{}{
+						"type":        []string{"array", "null"},
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Names of registered labels, replacing the task's labels; null removes them all",
+					},
+					"sprint_id": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The ID of the sprint the task is planned into, which can't be closed; null moves it to the backlog",
+					},
+					"story_points": map[string]interface{}{
+						"type":        []string{"number", "null"},
+						"description": "The relative size of the task in story points; null clears it",
+					},
+					"original_estimate": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The work expected, such as 4h or 2h30m; null clears it",
+					},
+					"remaining_estimate": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The work left, such as 1h30m; null clears it",
 					},
 					"due_date": map[string]interface{}{
-						"type":        "string",
-						"description": "The due date in YYYY-MM-DD format",
+						"type":        []string{"string", "null"},
+						"description": "The due date in YYYY-MM-DD format; null removes it",
+					},
+					"expected_version": map[string]interface{}{
+						"type":        "integer",
+						"description": "Only update if the task is still at this version (from get_task); fails if someone else changed it since",
 					},
 				},
 				"required": []string{"id"},
@@ -218,13 +367,18 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 		},
 		{
 			Name:        "delete_task",
-			Description: "Delete a task by ID",
+			Description: "Delete a task by ID, reporting which tasks were deleted or moved",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"id": map[string]interface{}{
 						"type":        "string",
-						"description": "The ID of the task to delete",
+						"description": "The key (such as PF-123) or ID of the task to delete",
+					},
+					"strategy": map[string]interface{}{
+						"type":        "string",
+						"description": "What to do with the task's children: delete the whole subtree, move them to the task's parent, or make them root tasks (default: cascade)",
+						"enum":        []string{"cascade", "reparent-to-grandparent", "orphan-to-root"},
 					},
 				},
 				"required": []string{"id"},
@@ -239,6 +393,305 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 				"required":   []string{},
 			},
 		},
+		{
+			Name:        "search_tasks",
+			Description: "Search task titles and descriptions, best match first. Use it to find related or duplicate work before creating a task.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Words to search for; word forms are matched (payment finds payments) and a trailing * matches a prefix",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of results (default: 10)",
+					},
+					"assignee_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks assigned to this user; an empty string selects unassigned tasks",
+					},
+					"reporter_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks reported by this user",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks with any of these labels (comma-separated)",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "link_tasks",
+			Description: "Link two tasks, e.g. record that one task blocks another. A blocked task can't move to in_progress until its blockers are done; links that would form a dependency cycle are rejected.",
+			InputSchema: linkSchema(),
+		},
+		{
+			Name:        "unlink_tasks",
+			Description: "Remove a link between two tasks",
+			InputSchema: linkSchema(),
+		},
+		{
+			Name:        "assign_task",
+			Description: "Assign a task to a user, or unassign it. Agents are users too, so an agent can take ownership of a task by assigning it to itself.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task to assign",
+					},
+					"user_id": map[string]interface{}{
+						"type":        []string{"string", "null"},
+						"description": "The ID of the user to assign the task to; null or an empty string unassigns it",
+					},
+					"expected_version": map[string]interface{}{
+						"type":        "integer",
+						"description": "Only assign if the task is still at this version (from get_task); fails if someone else changed it since",
+					},
+				},
+				"required": []string{"id", "user_id"},
+			},
+		},
+		{
+			Name:        "list_my_tasks",
+			Description: "List the tasks assigned to a user, by default the user this server acts as",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user whose tasks to list (default: the user this server acts as)",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Only tasks with this status; separate several with commas",
+					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"description": "Sort field, prefixed with - for descending order (default: created_at)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of tasks to return",
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "Cursor returned by a previous call to continue from",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "start_timer",
+			Description: "Start timing work on a task. Each user can run one timer at a time; stop it with stop_timer to log the time.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task being worked on",
+					},
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user doing the work (default: the user this server acts as)",
+					},
+					"note": map[string]interface{}{
+						"type":        "string",
+						"description": "What the work is",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			Name:        "stop_timer",
+			Description: "Stop a user's running timer and log the time on its task",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user whose timer to stop (default: the user this server acts as)",
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "log_work",
+			Description: "Log time already spent on a task",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task worked on",
+					},
+					"duration": map[string]interface{}{
+						"type":        "string",
+						"description": "The time spent, such as 45m or 1h30m",
+					},
+					"start": map[string]interface{}{
+						"type":        "string",
+						"description": "When the work started, in RFC 3339 format (default: duration before now)",
+					},
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user who did the work (default: the user this server acts as)",
+					},
+					"note": map[string]interface{}{
+						"type":        "string",
+						"description": "What the work was",
+					},
+				},
+				"required": []string{"task_id", "duration"},
+			},
+		},
+		{
+			Name:        "add_comment",
+			Description: "Comment on a task, such as to leave a progress note, instead of rewriting its description",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task to comment on",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "The comment, in Markdown",
+					},
+					"parent_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the comment this replies to",
+					},
+					"author_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the user commenting (default: the user this server acts as)",
+					},
+				},
+				"required": []string{"task_id", "body"},
+			},
+		},
+		{
+			Name:        "list_comments",
+			Description: "List the comments on a task as threads, oldest first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			Name:        "get_task_history",
+			Description: "Get the changes made to a task, newest first: who made each one, through which interface (web, api or mcp) and each field's value before and after. The history of a deleted task can still be read.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (such as PF-123) or ID of the task",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of changes to return (default: 20)",
+					},
+				},
+				"required": []string{"task_id"},
+			},
+		},
+		{
+			Name:        "create_sprint",
+			Description: "Plan a new sprint. Plan tasks into it with update_task's sprint_id.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "The name of the sprint, such as Sprint 12",
+					},
+					"goal": map[string]interface{}{
+						"type":        "string",
+						"description": "What the sprint sets out to achieve",
+					},
+					"start_date": map[string]interface{}{
+						"type":        "string",
+						"description": "The first day of the sprint in YYYY-MM-DD format",
+					},
+					"end_date": map[string]interface{}{
+						"type":        "string",
+						"description": "The last day of the sprint in YYYY-MM-DD format",
+					},
+				},
+				"required": []string{"name", "start_date", "end_date"},
+			},
+		},
+		{
+			Name:        "list_sprints",
+			Description: "List sprints ordered by start date. Use list_tasks with sprint_id to see the tasks in one.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"state": map[string]interface{}{
+						"type":        "string",
+						"description": "Only sprints in this state",
+						"enum":        []string{"planned", "active", "closed"},
+					},
+				},
+				"required": []string{},
+			},
+		},
+		{
+			Name:        "start_sprint",
+			Description: "Start a planned sprint, committing to the tasks planned into it. Only one sprint can be active at a time.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the sprint to start",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "close_sprint",
+			Description: "Close the active sprint, recording which tasks were done and carrying the unfinished ones over to the next sprint",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "The ID of the sprint to close",
+					},
+					"next_sprint_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The planned sprint to carry unfinished tasks over to (default: the next planned sprint, or the backlog if there is none)",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+	}
+
+	// Custom fields come from the workflow, so are only advertised once defined
+	for _, tool := range tools {
+		if tool.Name != "create_task" && tool.Name != "update_task" {
+			continue
+		}
+		if schema := customFieldsSchema(tool.Name == "update_task"); schema != nil {
+			tool.InputSchema["properties"].(map[string]interface{})["custom_fields"] = schema
+		}
 	}
 
 	result := ToolsListResult{
@@ -252,6 +705,110 @@ func (s *MCPServer) handleToolsList(request JSONRPCRequest) JSONRPCResponse {
 	}
 }
 
+// customFieldsSchema describes the custom_fields argument of create_task, or
+// of update_task when update is set, from the fields the workflow defines. A
+// field several types define is described by its first definition; values
+// are still checked against the task's own type. It returns nil when no
+// fields are defined.
+func customFieldsSchema(update bool) map[string]interface{} {
+	workflow := models.CurrentWorkflow()
+	properties := map[string]interface{}{}
+	usedOn := map[string][]string{}
+	for _, taskType := range []models.TaskType{models.TypeEpic, models.TypeStory, models.TypeTask, models.TypeSubtask} {
+		for _, field := range workflow.CustomFieldsFor(taskType) {
+			used := string(taskType)
+			if field.Required {
+				used += " (required)"
+			}
+			usedOn[field.Name] = append(usedOn[field.Name], used)
+			if _, ok := properties[field.Name]; !ok {
+				properties[field.Name] = customFieldProperty(field, update)
+			}
+		}
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+	for name, property := range properties {
+		property := property.(map[string]interface{})
+		property["description"] = strings.TrimSpace(property["description"].(string) + " Used on " + strings.Join(usedOn[name], ", ") + " tasks.")
+	}
+
+	description := "Values for the custom fields of the task's type"
+	if update {
+		description += ", merged into the task's values; null removes a value"
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"description":          description,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// customFieldProperty describes one custom field; null is allowed to remove
+// it when update is set
+func customFieldProperty(field models.CustomField, update bool) map[string]interface{} {
+	property := map[string]interface{}{"description": field.Description}
+	jsonType := "string"
+	switch field.Type {
+	case models.FieldNumber:
+		jsonType = "number"
+	case models.FieldDate:
+		property["format"] = "date"
+	case models.FieldURL:
+		property["format"] = "uri"
+	case models.FieldUser:
+		property["description"] = strings.TrimSpace(field.Description + " The ID of a user.")
+	case models.FieldEnum:
+		options := make([]interface{}, 0, len(field.Options)+1)
+		for _, option := range field.Options {
+			options = append(options, option)
+		}
+		if update {
+			options = append(options, nil)
+		}
+		property["enum"] = options
+	}
+
+	property["type"] = jsonType
+	if update {
+		property["type"] = []string{jsonType, "null"}
+	}
+	return property
+}
+
+// linkSchema is the input schema shared by link_tasks and unlink_tasks
+func linkSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "The key or ID of the task the link starts from",
+			},
+			"type": map[string]interface{}{
+				"type":        "string",
+				"description": "How the task relates to the target: id blocks target_id, is blocked by it, relates to it, duplicates it or is duplicated by it",
+				"enum":        []string{"blocks", "blocked_by", "relates_to", "duplicates", "duplicated_by"},
+			},
+			"target_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The key or ID of the other task",
+			},
+		},
+		"required": []string{"id", "type", "target_id"},
+	}
+}
+
+// dateArgument describes a date or time tool argument
+func dateArgument(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description + " (YYYY-MM-DD or RFC 3339)",
+	}
+}
+
 // sendError sends an error response
 func (s *MCPServer) sendError(encoder *json.Encoder, id interface{}, code int, message string, data interface{}) {
 	response := s.createErrorResponse(id, code, message, data)
@@ -1,23 +1,37 @@
 package mcp
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/search"
+	"github.com/aykay76/projectflow/internal/storage"
 )
 
 var ErrTaskNotFound = errors.New("task not found")
 
 // mockStorage implements a simple in-memory storage for testing
 type mockStorage struct {
-	tasks map[string]*models.Task
+	tasks      map[string]*models.Task
+	users      map[string]*models.User
+	labels     map[string]*models.Label
+	workLogs   []*models.WorkLog
+	comments   []*models.Comment
+	sprints    []*models.Sprint
+	milestones []*models.Milestone
+	events     []*models.TaskEvent
 }
 
 func newMockStorage() *mockStorage {
 	return &mockStorage{
-		tasks: make(map[string]*models.Task),
+		tasks:  make(map[string]*models.Task),
+		users:  make(map[string]*models.User),
+		labels: make(map[string]*models.Label),
 	}
 }
 
@@ -31,17 +45,59 @@ func (m *mockStorage) GetTask(id string) (*models.Task, error) {
 	if !exists {
 		return nil, ErrTaskNotFound
 	}
-	return task, nil
+	return task.Clone(), nil
+}
+
+func (m *mockStorage) GetTaskByKey(key string) (*models.Task, error) {
+	for _, task := range m.tasks {
+		if task.Key == key {
+			return task.Clone(), nil
+		}
+	}
+	return nil, ErrTaskNotFound
 }
 
 func (m *mockStorage) UpdateTask(task *models.Task) error {
-	if _, exists := m.tasks[task.ID]; !exists {
+	current, exists := m.tasks[task.ID]
+	if !exists {
 		return ErrTaskNotFound
 	}
+	if task.Version != 0 && task.Version != current.Version {
+		return storage.ErrVersionConflict
+	}
+	if _, ok := m.users[task.AssigneeID]; task.AssigneeID != "" && !ok {
+		return storage.ErrUserNotFound
+	}
+	task.Version = current.Version + 1
 	m.tasks[task.ID] = task
 	return nil
 }
 
+// WithActor returns a view of the mock that records task updates in the
+// history, like the backends' views do
+func (m *mockStorage) WithActor(actor storage.Actor) storage.Storage {
+	return &actorMockStorage{mockStorage: m, actor: actor}
+}
+
+type actorMockStorage struct {
+	*mockStorage
+	actor storage.Actor
+}
+
+func (a *actorMockStorage) UpdateTask(task *models.Task) error {
+	before, err := a.GetTask(task.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.mockStorage.UpdateTask(task); err != nil {
+		return err
+	}
+	if event := models.NewTaskEvent(before, task.Clone(), a.actor.UserID, a.actor.Source, time.Now()); event != nil {
+		return a.AppendEvents([]*models.TaskEvent{event})
+	}
+	return nil
+}
+
 func (m *mockStorage) DeleteTask(id string) error {
 	if _, exists := m.tasks[id]; !exists {
 		return ErrTaskNotFound
@@ -50,6 +106,38 @@ func (m *mockStorage) DeleteTask(id string) error {
 	return nil
 }
 
+func (m *mockStorage) DeleteTaskWithStrategy(id string, strategy storage.DeleteStrategy, expectedVersion int64) (*storage.DeleteResult, error) {
+	if err := m.DeleteTask(id); err != nil {
+		return nil, err
+	}
+	return &storage.DeleteResult{Deleted: []string{id}, Moved: []string{}}, nil
+}
+
+func (m *mockStorage) LinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	from, ok := m.tasks[fromID]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	to, ok := m.tasks[toID]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	from.AddLink(linkType, toID)
+	to.AddLink(linkType.Inverse(), fromID)
+	return nil
+}
+
+func (m *mockStorage) UnlinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	from, ok := m.tasks[fromID]
+	if !ok || !from.RemoveLink(linkType, toID) {
+		return storage.ErrLinkNotFound
+	}
+	if to, ok := m.tasks[toID]; ok {
+		to.RemoveLink(linkType.Inverse(), fromID)
+	}
+	return nil
+}
+
 func (m *mockStorage) ListTasks() ([]*models.Task, error) {
 	tasks := make([]*models.Task, 0, len(m.tasks))
 	for _, task := range m.tasks {
@@ -58,6 +146,307 @@ func (m *mockStorage) ListTasks() ([]*models.Task, error) {
 	return tasks, nil
 }
 
+func (m *mockStorage) QueryTasks(query storage.TaskQuery) (*storage.TaskPage, error) {
+	tasks, _ := m.ListTasks()
+	return storage.ApplyQuery(tasks, query)
+}
+
+func (m *mockStorage) SearchTasks(query string, limit int) ([]*storage.SearchResult, error) {
+	idx := search.NewIndex()
+	for _, task := range m.tasks {
+		idx.Add(task.ID, task.Title, task.Description)
+	}
+	var results []*storage.SearchResult
+	for _, hit := range idx.Search(query, limit) {
+		results = append(results, &storage.SearchResult{
+			Task:               m.tasks[hit.ID],
+			Score:              hit.Score,
+			TitleMatches:       hit.TitleMatches,
+			DescriptionMatches: hit.DescriptionMatches,
+		})
+	}
+	return results, nil
+}
+
+func (m *mockStorage) CreateUser(user *models.User) error {
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *mockStorage) GetUser(id string) (*models.User, error) {
+	user, exists := m.users[id]
+	if !exists {
+		return nil, storage.ErrUserNotFound
+	}
+	return user.Clone(), nil
+}
+
+func (m *mockStorage) UpdateUser(user *models.User) error {
+	if _, exists := m.users[user.ID]; !exists {
+		return storage.ErrUserNotFound
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *mockStorage) DeleteUser(id string) error {
+	delete(m.users, id)
+	return nil
+}
+
+func (m *mockStorage) ListUsers() ([]*models.User, error) {
+	users := make([]*models.User, 0, len(m.users))
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (m *mockStorage) CreateLabel(label *models.Label) error {
+	m.labels[label.Name] = label
+	return nil
+}
+
+func (m *mockStorage) GetLabel(name string) (*models.Label, error) {
+	label, exists := m.labels[name]
+	if !exists {
+		return nil, storage.ErrLabelNotFound
+	}
+	return label, nil
+}
+
+func (m *mockStorage) UpdateLabel(label *models.Label) error {
+	m.labels[label.Name] = label
+	return nil
+}
+
+func (m *mockStorage) DeleteLabel(name string) error {
+	delete(m.labels, name)
+	return nil
+}
+
+func (m *mockStorage) ListLabels() ([]*models.Label, error) {
+	labels := make([]*models.Label, 0, len(m.labels))
+	for _, label := range m.labels {
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+func (m *mockStorage) LabelTasks(taskIDs []string, add, remove []string) ([]*models.Task, error) {
+	var tasks []*models.Task
+	for _, id := range taskIDs {
+		task, exists := m.tasks[id]
+		if !exists {
+			return nil, ErrTaskNotFound
+		}
+		for _, label := range add {
+			if !task.HasLabel(label) {
+				task.Labels = append(task.Labels, label)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (m *mockStorage) CreateWorkLog(log *models.WorkLog) error {
+	if log.IsRunning() && log.Duration == 0 {
+		for _, other := range m.workLogs {
+			if other.UserID == log.UserID && other.IsRunning() {
+				return storage.ErrTimerRunning
+			}
+		}
+	} else if log.End == nil {
+		end := log.Start.Add(log.Duration.Duration())
+		log.End = &end
+	}
+	log.ID = fmt.Sprintf("log-%d", len(m.workLogs)+1)
+	m.workLogs = append(m.workLogs, log)
+	return nil
+}
+
+func (m *mockStorage) GetWorkLog(id string) (*models.WorkLog, error) {
+	for _, log := range m.workLogs {
+		if log.ID == id {
+			return log, nil
+		}
+	}
+	return nil, storage.ErrWorkLogNotFound
+}
+
+func (m *mockStorage) DeleteWorkLog(id string) error {
+	return nil
+}
+
+func (m *mockStorage) ListWorkLogs(query storage.WorkLogQuery) ([]*models.WorkLog, error) {
+	return m.workLogs, nil
+}
+
+func (m *mockStorage) StopTimer(userID string, end time.Time) (*models.WorkLog, error) {
+	for _, log := range m.workLogs {
+		if log.UserID == userID && log.IsRunning() {
+			log.Stop(end)
+			return log, nil
+		}
+	}
+	return nil, storage.ErrNoTimerRunning
+}
+
+func (m *mockStorage) CreateComment(comment *models.Comment) error {
+	if comment.ParentID != "" {
+		if _, err := m.GetComment(comment.ParentID); err != nil {
+			return err
+		}
+	}
+	comment.ID = fmt.Sprintf("comment-%d", len(m.comments)+1)
+	m.comments = append(m.comments, comment)
+	return nil
+}
+
+func (m *mockStorage) GetComment(id string) (*models.Comment, error) {
+	for _, comment := range m.comments {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	return nil, storage.ErrCommentNotFound
+}
+
+func (m *mockStorage) UpdateComment(comment *models.Comment) error {
+	return nil
+}
+
+func (m *mockStorage) DeleteComment(id string) error {
+	return nil
+}
+
+func (m *mockStorage) ListComments(taskID string) ([]*models.Comment, error) {
+	comments := []*models.Comment{}
+	for _, comment := range m.comments {
+		if comment.TaskID == taskID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (m *mockStorage) CreateSprint(sprint *models.Sprint) error {
+	sprint.ID = fmt.Sprintf("sprint-%d", len(m.sprints)+1)
+	m.sprints = append(m.sprints, sprint)
+	return nil
+}
+
+func (m *mockStorage) GetSprint(id string) (*models.Sprint, error) {
+	for _, sprint := range m.sprints {
+		if sprint.ID == id {
+			return sprint, nil
+		}
+	}
+	return nil, storage.ErrSprintNotFound
+}
+
+func (m *mockStorage) UpdateSprint(sprint *models.Sprint) error {
+	return nil
+}
+
+func (m *mockStorage) DeleteSprint(id string) error {
+	return nil
+}
+
+func (m *mockStorage) ListSprints() ([]*models.Sprint, error) {
+	return m.sprints, nil
+}
+
+func (m *mockStorage) StartSprint(id string) (*models.Sprint, error) {
+	sprint, err := m.GetSprint(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range m.sprints {
+		if other.State == models.SprintActive {
+			return nil, storage.ErrSprintActive
+		}
+	}
+	sprint.Start(m.sprintTasks(id), time.Now())
+	return sprint, nil
+}
+
+func (m *mockStorage) CloseSprint(id, nextID string) (*models.Sprint, error) {
+	sprint, err := m.GetSprint(id)
+	if err != nil {
+		return nil, err
+	}
+	if nextID == "" {
+		for _, other := range m.sprints {
+			if other.State == models.SprintPlanned {
+				nextID = other.ID
+				break
+			}
+		}
+	}
+	sprint.Close(m.sprintTasks(id), nextID, time.Now())
+	return sprint, nil
+}
+
+func (m *mockStorage) CreateMilestone(milestone *models.Milestone) error {
+	milestone.ID = fmt.Sprintf("milestone-%d", len(m.milestones)+1)
+	m.milestones = append(m.milestones, milestone)
+	return nil
+}
+
+func (m *mockStorage) GetMilestone(id string) (*models.Milestone, error) {
+	for _, milestone := range m.milestones {
+		if milestone.ID == id {
+			return milestone, nil
+		}
+	}
+	return nil, storage.ErrMilestoneNotFound
+}
+
+func (m *mockStorage) UpdateMilestone(milestone *models.Milestone) error {
+	return nil
+}
+
+func (m *mockStorage) DeleteMilestone(id string) error {
+	return nil
+}
+
+func (m *mockStorage) ListMilestones() ([]*models.Milestone, error) {
+	return m.milestones, nil
+}
+
+func (m *mockStorage) sprintTasks(id string) []*models.Task {
+	var tasks []*models.Task
+	for _, task := range m.tasks {
+		if task.SprintID == id {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+func (m *mockStorage) AppendEvents(events []*models.TaskEvent) error {
+	for _, event := range events {
+		event.ID = fmt.Sprintf("event-%d", len(m.events)+1)
+		m.events = append(m.events, event)
+	}
+	return nil
+}
+
+func (m *mockStorage) ListEvents(query storage.EventQuery) ([]*models.TaskEvent, error) {
+	events := []*models.TaskEvent{}
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if query.TaskID == "" || m.events[i].TaskID == query.TaskID {
+			events = append(events, m.events[i])
+		}
+	}
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+	return events, nil
+}
+
 func (m *mockStorage) GetTaskChildren(parentID string) ([]*models.Task, error) {
 	var children []*models.Task
 	for _, task := range m.tasks {
@@ -149,7 +538,7 @@ func TestMCPServer_ToolsList(t *testing.T) {
 		t.Errorf("Expected ToolsListResult, got: %T", response.Result)
 	}
 
-	expectedTools := []string{"list_tasks", "create_task", "get_task", "update_task", "delete_task", "get_task_hierarchy"}
+	expectedTools := []string{"list_tasks", "create_task", "get_task", "update_task", "delete_task", "get_task_hierarchy", "search_tasks", "link_tasks", "unlink_tasks", "assign_task", "list_my_tasks", "start_timer", "stop_timer", "log_work", "add_comment", "list_comments", "get_task_history", "create_sprint", "list_sprints", "start_sprint", "close_sprint"}
 	if len(result.Tools) != len(expectedTools) {
 		t.Errorf("Expected %d tools, got %d", len(expectedTools), len(result.Tools))
 	}
@@ -218,6 +607,611 @@ func TestMCPServer_ListTasks(t *testing.T) {
 	}
 }
 
+func TestMCPServer_ListTasks_Filtered(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	for i, status := range []models.TaskStatus{models.StatusTodo, models.StatusDone, models.StatusDone} {
+		task := models.NewTask("Task", "")
+		task.ID = string(rune('a' + i))
+		task.Status = status
+		storage.CreateTask(task)
+	}
+
+	args := map[string]interface{}{
+		"status": "done",
+		"limit":  float64(1),
+	}
+	result, err := server.handleListTasks(args)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.Contains(result.Content[0].Text, "Found 2 tasks, showing 1") {
+		t.Errorf("Expected a page of the 2 done tasks, got: %s", result.Content[0].Text)
+	}
+	if !strings.Contains(result.Content[0].Text, "next_cursor: ") {
+		t.Errorf("Expected a cursor for the next page")
+	}
+
+	_, err = server.handleListTasks(map[string]interface{}{"status": "finished"})
+	if err == nil {
+		t.Errorf("Expected an error for an invalid status")
+	}
+}
+
+func TestMCPServer_Labels(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+	storage.CreateLabel(&models.Label{Name: "mcp", Color: models.DefaultLabelColor})
+
+	for i, labels := range [][]string{{"mcp"}, nil} {
+		task := models.NewTask("Task "+string(rune('a'+i)), "")
+		task.ID = string(rune('a' + i))
+		task.Labels = labels
+		storage.CreateTask(task)
+	}
+
+	result, err := server.handleListTasks(map[string]interface{}{"label": "MCP"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Found 1 tasks") || !strings.Contains(result.Content[0].Text, "Task a") {
+		t.Errorf("Expected only task a, got: %s", result.Content[0].Text)
+	}
+
+	contents, err := server.readSummaryResource()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(contents[0].Text, "Label Breakdown:\n- mcp: 1\n") {
+		t.Errorf("Expected a count of the mcp label, got: %s", contents[0].Text)
+	}
+}
+
+func TestMCPServer_SearchTasks(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	task := models.NewTask("Card payments", "Accept card payments at checkout")
+	task.ID = "pay"
+	storage.CreateTask(task)
+
+	result, err := server.handleSearchTasks(map[string]interface{}{"query": "payment"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Card **payments**") {
+		t.Errorf("Expected a highlighted title, got: %s", result.Content[0].Text)
+	}
+
+	result, err = server.handleSearchTasks(map[string]interface{}{"query": "kubernetes "})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "No tasks match") {
+		t.Errorf("Expected no matches, got: %s", result.Content[0].Text)
+	}
+
+	if _, err := server.handleSearchTasks(map[string]interface{}{}); err == nil {
+		t.Errorf("Expected an error without a query")
+	}
+}
+
+func TestMCPServer_UpdateTask_ExpectedVersion(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	task := models.NewTask("Task", "")
+	task.ID = "a"
+	task.Version = 2
+	storage.CreateTask(task)
+
+	_, err := server.handleUpdateTask(map[string]interface{}{
+		"id":               "a",
+		"title":            "Stale edit",
+		"expected_version": float64(1),
+	})
+	if err == nil || !strings.Contains(err.Error(), "get the task again") {
+		t.Fatalf("Expected a version conflict, got: %v", err)
+	}
+
+	_, err = server.handleUpdateTask(map[string]interface{}{
+		"id":               "a",
+		"title":            "Fresh edit",
+		"expected_version": float64(2),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got, _ := storage.GetTask("a"); got.Title != "Fresh edit" || got.Version != 3 {
+		t.Errorf("Expected the update at version 3, got %q at version %d", got.Title, got.Version)
+	}
+
+	if _, err := server.handleUpdateTask(map[string]interface{}{"id": "a", "expected_version": float64(1.5)}); err == nil {
+		t.Errorf("Expected an error for a fractional expected_version")
+	}
+}
+
+func TestMCPServer_UpdateTask_Nulls(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	task := models.NewTask("Task", "Old notes")
+	task.ID = "a"
+	task.ParentID = "epic"
+	task.SetDueDate("2025-03-01")
+	storage.CreateTask(task)
+
+	_, err := server.handleUpdateTask(map[string]interface{}{
+		"id":          "a",
+		"title":       "",
+		"description": nil,
+		"parent_id":   nil,
+		"due_date":    nil,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	got, _ := storage.GetTask("a")
+	if got.Title != "Task" {
+		t.Errorf("Expected an empty title to be ignored, got %q", got.Title)
+	}
+	if got.Description != "" || got.ParentID != "" || got.DueDate != nil {
+		t.Errorf("Expected null to clear fields, got description %q, parent %q, due date %v",
+			got.Description, got.ParentID, got.DueDate)
+	}
+
+	if _, err := server.handleUpdateTask(map[string]interface{}{"id": "a", "title": nil}); err == nil {
+		t.Errorf("Expected an error when clearing the title")
+	}
+}
+
+func TestMCPServer_LinkTasks(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	for _, id := range []string{"a", "b"} {
+		task := models.NewTask("Task "+id, "")
+		task.ID = id
+		storage.CreateTask(task)
+	}
+
+	args := map[string]interface{}{"id": "a", "type": "blocks", "target_id": "b"}
+	if _, err := server.handleLinkTasks(args); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if b, _ := storage.GetTask("b"); !b.HasLink(models.LinkBlockedBy, "a") {
+		t.Errorf("Expected b to be blocked by a, got links %v", b.Links)
+	}
+
+	if _, err := server.handleUnlinkTasks(args); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := server.handleUnlinkTasks(args); err == nil {
+		t.Errorf("Expected an error removing a link that doesn't exist")
+	}
+
+	if _, err := server.handleLinkTasks(map[string]interface{}{"id": "a", "type": "follows", "target_id": "b"}); err == nil {
+		t.Errorf("Expected an error for an invalid link type")
+	}
+}
+
+func TestMCPServer_AssignTask(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	agent := models.NewUser("Release bot", models.UserAgent)
+	agent.ID = "bot"
+	storage.CreateUser(agent)
+	for _, id := range []string{"a", "b"} {
+		task := models.NewTask("Task "+id, "")
+		task.ID = id
+		storage.CreateTask(task)
+	}
+
+	result, err := server.handleAssignTask(map[string]interface{}{"id": "a", "user_id": "bot"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Release bot (bot, agent)") {
+		t.Errorf("Expected the assignee in the result, got: %s", result.Content[0].Text)
+	}
+	if a, _ := storage.GetTask("a"); a.AssigneeID != "bot" {
+		t.Errorf("Expected a to be assigned to bot, got %q", a.AssigneeID)
+	}
+
+	if _, err := server.handleAssignTask(map[string]interface{}{"id": "b", "user_id": "nobody"}); err == nil {
+		t.Errorf("Expected an error assigning to an unknown user")
+	}
+	if _, err := server.handleAssignTask(map[string]interface{}{"id": "b"}); err == nil {
+		t.Errorf("Expected an error without user_id")
+	}
+
+	// The server's own user is the default for list_my_tasks
+	if _, err := server.handleListMyTasks(map[string]interface{}{}); err == nil {
+		t.Errorf("Expected an error without a user")
+	}
+	server.SetUserID("bot")
+	result, err = server.handleListMyTasks(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Found 1 tasks") || !strings.Contains(result.Content[0].Text, "Task a") {
+		t.Errorf("Expected only task a, got: %s", result.Content[0].Text)
+	}
+
+	if _, err := server.handleAssignTask(map[string]interface{}{"id": "a", "user_id": nil}); err != nil {
+		t.Fatalf("Expected no error unassigning, got: %v", err)
+	}
+	if a, _ := storage.GetTask("a"); a.AssigneeID != "" {
+		t.Errorf("Expected a to be unassigned, got %q", a.AssigneeID)
+	}
+}
+
+func TestMCPServer_CreateTask_Reporter(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+	server.SetUserID("bot")
+
+	if _, err := server.handleCreateTask(map[string]interface{}{"title": "Filed by bot"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	tasks, _ := storage.ListTasks()
+	if len(tasks) != 1 || tasks[0].ReporterID != "bot" {
+		t.Errorf("Expected one task reported by bot, got %v", tasks)
+	}
+}
+
+func TestMCPServer_CustomFields(t *testing.T) {
+	workflow := models.DefaultWorkflow()
+	workflow.CustomFields = map[models.TaskType][]models.CustomField{
+		models.TypeTask: {
+			{Name: "severity", Type: models.FieldEnum, Options: []string{"low", "high"}, Required: true},
+			{Name: "cost", Type: models.FieldNumber},
+		},
+	}
+	models.SetWorkflow(workflow)
+	t.Cleanup(func() { models.SetWorkflow(nil) })
+
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	response := server.handleRequest(JSONRPCRequest{JSONRPC: "2.0", Method: "tools/list", ID: 1})
+	for _, tool := range response.Result.(ToolsListResult).Tools {
+		properties := tool.InputSchema["properties"].(map[string]interface{})
+		if _, ok := properties["custom_fields"]; ok != (tool.Name == "create_task" || tool.Name == "update_task") {
+			t.Errorf("Tool %s advertises custom_fields = %v", tool.Name, ok)
+		}
+	}
+
+	for _, values := range []map[string]interface{}{
+		{"cost": 3},
+		{"severity": "urgent"},
+		{"severity": "low", "component": "parser"},
+	} {
+		if _, err := server.handleCreateTask(map[string]interface{}{"title": "Invalid", "custom_fields": values}); err == nil {
+			t.Errorf("Expected an error for custom fields %v", values)
+		}
+	}
+	if _, err := server.handleCreateTask(map[string]interface{}{
+		"title": "Crash on save", "custom_fields": map[string]interface{}{"severity": "high", "cost": "1.5"},
+	}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	tasks, _ := storage.ListTasks()
+	if len(tasks) != 1 || tasks[0].CustomFields["severity"] != "high" || tasks[0].CustomFields["cost"] != 1.5 {
+		t.Fatalf("Expected one task with both custom fields, got %v", tasks)
+	}
+
+	task := tasks[0]
+	task.ID = "a"
+	storage.tasks = map[string]*models.Task{"a": task}
+	if _, err := server.handleUpdateTask(map[string]interface{}{
+		"id": "a", "custom_fields": map[string]interface{}{"cost": nil},
+	}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := storage.tasks["a"].CustomFields; len(got) != 1 || got["severity"] != "high" {
+		t.Errorf("Expected only severity left, got %v", got)
+	}
+	if _, err := server.handleUpdateTask(map[string]interface{}{
+		"id": "a", "custom_fields": map[string]interface{}{"severity": nil},
+	}); err == nil {
+		t.Errorf("Expected an error removing a required custom field")
+	}
+}
+
+func TestMCPServer_TaskKeys(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	overdue := time.Now().AddDate(0, 0, -1)
+	for i, title := range []string{"Epic", "Story"} {
+		task := models.NewTask(title, "")
+		task.ID = fmt.Sprintf("task-%d", i+1)
+		task.Key = fmt.Sprintf("PF-%d", i+1)
+		task.DueDate = &overdue
+		storage.tasks[task.ID] = task
+	}
+	storage.tasks["task-1"].Type = models.TypeEpic
+	storage.tasks["task-2"].Type = models.TypeStory
+
+	result, err := server.handleGetTask(map[string]interface{}{"id": "pf-2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, `"id": "task-2"`) {
+		t.Errorf("Expected the task with key PF-2, got: %s", result.Content[0].Text)
+	}
+	if _, err := server.handleGetTask(map[string]interface{}{"id": "PF-9"}); err == nil {
+		t.Errorf("Expected an error for an unknown key")
+	}
+
+	if _, err := server.handleUpdateTask(map[string]interface{}{"id": "PF-2", "parent_id": "PF-1"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if storage.tasks["task-2"].ParentID != "task-1" {
+		t.Errorf("Expected the parent key resolved to its ID, got %q", storage.tasks["task-2"].ParentID)
+	}
+	if _, err := server.handleLinkTasks(map[string]interface{}{"id": "PF-1", "type": "blocks", "target_id": "PF-2"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if links := storage.tasks["task-2"].Links; len(links) != 1 || links[0].TaskID != "task-1" {
+		t.Errorf("Expected PF-2 blocked by task-1, got %v", links)
+	}
+
+	contents, err := server.readSummaryResource()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(contents[0].Text, "Overdue: 2 (PF-1, PF-2)") {
+		t.Errorf("Expected the overdue tasks by key, got: %s", contents[0].Text)
+	}
+
+	result, err = server.handleAssignTask(map[string]interface{}{"id": "PF-1", "user_id": nil})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Content[0].Text != "Unassigned task: PF-1 Epic (task-1)" {
+		t.Errorf("Expected the task named by key, got: %s", result.Content[0].Text)
+	}
+}
+
+func TestMCPServer_Timers(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	for _, id := range []string{"story", "task"} {
+		task := models.NewTask("Task "+id, "")
+		task.ID = id
+		storage.CreateTask(task)
+	}
+	storage.tasks["task"].ParentID = "story"
+
+	if _, err := server.handleStartTimer(map[string]interface{}{"task_id": "task"}); err == nil {
+		t.Errorf("Expected an error without a user_id or MCP_USER_ID")
+	}
+
+	server.SetUserID("bot")
+	if _, err := server.handleStartTimer(map[string]interface{}{"task_id": "task"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := server.handleStartTimer(map[string]interface{}{"task_id": "story"}); err == nil {
+		t.Errorf("Expected an error starting a second timer")
+	}
+	if _, err := server.handleStopTimer(map[string]interface{}{}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := server.handleStopTimer(map[string]interface{}{}); err == nil {
+		t.Errorf("Expected an error with no timer running")
+	}
+
+	result, err := server.handleLogWork(map[string]interface{}{"task_id": "task", "duration": "1h30m"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Logged 1h30m on task task") {
+		t.Errorf("Expected the logged time, got: %s", result.Content[0].Text)
+	}
+
+	result, err = server.handleLogWork(map[string]interface{}{
+		"task_id":  "story",
+		"duration": "30m",
+		"start":    "2025-06-02T09:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "30m on the task, 2h including subtasks") {
+		t.Errorf("Expected the story's totals, got: %s", result.Content[0].Text)
+	}
+
+	if _, err := server.handleLogWork(map[string]interface{}{"task_id": "task", "duration": "soon"}); err == nil {
+		t.Errorf("Expected an error for an invalid duration")
+	}
+}
+
+func TestMCPServer_Comments(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	task := models.NewTask("Task", "")
+	task.ID = "a"
+	storage.CreateTask(task)
+	agent := models.NewUser("Release bot", models.UserAgent)
+	agent.ID = "bot"
+	storage.CreateUser(agent)
+
+	if _, err := server.handleAddComment(map[string]interface{}{"task_id": "a", "body": "Started"}); err == nil {
+		t.Errorf("Expected an error without an author_id or MCP_USER_ID")
+	}
+
+	server.SetUserID("bot")
+	result, err := server.handleAddComment(map[string]interface{}{"task_id": "a", "body": "Started on the **parser**"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Added comment comment-1 to task a") {
+		t.Errorf("Expected the new comment's ID, got: %s", result.Content[0].Text)
+	}
+	if _, err := server.handleAddComment(map[string]interface{}{"task_id": "a", "body": "Done\nwith tests", "parent_id": "comment-1"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := server.handleAddComment(map[string]interface{}{"task_id": "a", "body": "Hi", "parent_id": "missing"}); err == nil {
+		t.Errorf("Expected an error replying to a missing comment")
+	}
+
+	result, err = server.handleListComments(map[string]interface{}{"task_id": "a"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	text := result.Content[0].Text
+	if !strings.Contains(text, "2 comments on task a") || !strings.Contains(text, "- [comment-1] Release bot at ") ||
+		!strings.Contains(text, "\n  - [comment-2] Release bot at ") || !strings.Contains(text, "\n    with tests\n") {
+		t.Errorf("Expected the threaded comments, got: %s", text)
+	}
+}
+
+func TestMCPServer_TaskHistory(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	task := models.NewTask("Parser", "")
+	task.ID = "a"
+	storage.CreateTask(task)
+	agent := models.NewUser("Release bot", models.UserAgent)
+	agent.ID = "bot"
+	storage.CreateUser(agent)
+
+	if _, err := server.handleGetTaskHistory(map[string]interface{}{"task_id": "missing"}); err == nil {
+		t.Errorf("Expected an error for a missing task")
+	}
+	result, err := server.handleGetTaskHistory(map[string]interface{}{"task_id": "a"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "No recorded changes") {
+		t.Errorf("Expected no changes yet, got: %s", result.Content[0].Text)
+	}
+
+	server.SetUserID("bot")
+	if _, err := server.handleUpdateTask(map[string]interface{}{"id": "a", "status": "in_progress"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(storage.events) != 1 || storage.events[0].ActorID != "bot" || storage.events[0].Source != models.SourceMCP {
+		t.Fatalf("Expected one change recorded as bot over MCP, got: %+v", storage.events)
+	}
+
+	result, err = server.handleGetTaskHistory(map[string]interface{}{"task_id": "a"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	text := result.Content[0].Text
+	if !strings.Contains(text, "History of task Parser (a)") || !strings.Contains(text, "updated by Release bot via mcp") ||
+		!strings.Contains(text, `status: "todo" -> "in_progress"`) {
+		t.Errorf("Expected the status change, got: %s", text)
+	}
+}
+
+func TestMCPServer_Sprints(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	for _, name := range []string{"Sprint 1", "Sprint 2"} {
+		if _, err := server.handleCreateSprint(map[string]interface{}{
+			"name": name, "start_date": "2026-01-05", "end_date": "2026-01-18",
+		}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	if _, err := server.handleCreateSprint(map[string]interface{}{"name": "Undated"}); err == nil {
+		t.Errorf("Expected an error for a sprint without dates")
+	}
+
+	for i, status := range []models.TaskStatus{models.StatusDone, models.StatusInProgress} {
+		task := models.NewTask(fmt.Sprintf("Task %d", i), "")
+		task.ID = fmt.Sprintf("task-%d", i)
+		task.Status = status
+		task.StoryPoints = 2
+		task.SprintID = "sprint-1"
+		storage.tasks[task.ID] = task
+	}
+
+	result, err := server.handleStartSprint(map[string]interface{}{"id": "sprint-1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, `Started sprint "Sprint 1" committing to 2 tasks (4 points)`) {
+		t.Errorf("Expected the commitment, got: %s", result.Content[0].Text)
+	}
+	if _, err := server.handleStartSprint(map[string]interface{}{"id": "sprint-2"}); err == nil ||
+		!strings.Contains(err.Error(), "close_sprint") {
+		t.Errorf("Expected an error pointing at close_sprint, got: %v", err)
+	}
+
+	result, err = server.handleCloseSprint(map[string]interface{}{"id": "sprint-1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "1 tasks completed (2 points); 1 unfinished tasks carried over to sprint sprint-2") {
+		t.Errorf("Expected one task carried over, got: %s", result.Content[0].Text)
+	}
+
+	result, err = server.handleListTasks(map[string]interface{}{"sprint_id": "sprint-2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Found 1 tasks") {
+		t.Errorf("Expected the carried over task in sprint 2, got: %s", result.Content[0].Text)
+	}
+	result, err = server.handleListSprints(map[string]interface{}{"state": "closed"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Found 1 sprints") {
+		t.Errorf("Expected the closed sprint, got: %s", result.Content[0].Text)
+	}
+}
+
+func TestMCPServer_MilestonesResource(t *testing.T) {
+	storage := newMockStorage()
+	server := NewMCPServer(storage)
+
+	due := time.Now().AddDate(0, 0, -1)
+	for i, status := range []models.TaskStatus{models.StatusDone, models.StatusTodo} {
+		task := models.NewTask(fmt.Sprintf("Task %d", i), "")
+		task.ID = fmt.Sprintf("task-%d", i)
+		task.Status = status
+		task.DueDate = &due
+		storage.CreateTask(task)
+	}
+	milestone := models.NewMilestone("1.0", "", time.Now().AddDate(0, 1, 0))
+	milestone.TaskIDs = []string{"task-0", "task-1"}
+	storage.CreateMilestone(milestone)
+
+	response := server.handleResourcesRead(JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "resources/read",
+		Params:  map[string]interface{}{"uri": "projectflow://milestones"},
+	})
+	if response.Error != nil {
+		t.Fatalf("Expected no error, got: %v", response.Error)
+	}
+
+	var reports []models.MilestoneReport
+	text := response.Result.(ResourceReadResult).Contents[0].Text
+	if err := json.Unmarshal([]byte(text), &reports); err != nil {
+		t.Fatalf("Expected milestones JSON, got: %s", text)
+	}
+	if len(reports) != 1 || reports[0].Progress.Percent != 50 || !reports[0].Progress.AtRisk ||
+		len(reports[0].Progress.Overdue) != 1 || reports[0].Progress.Overdue[0] != "task-1" {
+		t.Errorf("Expected 1.0 half done and at risk from task-1, got: %s", text)
+	}
+}
+
 func TestMCPServer_InvalidToolCall(t *testing.T) {
 	storage := newMockStorage()
 	server := NewMCPServer(storage)
@@ -2,10 +2,17 @@ package mcp
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/patch"
+	"github.com/aykay76/projectflow/internal/search"
+	"github.com/aykay76/projectflow/internal/storage"
 )
 
 // handleToolsCall handles tool call requests
@@ -39,6 +46,36 @@ func (s *MCPServer) handleToolsCall(request JSONRPCRequest) JSONRPCResponse {
 		result, callErr = s.handleDeleteTask(toolCallReq.Arguments)
 	case "get_task_hierarchy":
 		result, callErr = s.handleGetTaskHierarchy(toolCallReq.Arguments)
+	case "search_tasks":
+		result, callErr = s.handleSearchTasks(toolCallReq.Arguments)
+	case "link_tasks":
+		result, callErr = s.handleLinkTasks(toolCallReq.Arguments)
+	case "unlink_tasks":
+		result, callErr = s.handleUnlinkTasks(toolCallReq.Arguments)
+	case "assign_task":
+		result, callErr = s.handleAssignTask(toolCallReq.Arguments)
+	case "list_my_tasks":
+		result, callErr = s.handleListMyTasks(toolCallReq.Arguments)
+	case "start_timer":
+		result, callErr = s.handleStartTimer(toolCallReq.Arguments)
+	case "stop_timer":
+		result, callErr = s.handleStopTimer(toolCallReq.Arguments)
+	case "log_work":
+		result, callErr = s.handleLogWork(toolCallReq.Arguments)
+	case "add_comment":
+		result, callErr = s.handleAddComment(toolCallReq.Arguments)
+	case "list_comments":
+		result, callErr = s.handleListComments(toolCallReq.Arguments)
+	case "get_task_history":
+		result, callErr = s.handleGetTaskHistory(toolCallReq.Arguments)
+	case "create_sprint":
+		result, callErr = s.handleCreateSprint(toolCallReq.Arguments)
+	case "list_sprints":
+		result, callErr = s.handleListSprints(toolCallReq.Arguments)
+	case "start_sprint":
+		result, callErr = s.handleStartSprint(toolCallReq.Arguments)
+	case "close_sprint":
+		result, callErr = s.handleCloseSprint(toolCallReq.Arguments)
 	default:
 		return s.createErrorResponse(request.ID, -32601, "Unknown tool", nil)
 	}
@@ -62,24 +99,67 @@ func (s *MCPServer) handleToolsCall(request JSONRPCRequest) JSONRPCResponse {
 
 // handleListTasks handles the list_tasks tool call
 func (s *MCPServer) handleListTasks(args map[string]interface{}) (ToolCallResult, error) {
-	tasks, err := s.storage.ListTasks()
+	query, err := storage.ParseTaskQuery(func(name string) (string, bool) {
+		return argumentString(args, name)
+	})
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	return s.queryTasks(query)
+}
+
+// queryTasks runs query and reports the page of tasks found
+func (s *MCPServer) queryTasks(query storage.TaskQuery) (ToolCallResult, error) {
+	page, err := s.storage.QueryTasks(query)
 	if err != nil {
 		return ToolCallResult{}, fmt.Errorf("failed to list tasks: %w", err)
 	}
 
-	tasksJSON, err := json.MarshalIndent(tasks, "", "  ")
+	tasksJSON, err := json.MarshalIndent(page.Tasks, "", "  ")
 	if err != nil {
 		return ToolCallResult{}, fmt.Errorf("failed to marshal tasks: %w", err)
 	}
 
+	text := fmt.Sprintf("Found %d tasks:\n\n%s", page.Total, string(tasksJSON))
+	if page.NextCursor != "" {
+		text = fmt.Sprintf("Found %d tasks, showing %d:\n\n%s\n\nnext_cursor: %s",
+			page.Total, len(page.Tasks), string(tasksJSON), page.NextCursor)
+	}
+
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: fmt.Sprintf("Found %d tasks:\n\n%s", len(tasks), string(tasksJSON)),
+			Text: text,
 		}},
 	}, nil
 }
 
+// argumentString returns a tool argument as a string. Numbers and booleans
+// are formatted and arrays are joined with commas.
+func argumentString(args map[string]interface{}, name string) (string, bool) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ","), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
 // handleCreateTask handles the create_task tool call
 func (s *MCPServer) handleCreateTask(args map[string]interface{}) (ToolCallResult, error) {
 	title, ok := args["title"].(string)
@@ -115,15 +195,61 @@ func (s *MCPServer) handleCreateTask(args map[string]interface{}) (ToolCallResul
 	}
 
 	if parentID, ok := args["parent_id"].(string); ok && parentID != "" {
+		parentID, err := storage.ResolveTaskID(s.storage, parentID)
+		if err != nil {
+			return ToolCallResult{}, fmt.Errorf("failed to get parent task: %w", err)
+		}
 		task.ParentID = parentID
 	}
 
+	if assigneeID, ok := args["assignee_id"].(string); ok {
+		task.AssigneeID = assigneeID
+	}
+	task.ReporterID = s.userID
+	if reporterID, ok := args["reporter_id"].(string); ok && reporterID != "" {
+		task.ReporterID = reporterID
+	}
+
 	if dueDate, ok := args["due_date"].(string); ok && dueDate != "" {
 		if err := task.SetDueDate(dueDate); err != nil {
 			return ToolCallResult{}, fmt.Errorf("invalid due date format: %w", err)
 		}
 	}
 
+	if labels, ok := argumentString(args, "labels"); ok && labels != "" {
+		task.Labels = strings.Split(labels, ",")
+	}
+
+	task.SprintID, _ = args["sprint_id"].(string)
+
+	if values, ok := args["custom_fields"].(map[string]interface{}); ok {
+		task.CustomFields = values
+	}
+	if err := models.CurrentWorkflow().CheckCustomFields(task); err != nil {
+		return ToolCallResult{}, err
+	}
+
+	if value, ok := args["story_points"]; ok && value != nil {
+		points, ok := value.(float64)
+		if !ok || points < 0 {
+			return ToolCallResult{}, fmt.Errorf("story_points must be a number of at least 0")
+		}
+		task.StoryPoints = points
+	}
+
+	for name, estimate := range map[string]*models.Estimate{
+		"original_estimate":  &task.OriginalEstimate,
+		"remaining_estimate": &task.RemainingEstimate,
+	} {
+		if value, ok := args[name].(string); ok {
+			parsed, err := models.ParseEstimate(value)
+			if err != nil {
+				return ToolCallResult{}, err
+			}
+			*estimate = parsed
+		}
+	}
+
 	if err := s.storage.CreateTask(task); err != nil {
 		return ToolCallResult{}, fmt.Errorf("failed to create task: %w", err)
 	}
@@ -143,9 +269,9 @@ func (s *MCPServer) handleCreateTask(args map[string]interface{}) (ToolCallResul
 
 // handleGetTask handles the get_task tool call
 func (s *MCPServer) handleGetTask(args map[string]interface{}) (ToolCallResult, error) {
-	id, ok := args["id"].(string)
-	if !ok || id == "" {
-		return ToolCallResult{}, fmt.Errorf("id is required and must be a string")
+	id, err := s.taskArgument(args, "id")
+	if err != nil {
+		return ToolCallResult{}, err
 	}
 
 	task, err := s.storage.GetTask(id)
@@ -166,11 +292,14 @@ func (s *MCPServer) handleGetTask(args map[string]interface{}) (ToolCallResult,
 	}, nil
 }
 
+// updateTaskFields are the update_task arguments that change the task
+var updateTaskFields = []string{"title", "description", "status", "priority", "type", "parent_id", "assignee_id", "reporter_id", "labels", "sprint_id", "custom_fields", "story_points", "original_estimate", "remaining_estimate", "due_date"}
+
 // handleUpdateTask handles the update_task tool call
 func (s *MCPServer) handleUpdateTask(args map[string]interface{}) (ToolCallResult, error) {
-	id, ok := args["id"].(string)
-	if !ok || id == "" {
-		return ToolCallResult{}, fmt.Errorf("id is required and must be a string")
+	id, err := s.taskArgument(args, "id")
+	if err != nil {
+		return ToolCallResult{}, err
 	}
 
 	// Get existing task
@@ -179,118 +308,734 @@ func (s *MCPServer) handleUpdateTask(args map[string]interface{}) (ToolCallResul
 		return ToolCallResult{}, fmt.Errorf("failed to get existing task: %w", err)
 	}
 
-	// Update fields
-	task := *existingTask
+	fields := make(map[string]interface{})
+	for _, name := range updateTaskFields {
+		value, ok := args[name]
+		if !ok {
+			continue
+		}
+		if value == "" {
+			switch name {
+			case "title", "status", "priority", "type":
+				// An empty string leaves these unchanged
+				continue
+			case "parent_id", "assignee_id", "reporter_id", "labels", "sprint_id", "original_estimate", "remaining_estimate", "due_date":
+				value = nil
+			}
+		}
+		if parentID, ok := value.(string); ok && name == "parent_id" {
+			if value, err = storage.ResolveTaskID(s.storage, parentID); err != nil {
+				return ToolCallResult{}, fmt.Errorf("failed to get parent task: %w", err)
+			}
+		}
+		fields[name] = value
+	}
 
-	if title, ok := args["title"].(string); ok && title != "" {
-		task.Title = title
+	// Explicit nulls clear fields, as in a merge patch
+	task, err := patch.Task(existingTask, patch.NewMergePatch(fields))
+	if err != nil {
+		return ToolCallResult{}, err
 	}
 
-	if description, ok := args["description"].(string); ok {
-		task.Description = description
+	if err := s.saveTask(task, args); err != nil {
+		return ToolCallResult{}, err
 	}
 
-	if status, ok := args["status"].(string); ok && status != "" {
-		if !models.IsValidStatus(status) {
-			return ToolCallResult{}, fmt.Errorf("invalid status: %s", status)
+	taskJSON, err := json.MarshalIndent(&task, "", "  ")
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Successfully updated task:\n\n%s", string(taskJSON)),
+		}},
+	}, nil
+}
+
+// saveTask stores a task changed by a tool call. Without expected_version in
+// args the update is checked against the version the task was read at.
+func (s *MCPServer) saveTask(task *models.Task, args map[string]interface{}) error {
+	if value, ok := args["expected_version"]; ok {
+		expected, ok := value.(float64)
+		if !ok || expected < 1 || expected != float64(int64(expected)) {
+			return fmt.Errorf("expected_version must be a positive integer")
 		}
-		task.Status = models.TaskStatus(status)
+		task.Version = int64(expected)
+	}
 
-		// Auto-set start date if status changes to in_progress
-		if task.Status == models.StatusInProgress && task.StartedAt == nil {
-			now := time.Now()
-			task.StartedAt = &now
+	if err := s.storage.UpdateTask(task); err != nil {
+		if errors.Is(err, storage.ErrVersionConflict) {
+			return fmt.Errorf("%w; get the task again and reapply your changes", err)
 		}
+		return fmt.Errorf("failed to update task: %w", err)
 	}
+	return nil
+}
 
-	if priority, ok := args["priority"].(string); ok && priority != "" {
-		if !models.IsValidPriority(priority) {
-			return ToolCallResult{}, fmt.Errorf("invalid priority: %s", priority)
+// handleDeleteTask handles the delete_task tool call
+func (s *MCPServer) handleDeleteTask(args map[string]interface{}) (ToolCallResult, error) {
+	id, err := s.taskArgument(args, "id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+
+	strategyName, _ := args["strategy"].(string)
+	strategy, err := storage.ParseDeleteStrategy(strategyName)
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+
+	// Get task info before deletion for confirmation
+	task, err := s.storage.GetTask(id)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	result, err := s.storage.DeleteTaskWithStrategy(id, strategy, 0)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to delete task: %w", err)
+	}
+	if s.attachments != nil {
+		// The tasks are gone, so a failure here only leaves files behind
+		if err := storage.DeleteAttachmentsOf(s.attachments, result); err != nil {
+			log.Printf("Error deleting attachments: %v", err)
 		}
-		task.Priority = models.TaskPriority(priority)
 	}
 
-	if taskType, ok := args["type"].(string); ok && taskType != "" {
-		if !models.IsValidType(taskType) {
-			return ToolCallResult{}, fmt.Errorf("invalid type: %s", taskType)
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to marshal delete result: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Successfully deleted task: %s\n\n%s", taskName(task), string(resultJSON)),
+		}},
+	}, nil
+}
+
+// handleGetTaskHierarchy handles the get_task_hierarchy tool call
+func (s *MCPServer) handleGetTaskHierarchy(args map[string]interface{}) (ToolCallResult, error) {
+	hierarchy, err := s.storage.GetTaskHierarchy()
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to get task hierarchy: %w", err)
+	}
+
+	hierarchyJSON, err := json.MarshalIndent(hierarchy, "", "  ")
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to marshal hierarchy: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Task hierarchy:\n\n%s", string(hierarchyJSON)),
+		}},
+	}, nil
+}
+
+// handleSearchTasks handles the search_tasks tool call
+func (s *MCPServer) handleSearchTasks(args map[string]interface{}) (ToolCallResult, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return ToolCallResult{}, fmt.Errorf("query is required and must be a string")
+	}
+
+	limit := 10
+	if value, ok := args["limit"].(float64); ok {
+		if value < 0 {
+			return ToolCallResult{}, fmt.Errorf("limit must not be negative")
 		}
-		task.Type = models.TaskType(taskType)
+		limit = int(value)
 	}
 
-	if parentID, ok := args["parent_id"].(string); ok {
-		task.ParentID = parentID
+	filter, filtered, err := storage.ParseSearchFilter(func(name string) (string, bool) {
+		return argumentString(args, name)
+	})
+	if err != nil {
+		return ToolCallResult{}, err
 	}
 
-	if dueDate, ok := args["due_date"].(string); ok {
-		if dueDate == "" {
-			task.DueDate = nil
-		} else {
-			if err := task.SetDueDate(dueDate); err != nil {
-				return ToolCallResult{}, fmt.Errorf("invalid due date format: %w", err)
-			}
+	var results []*storage.SearchResult
+	if !filtered {
+		results, err = s.storage.SearchTasks(query, limit)
+	} else {
+		// Filter every match so the limit counts only the ones kept
+		results, err = s.storage.SearchTasks(query, 0)
+		results = storage.FilterSearchResults(results, filter, limit)
+	}
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to search tasks: %w", err)
+	}
+
+	if len(results) == 0 {
+		return ToolCallResult{
+			Content: []Content{{
+				Type: "text",
+				Text: fmt.Sprintf("No tasks match %q", query),
+			}},
+		}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d matching tasks:\n", len(results))
+	for i, result := range results {
+		task := result.Task
+		fmt.Fprintf(&b, "\n%d. %s\n   Key: %s | ID: %s | Type: %s | Status: %s | Priority: %s | Score: %.2f\n",
+			i+1, search.Highlight(task.Title, result.TitleMatches, search.TextMarker),
+			task.Key, task.ID, task.Type, task.Status, task.Priority, result.Score)
+		if task.Description != "" {
+			fmt.Fprintf(&b, "   %s\n", search.Snippet(task.Description, result.DescriptionMatches, 160, search.TextMarker))
 		}
 	}
 
-	task.UpdatedAt = time.Now()
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: b.String(),
+		}},
+	}, nil
+}
 
-	if err := s.storage.UpdateTask(&task); err != nil {
-		return ToolCallResult{}, fmt.Errorf("failed to update task: %w", err)
+// handleLinkTasks handles the link_tasks tool call
+func (s *MCPServer) handleLinkTasks(args map[string]interface{}) (ToolCallResult, error) {
+	fromID, linkType, toID, err := s.linkArguments(args)
+	if err != nil {
+		return ToolCallResult{}, err
 	}
 
-	taskJSON, err := json.MarshalIndent(&task, "", "  ")
+	if err := s.storage.LinkTasks(fromID, linkType, toID); err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to link tasks: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Linked: %s %s %s", fromID, linkType, toID),
+		}},
+	}, nil
+}
+
+// handleUnlinkTasks handles the unlink_tasks tool call
+func (s *MCPServer) handleUnlinkTasks(args map[string]interface{}) (ToolCallResult, error) {
+	fromID, linkType, toID, err := s.linkArguments(args)
 	if err != nil {
-		return ToolCallResult{}, fmt.Errorf("failed to marshal task: %w", err)
+		return ToolCallResult{}, err
+	}
+
+	if err := s.storage.UnlinkTasks(fromID, linkType, toID); err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to unlink tasks: %w", err)
 	}
 
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: fmt.Sprintf("Successfully updated task:\n\n%s", string(taskJSON)),
+			Text: fmt.Sprintf("Removed link: %s %s %s", fromID, linkType, toID),
 		}},
 	}, nil
 }
 
-// handleDeleteTask handles the delete_task tool call
-func (s *MCPServer) handleDeleteTask(args map[string]interface{}) (ToolCallResult, error) {
-	id, ok := args["id"].(string)
-	if !ok || id == "" {
-		return ToolCallResult{}, fmt.Errorf("id is required and must be a string")
+// handleAssignTask handles the assign_task tool call
+func (s *MCPServer) handleAssignTask(args map[string]interface{}) (ToolCallResult, error) {
+	id, err := s.taskArgument(args, "id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	value, ok := args["user_id"]
+	if !ok {
+		return ToolCallResult{}, fmt.Errorf("user_id is required; pass null to unassign the task")
+	}
+	userID, ok := value.(string)
+	if value != nil && !ok {
+		return ToolCallResult{}, fmt.Errorf("user_id must be a string or null")
 	}
 
-	// Get task info before deletion for confirmation
 	task, err := s.storage.GetTask(id)
 	if err != nil {
 		return ToolCallResult{}, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if err := s.storage.DeleteTask(id); err != nil {
-		return ToolCallResult{}, fmt.Errorf("failed to delete task: %w", err)
+	task.AssigneeID = userID
+	task.UpdatedAt = time.Now()
+	if err := s.saveTask(task, args); err != nil {
+		return ToolCallResult{}, err
+	}
+
+	text := fmt.Sprintf("Unassigned task: %s", taskName(task))
+	if userID != "" {
+		user, err := s.storage.GetUser(userID)
+		if err != nil {
+			return ToolCallResult{}, fmt.Errorf("failed to get user: %w", err)
+		}
+		text = fmt.Sprintf("Assigned task %s to %s (%s, %s)", taskName(task), user.Name, user.ID, user.Kind)
 	}
 
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: fmt.Sprintf("Successfully deleted task: %s (%s)", task.Title, task.ID),
+			Text: text,
 		}},
 	}, nil
 }
 
-// handleGetTaskHierarchy handles the get_task_hierarchy tool call
-func (s *MCPServer) handleGetTaskHierarchy(args map[string]interface{}) (ToolCallResult, error) {
-	hierarchy, err := s.storage.GetTaskHierarchy()
+// userArgument returns the user ID argument called name, defaulting to the
+// user this server acts as
+func (s *MCPServer) userArgument(args map[string]interface{}, name string) (string, error) {
+	userID, _ := args[name].(string)
+	if userID == "" {
+		userID = s.userID
+	}
+	if userID == "" {
+		return "", fmt.Errorf("%s is required because this server doesn't act as a user; set MCP_USER_ID to give it one", name)
+	}
+	return userID, nil
+}
+
+// taskArgument returns the ID of the task named by the argument called name,
+// which may hold the task's key or its ID
+func (s *MCPServer) taskArgument(args map[string]interface{}, name string) (string, error) {
+	ref, ok := args[name].(string)
+	if !ok || ref == "" {
+		return "", fmt.Errorf("%s is required and must be a string", name)
+	}
+	id, err := storage.ResolveTaskID(s.storage, ref)
 	if err != nil {
-		return ToolCallResult{}, fmt.Errorf("failed to get task hierarchy: %w", err)
+		return "", fmt.Errorf("failed to get task: %w", err)
 	}
+	return id, nil
+}
 
-	hierarchyJSON, err := json.MarshalIndent(hierarchy, "", "  ")
+// taskName names a task in tool output by its key, title and ID
+func taskName(task *models.Task) string {
+	if task.Key == "" {
+		return fmt.Sprintf("%s (%s)", task.Title, task.ID)
+	}
+	return fmt.Sprintf("%s %s (%s)", task.Key, task.Title, task.ID)
+}
+
+// taskRef refers to a task in lists of tasks by its key, or by its ID if it
+// has none
+func taskRef(task *models.Task) string {
+	if task.Key == "" {
+		return task.ID
+	}
+	return task.Key
+}
+
+// handleListMyTasks handles the list_my_tasks tool call
+func (s *MCPServer) handleListMyTasks(args map[string]interface{}) (ToolCallResult, error) {
+	userID, err := s.userArgument(args, "user_id")
 	if err != nil {
-		return ToolCallResult{}, fmt.Errorf("failed to marshal hierarchy: %w", err)
+		return ToolCallResult{}, err
+	}
+
+	query, err := storage.ParseTaskQuery(func(name string) (string, bool) {
+		switch name {
+		case "status", "sort", "limit", "cursor":
+			return argumentString(args, name)
+		}
+		return "", false
+	})
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	query.AssigneeID = &userID
+	return s.queryTasks(query)
+}
+
+// handleStartTimer handles the start_timer tool call
+func (s *MCPServer) handleStartTimer(args map[string]interface{}) (ToolCallResult, error) {
+	taskID, err := s.taskArgument(args, "task_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	userID, err := s.userArgument(args, "user_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+
+	timer := models.NewWorkLog(taskID, userID)
+	timer.Note, _ = args["note"].(string)
+	if err := s.storage.CreateWorkLog(timer); err != nil {
+		if errors.Is(err, storage.ErrTimerRunning) {
+			return ToolCallResult{}, fmt.Errorf("%w; stop it with stop_timer first", err)
+		}
+		return ToolCallResult{}, fmt.Errorf("failed to start timer: %w", err)
 	}
 
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: fmt.Sprintf("Task hierarchy:\n\n%s", string(hierarchyJSON)),
+			Text: fmt.Sprintf("Started timer %s on task %s at %s", timer.ID, taskID, timer.Start.Format(time.RFC3339)),
+		}},
+	}, nil
+}
+
+// handleStopTimer handles the stop_timer tool call
+func (s *MCPServer) handleStopTimer(args map[string]interface{}) (ToolCallResult, error) {
+	userID, err := s.userArgument(args, "user_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+
+	timer, err := s.storage.StopTimer(userID, time.Now())
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to stop timer: %w", err)
+	}
+	return s.workLogged(timer)
+}
+
+// handleLogWork handles the log_work tool call
+func (s *MCPServer) handleLogWork(args map[string]interface{}) (ToolCallResult, error) {
+	taskID, err := s.taskArgument(args, "task_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	userID, err := s.userArgument(args, "user_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	durationArg, _ := args["duration"].(string)
+	duration, err := models.ParseEstimate(durationArg)
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	if duration == 0 {
+		return ToolCallResult{}, fmt.Errorf("duration is required, such as 45m or 1h30m")
+	}
+
+	log := models.NewWorkLog(taskID, userID)
+	log.Duration = duration
+	log.Note, _ = args["note"].(string)
+	log.Start = log.Start.Add(-duration.Duration())
+	if start, ok := args["start"].(string); ok && start != "" {
+		if log.Start, err = time.Parse(time.RFC3339, start); err != nil {
+			return ToolCallResult{}, fmt.Errorf("invalid start, use RFC 3339: %w", err)
+		}
+	}
+
+	if err := s.storage.CreateWorkLog(log); err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to log work: %w", err)
+	}
+	return s.workLogged(log)
+}
+
+// workLogged reports a finished work log with the time now logged against
+// its task
+func (s *MCPServer) workLogged(log *models.WorkLog) (ToolCallResult, error) {
+	summary, err := storage.SummarizeTime(s.storage, log.TaskID)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to summarize time: %w", err)
+	}
+
+	text := fmt.Sprintf("Logged %s on task %s. Time logged: %s on the task, %s including subtasks",
+		log.Duration, log.TaskID, summary.Logged, summary.SubtreeLogged)
+	if summary.OriginalEstimate > 0 {
+		text += fmt.Sprintf(" (estimate: %s)", summary.OriginalEstimate)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: text,
+		}},
+	}, nil
+}
+
+// handleAddComment handles the add_comment tool call
+func (s *MCPServer) handleAddComment(args map[string]interface{}) (ToolCallResult, error) {
+	taskID, err := s.taskArgument(args, "task_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	body, ok := args["body"].(string)
+	if !ok || body == "" {
+		return ToolCallResult{}, fmt.Errorf("body is required and must be a string")
+	}
+	authorID, err := s.userArgument(args, "author_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+
+	comment := models.NewComment(taskID, authorID, body)
+	comment.ParentID, _ = args["parent_id"].(string)
+	if err := s.storage.CreateComment(comment); err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	text := fmt.Sprintf("Added comment %s to task %s", comment.ID, taskID)
+	if comment.ParentID != "" {
+		text = fmt.Sprintf("Added comment %s to task %s in reply to %s", comment.ID, taskID, comment.ParentID)
+	}
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: text,
+		}},
+	}, nil
+}
+
+// handleListComments handles the list_comments tool call
+func (s *MCPServer) handleListComments(args map[string]interface{}) (ToolCallResult, error) {
+	taskID, err := s.taskArgument(args, "task_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	if !s.storage.TaskExists(taskID) {
+		return ToolCallResult{}, fmt.Errorf("task not found: %s", taskID)
+	}
+
+	comments, err := s.storage.ListComments(taskID)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to list comments: %w", err)
+	}
+	if len(comments) == 0 {
+		return ToolCallResult{
+			Content: []Content{{
+				Type: "text",
+				Text: fmt.Sprintf("No comments on task %s", taskID),
+			}},
+		}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d comments on task %s:\n", len(comments), taskID)
+	var writeThreads func(threads []*models.CommentThread, depth int)
+	writeThreads = func(threads []*models.CommentThread, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, thread := range threads {
+			edited := ""
+			if thread.EditedAt != nil {
+				edited = ", edited"
+			}
+			fmt.Fprintf(&b, "\n%s- [%s] %s at %s%s:\n", indent, thread.ID, s.userName(thread.AuthorID),
+				thread.CreatedAt.Format(time.RFC3339), edited)
+			for _, line := range strings.Split(thread.Body, "\n") {
+				fmt.Fprintf(&b, "%s  %s\n", indent, line)
+			}
+			writeThreads(thread.Replies, depth+1)
+		}
+	}
+	writeThreads(models.ThreadComments(comments), 0)
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: b.String(),
+		}},
+	}, nil
+}
+
+// userName returns a user's name, or their ID if they can't be found
+func (s *MCPServer) userName(id string) string {
+	if user, err := s.storage.GetUser(id); err == nil {
+		return user.Name
+	}
+	return id
+}
+
+// handleGetTaskHistory handles the get_task_history tool call
+func (s *MCPServer) handleGetTaskHistory(args map[string]interface{}) (ToolCallResult, error) {
+	taskID, err := s.taskArgument(args, "task_id")
+	if err != nil {
+		return ToolCallResult{}, err
+	}
+	limit := 20
+	if value, ok := args["limit"].(float64); ok {
+		if value < 0 {
+			return ToolCallResult{}, fmt.Errorf("limit must not be negative")
+		}
+		limit = int(value)
+	}
+
+	events, err := s.storage.ListEvents(storage.EventQuery{TaskID: taskID, Limit: limit})
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to get task history: %w", err)
+	}
+	if len(events) == 0 {
+		if !s.storage.TaskExists(taskID) {
+			return ToolCallResult{}, fmt.Errorf("task not found: %s", taskID)
+		}
+		return ToolCallResult{
+			Content: []Content{{
+				Type: "text",
+				Text: fmt.Sprintf("No recorded changes to task %s", taskID),
+			}},
+		}, nil
+	}
+
+	// The history outlives the task, which is then named by its last title
+	name := fmt.Sprintf("%s (%s)", events[0].TaskTitle, taskID)
+	if task, err := s.storage.GetTask(taskID); err == nil {
+		name = taskName(task)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "History of task %s, newest first:\n", name)
+	for _, event := range events {
+		actor := "unknown user"
+		if event.ActorID != "" {
+			actor = s.userName(event.ActorID)
+		}
+		fmt.Fprintf(&b, "\n- %s %s by %s via %s\n", event.CreatedAt.Format(time.RFC3339), event.Action, actor, event.Source)
+		if event.Action != models.ActionUpdated {
+			continue
+		}
+		for _, change := range event.Changes {
+			fmt.Fprintf(&b, "  %s: %s -> %s\n", change.Field, historyValue(change.Before), historyValue(change.After))
+		}
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: b.String(),
 		}},
 	}, nil
 }
+
+// handleCreateSprint handles the create_sprint tool call
+func (s *MCPServer) handleCreateSprint(args map[string]interface{}) (ToolCallResult, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return ToolCallResult{}, fmt.Errorf("name is required and must be a string")
+	}
+	goal, _ := args["goal"].(string)
+
+	sprint := models.NewSprint(name, goal, time.Time{}, time.Time{})
+	start, _ := args["start_date"].(string)
+	if err := sprint.SetStartDate(start); err != nil {
+		return ToolCallResult{}, fmt.Errorf("start_date is required in YYYY-MM-DD format")
+	}
+	end, _ := args["end_date"].(string)
+	if err := sprint.SetEndDate(end); err != nil {
+		return ToolCallResult{}, fmt.Errorf("end_date is required in YYYY-MM-DD format")
+	}
+
+	if err := s.storage.CreateSprint(sprint); err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to create sprint: %w", err)
+	}
+	return sprintResult("Successfully created sprint", sprint)
+}
+
+// handleListSprints handles the list_sprints tool call
+func (s *MCPServer) handleListSprints(args map[string]interface{}) (ToolCallResult, error) {
+	state, _ := args["state"].(string)
+	if state != "" && !models.IsValidSprintState(state) {
+		return ToolCallResult{}, fmt.Errorf("invalid state: %s", state)
+	}
+
+	sprints, err := s.storage.ListSprints()
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to list sprints: %w", err)
+	}
+	selected := []*models.Sprint{}
+	for _, sprint := range sprints {
+		if state == "" || sprint.State == models.SprintState(state) {
+			selected = append(selected, sprint)
+		}
+	}
+
+	sprintsJSON, err := json.MarshalIndent(selected, "", "  ")
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to marshal sprints: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("Found %d sprints:\n\n%s", len(selected), string(sprintsJSON)),
+		}},
+	}, nil
+}
+
+// handleStartSprint handles the start_sprint tool call
+func (s *MCPServer) handleStartSprint(args map[string]interface{}) (ToolCallResult, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return ToolCallResult{}, fmt.Errorf("id is required and must be a string")
+	}
+
+	sprint, err := s.storage.StartSprint(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrSprintActive) {
+			return ToolCallResult{}, fmt.Errorf("%w; close it with close_sprint first", err)
+		}
+		return ToolCallResult{}, fmt.Errorf("failed to start sprint: %w", err)
+	}
+
+	summary := fmt.Sprintf("Started sprint %q committing to %d tasks (%g points)",
+		sprint.Name, len(sprint.Committed), sprint.CommittedPoints)
+	return sprintResult(summary, sprint)
+}
+
+// handleCloseSprint handles the close_sprint tool call
+func (s *MCPServer) handleCloseSprint(args map[string]interface{}) (ToolCallResult, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return ToolCallResult{}, fmt.Errorf("id is required and must be a string")
+	}
+	nextID, _ := args["next_sprint_id"].(string)
+
+	sprint, err := s.storage.CloseSprint(id, nextID)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to close sprint: %w", err)
+	}
+
+	summary := fmt.Sprintf("Closed sprint %q with %d tasks completed (%g points)",
+		sprint.Name, len(sprint.Completed), sprint.CompletedPoints)
+	if sprint.CarriedOverTo != "" {
+		summary += fmt.Sprintf("; %d unfinished tasks carried over to sprint %s", len(sprint.CarriedOver), sprint.CarriedOverTo)
+	} else if len(sprint.CarriedOver) > 0 {
+		summary += fmt.Sprintf("; %d unfinished tasks moved to the backlog", len(sprint.CarriedOver))
+	}
+	return sprintResult(summary, sprint)
+}
+
+// sprintResult reports a sprint after summary
+func sprintResult(summary string, sprint *models.Sprint) (ToolCallResult, error) {
+	sprintJSON, err := json.MarshalIndent(sprint, "", "  ")
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("failed to marshal sprint: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: fmt.Sprintf("%s:\n\n%s", summary, string(sprintJSON)),
+		}},
+	}, nil
+}
+
+// historyValue formats a field value from the task history, shortening long
+// ones such as descriptions
+func historyValue(value json.RawMessage) string {
+	text := string(value)
+	if text == "" {
+		text = "null"
+	}
+	if runes := []rune(text); len(runes) > 80 {
+		text = string(runes[:77]) + "..."
+	}
+	return text
+}
+
+// linkArguments reads the arguments shared by link_tasks and unlink_tasks
+func (s *MCPServer) linkArguments(args map[string]interface{}) (string, models.LinkType, string, error) {
+	fromID, err := s.taskArgument(args, "id")
+	if err != nil {
+		return "", "", "", err
+	}
+	toID, err := s.taskArgument(args, "target_id")
+	if err != nil {
+		return "", "", "", err
+	}
+	linkType, _ := args["type"].(string)
+	if !models.IsValidLinkType(linkType) {
+		return "", "", "", fmt.Errorf("invalid link type: %s", linkType)
+	}
+	return fromID, models.LinkType(linkType), toID, nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Attachment is a file attached to a task. Its content is kept by an
+// attachment store, which finds it by its SHA-256.
+type Attachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewAttachment creates an attachment to a task, before its content is stored
+func NewAttachment(taskID, filename, contentType string) *Attachment {
+	return &Attachment{
+		TaskID:      taskID,
+		Filename:    CleanFilename(filename),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// CleanFilename makes an uploaded file name safe to store and to send back
+// in a header: the base name only, without control characters and at most
+// 255 bytes
+func CleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	for len(name) > 255 {
+		// Trim whole runes so the name stays valid UTF-8
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
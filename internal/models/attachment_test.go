@@ -0,0 +1,25 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanFilename(t *testing.T) {
+	tests := map[string]string{
+		"report.pdf":          "report.pdf",
+		"../../etc/passwd":    "passwd",
+		`C:\Users\me\log.txt`: "log.txt",
+		"bad\x00\nname.txt":   "badname.txt",
+		"..":                  "",
+		"":                    "",
+	}
+	for input, want := range tests {
+		if got := CleanFilename(input); got != want {
+			t.Errorf("CleanFilename(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if got := CleanFilename(strings.Repeat("é", 200)); len(got) != 254 {
+		t.Errorf("CleanFilename() of a long name is %d bytes, want 254", len(got))
+	}
+}
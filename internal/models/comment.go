@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/aykay76/projectflow/internal/markdown"
+)
+
+// MaxCommentLength is the longest comment body accepted, in bytes
+const MaxCommentLength = 20000
+
+// Comment is a note left on a task by a user. Bodies are Markdown. A comment
+// with a ParentID is a reply to that comment.
+type Comment struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	AuthorID  string     `json:"author_id"`
+	ParentID  string     `json:"parent_id,omitempty"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// NewComment creates a comment by a user on a task
+func NewComment(taskID, authorID, body string) *Comment {
+	return &Comment{
+		TaskID:    taskID,
+		AuthorID:  authorID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Edit replaces the comment's body and records when it was edited
+func (c *Comment) Edit(body string) {
+	now := time.Now()
+	c.Body = body
+	c.EditedAt = &now
+}
+
+// HTML renders the comment's body. Raw HTML in the body is escaped, so the
+// result is safe to show.
+func (c *Comment) HTML() string {
+	return markdown.Render(c.Body)
+}
+
+// CommentThread is a comment with its body rendered and its replies
+type CommentThread struct {
+	*Comment
+	BodyHTML string           `json:"body_html"`
+	Replies  []*CommentThread `json:"replies"`
+}
+
+// NewCommentThread renders a comment, without its replies
+func NewCommentThread(comment *Comment) *CommentThread {
+	return &CommentThread{
+		Comment:  comment,
+		BodyHTML: comment.HTML(),
+		Replies:  []*CommentThread{},
+	}
+}
+
+// ThreadComments arranges comments into threads, keeping their order within
+// each level. A reply whose parent isn't among comments starts a thread of
+// its own.
+func ThreadComments(comments []*Comment) []*CommentThread {
+	threads := make(map[string]*CommentThread, len(comments))
+	for _, comment := range comments {
+		threads[comment.ID] = NewCommentThread(comment)
+	}
+
+	roots := []*CommentThread{}
+	for _, comment := range comments {
+		thread := threads[comment.ID]
+		if parent, ok := threads[comment.ParentID]; ok && comment.ParentID != comment.ID {
+			parent.Replies = append(parent.Replies, thread)
+		} else {
+			roots = append(roots, thread)
+		}
+	}
+	return roots
+}
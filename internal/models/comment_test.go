@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThreadComments(t *testing.T) {
+	now := time.Now()
+	comment := func(id, parentID string) *Comment {
+		return &Comment{ID: id, ParentID: parentID, Body: "*" + id + "*", CreatedAt: now}
+	}
+
+	threads := ThreadComments([]*Comment{
+		comment("a", ""),
+		comment("b", "a"),
+		comment("c", ""),
+		comment("d", "b"),
+		comment("e", "gone"),
+	})
+
+	if len(threads) != 3 || threads[0].ID != "a" || threads[1].ID != "c" || threads[2].ID != "e" {
+		t.Fatalf("ThreadComments() roots = %v, want a, c and e", threads)
+	}
+	if len(threads[0].Replies) != 1 || len(threads[0].Replies[0].Replies) != 1 || threads[0].Replies[0].Replies[0].ID != "d" {
+		t.Errorf("ThreadComments() didn't nest d under b under a")
+	}
+	if threads[0].BodyHTML != "<p><em>a</em></p>" {
+		t.Errorf("BodyHTML = %q, want rendered Markdown", threads[0].BodyHTML)
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CustomFieldType is the kind of value a custom field holds
+type CustomFieldType string
+
+const (
+	FieldString CustomFieldType = "string"
+	FieldNumber CustomFieldType = "number"
+	// FieldEnum holds one of the field's options
+	FieldEnum CustomFieldType = "enum"
+	// FieldDate holds a date in YYYY-MM-DD format
+	FieldDate CustomFieldType = "date"
+	// FieldUser holds the ID of a user
+	FieldUser CustomFieldType = "user"
+	// FieldURL holds an http or https URL
+	FieldURL CustomFieldType = "url"
+)
+
+// CustomField defines a field that tasks of some type can carry in their
+// CustomFields, such as a component on stories
+type CustomField struct {
+	Name        string          `json:"name"`
+	Type        CustomFieldType `json:"type"`
+	Description string          `json:"description,omitempty"`
+	Options     []string        `json:"options,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+}
+
+// FieldValues holds a task's custom field values by field name: a float64
+// for number fields and a string for the rest
+type FieldValues map[string]interface{}
+
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// validate checks a custom field definition
+func (f CustomField) validate() error {
+	if !customFieldNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("custom field name %q must be lower case letters, digits or underscores, starting with a letter", f.Name)
+	}
+	switch f.Type {
+	case FieldString, FieldNumber, FieldDate, FieldUser, FieldURL:
+		if len(f.Options) > 0 {
+			return fmt.Errorf("custom field %q has options but isn't an enum", f.Name)
+		}
+	case FieldEnum:
+		if len(f.Options) == 0 {
+			return fmt.Errorf("custom field %q is an enum without options", f.Name)
+		}
+	default:
+		return fmt.Errorf("custom field %q has invalid type %q", f.Name, f.Type)
+	}
+	return nil
+}
+
+// normalize checks value against the field and returns its canonical form:
+// a float64 for numbers and a string for everything else
+func (f CustomField) normalize(value interface{}) (interface{}, error) {
+	if f.Type == FieldNumber {
+		switch number := value.(type) {
+		case float64:
+			return number, nil
+		case int:
+			return float64(number), nil
+		case string:
+			// Agents often send numbers as strings
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("custom field %q must be a number", f.Name)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("custom field %q must be a string", f.Name)
+	}
+	text = strings.TrimSpace(text)
+	switch f.Type {
+	case FieldEnum:
+		for _, option := range f.Options {
+			if text == option {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("custom field %q must be one of %s", f.Name, strings.Join(f.Options, ", "))
+	case FieldDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return nil, fmt.Errorf("custom field %q must be a date in YYYY-MM-DD format", f.Name)
+		}
+	case FieldURL:
+		parsed, err := url.ParseRequestURI(text)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("custom field %q must be an http or https URL", f.Name)
+		}
+	}
+	return text, nil
+}
+
+// CustomFieldsFor returns the custom fields defined for tasks of type
+// taskType
+func (w *Workflow) CustomFieldsFor(taskType TaskType) []CustomField {
+	return w.CustomFields[taskType]
+}
+
+// CheckCustomFields puts task's custom field values in canonical form and
+// checks them against the fields its type defines. A field set to null or
+// a blank string is removed. Whether a user field names an existing user
+// is left to storage.
+func (w *Workflow) CheckCustomFields(task *Task) error {
+	fields := make(map[string]CustomField)
+	for _, field := range w.CustomFieldsFor(task.Type) {
+		fields[field.Name] = field
+	}
+
+	values := make(FieldValues, len(task.CustomFields))
+	for name, value := range task.CustomFields {
+		if text, ok := value.(string); value == nil || ok && strings.TrimSpace(text) == "" {
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("custom field %q is not defined for %s tasks", name, task.Type)
+		}
+		normalized, err := field.normalize(value)
+		if err != nil {
+			return err
+		}
+		values[name] = normalized
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if _, ok := values[field.Name]; field.Required && !ok {
+			missing = append(missing, field.Name)
+		}
+	}
+	switch len(missing) {
+	case 0:
+	case 1:
+		return fmt.Errorf("custom field %q is required for %s tasks", missing[0], task.Type)
+	default:
+		sort.Strings(missing)
+		return fmt.Errorf("custom fields %s are required for %s tasks", strings.Join(missing, ", "), task.Type)
+	}
+
+	task.CustomFields = nil
+	if len(values) > 0 {
+		task.CustomFields = values
+	}
+	return nil
+}
+
+// CustomFieldUsers returns the user IDs held in task's user custom fields
+func (w *Workflow) CustomFieldUsers(task *Task) []string {
+	var ids []string
+	for _, field := range w.CustomFieldsFor(task.Type) {
+		if id, ok := task.CustomFields[field.Name].(string); ok && field.Type == FieldUser && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SetCustomFields merges values into task's custom fields; a null value
+// removes the field
+func (t *Task) SetCustomFields(values FieldValues) {
+	if len(values) == 0 {
+		return
+	}
+	merged := make(FieldValues, len(t.CustomFields)+len(values))
+	for name, value := range t.CustomFields {
+		merged[name] = value
+	}
+	for name, value := range values {
+		if value == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = value
+		}
+	}
+	t.CustomFields = merged
+}
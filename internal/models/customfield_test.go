@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+const fieldsWorkflow = `{
+	"initial": "todo",
+	"statuses": [{"id": "todo", "category": "todo"}, {"id": "done", "category": "done"}],
+	"custom_fields": {
+		"story": [{"name": "component", "type": "string", "required": true}],
+		"task": [
+			{"name": "severity", "type": "enum", "options": ["low", "high"]},
+			{"name": "cost", "type": "number"},
+			{"name": "found_on", "type": "date"},
+			{"name": "owner", "type": "user"},
+			{"name": "ticket", "type": "url"}
+		]
+	}
+}`
+
+func TestWorkflow_CheckCustomFields(t *testing.T) {
+	workflow, err := ParseWorkflow([]byte(fieldsWorkflow))
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+
+	task := &Task{Type: TypeTask, CustomFields: FieldValues{
+		"severity": "high",
+		"cost":     "2.5",
+		"found_on": "2026-03-01",
+		"owner":    "user-1",
+		"ticket":   " https://example.com/T-1 ",
+		"notes":    nil,
+	}}
+	if err := workflow.CheckCustomFields(task); err != nil {
+		t.Fatalf("CheckCustomFields() error = %v", err)
+	}
+	if task.CustomFields["cost"] != 2.5 || task.CustomFields["ticket"] != "https://example.com/T-1" || len(task.CustomFields) != 5 {
+		t.Errorf("CheckCustomFields() = %v, want canonical values without the null one", task.CustomFields)
+	}
+	if users := workflow.CustomFieldUsers(task); len(users) != 1 || users[0] != "user-1" {
+		t.Errorf("CustomFieldUsers() = %v, want user-1", users)
+	}
+
+	tests := []struct {
+		name   string
+		task   *Task
+		errMsg string
+	}{
+		{"undefined field", &Task{Type: TypeTask, CustomFields: FieldValues{"component": "api"}}, "not defined for task tasks"},
+		{"option not listed", &Task{Type: TypeTask, CustomFields: FieldValues{"severity": "urgent"}}, "must be one of low, high"},
+		{"not a number", &Task{Type: TypeTask, CustomFields: FieldValues{"cost": "cheap"}}, "must be a number"},
+		{"number as string field", &Task{Type: TypeTask, CustomFields: FieldValues{"owner": 7.0}}, "must be a string"},
+		{"bad date", &Task{Type: TypeTask, CustomFields: FieldValues{"found_on": "March 1st"}}, "YYYY-MM-DD"},
+		{"bad URL", &Task{Type: TypeTask, CustomFields: FieldValues{"ticket": "ftp://example.com"}}, "http or https URL"},
+		{"required missing", &Task{Type: TypeStory, CustomFields: FieldValues{"component": " "}}, `"component" is required for story tasks`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := workflow.CheckCustomFields(tt.task)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("CheckCustomFields() error = %v, want one containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestTask_SetCustomFields(t *testing.T) {
+	task := &Task{CustomFields: FieldValues{"severity": "low", "cost": 1.0}}
+	clone := task.Clone()
+
+	task.SetCustomFields(FieldValues{"severity": "high", "cost": nil})
+	if len(task.CustomFields) != 1 || task.CustomFields["severity"] != "high" {
+		t.Errorf("SetCustomFields() = %v, want severity high and cost removed", task.CustomFields)
+	}
+	if clone.CustomFields["severity"] != "low" || clone.CustomFields["cost"] != 1.0 {
+		t.Errorf("clone changed to %v, want its own copy", clone.CustomFields)
+	}
+}
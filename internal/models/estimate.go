@@ -0,0 +1,180 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Estimate is an amount of work, written in JSON like "2h30m"
+type Estimate time.Duration
+
+// ParseEstimate parses an estimate such as "45m", "4h" or "2h30m". An empty
+// string is no estimate.
+func ParseEstimate(s string) (Estimate, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid estimate %q: use a duration like 2h30m", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid estimate %q: must not be negative", s)
+	}
+	return Estimate(d), nil
+}
+
+// Duration returns the estimate as a time.Duration
+func (e Estimate) Duration() time.Duration {
+	return time.Duration(e)
+}
+
+// String formats the estimate in hours and minutes, such as "2h30m"
+func (e Estimate) String() string {
+	d := time.Duration(e)
+	if d%time.Minute != 0 {
+		return d.String()
+	}
+	hours, minutes := d/time.Hour, (d%time.Hour)/time.Minute
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+// MarshalJSON writes the estimate as a string
+func (e Estimate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// UnmarshalJSON reads an estimate written as a string; null is no estimate
+func (e *Estimate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*e = 0
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("estimate must be a string like 2h30m")
+	}
+	parsed, err := ParseEstimate(s)
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+// RemainingWork returns the work left on the task: none once it is done, and
+// otherwise its remaining estimate, falling back to the original estimate
+func (t *Task) RemainingWork() Estimate {
+	if IsDoneStatus(t.Status) {
+		return 0
+	}
+	if t.RemainingEstimate > 0 {
+		return t.RemainingEstimate
+	}
+	return t.OriginalEstimate
+}
+
+// Rollup totals the points and estimates of a task and its descendants
+type Rollup struct {
+	TotalPoints       float64  `json:"total_points"`
+	CompletedPoints   float64  `json:"completed_points"`
+	RemainingPoints   float64  `json:"remaining_points"`
+	OriginalEstimate  Estimate `json:"original_estimate"`
+	RemainingEstimate Estimate `json:"remaining_estimate"`
+}
+
+// RollUp sets the task's Rollup from its own points and estimates and the
+// roll-ups of its children, which must already be set
+func (h *HierarchyTask) RollUp() {
+	rollup := Rollup{
+		TotalPoints:       h.StoryPoints,
+		OriginalEstimate:  h.OriginalEstimate,
+		RemainingEstimate: h.RemainingWork(),
+	}
+	if IsDoneStatus(h.Status) {
+		rollup.CompletedPoints = h.StoryPoints
+	} else {
+		rollup.RemainingPoints = h.StoryPoints
+	}
+
+	for _, child := range h.ChildTasks {
+		rollup.TotalPoints += child.Rollup.TotalPoints
+		rollup.CompletedPoints += child.Rollup.CompletedPoints
+		rollup.RemainingPoints += child.Rollup.RemainingPoints
+		rollup.OriginalEstimate += child.Rollup.OriginalEstimate
+		rollup.RemainingEstimate += child.Rollup.RemainingEstimate
+	}
+	h.Rollup = rollup
+}
+
+// estimateTolerance is how far actual time may stray from the estimate, as a
+// fraction of it, for the estimate to count as on target
+const estimateTolerance = 0.1
+
+// EstimateAccuracy compares a finished task's original estimate with the time
+// it actually took
+type EstimateAccuracy struct {
+	TaskID   string   `json:"task_id"`
+	Title    string   `json:"title"`
+	Estimate Estimate `json:"estimate"`
+	Actual   Estimate `json:"actual"`
+	// Ratio is actual over estimate; above 1 means it took longer than estimated
+	Ratio float64 `json:"ratio"`
+}
+
+// AccuracyReport summarises how well finished tasks were estimated
+type AccuracyReport struct {
+	Tasks         []EstimateAccuracy `json:"tasks"`
+	TotalEstimate Estimate           `json:"total_estimate"`
+	TotalActual   Estimate           `json:"total_actual"`
+	Ratio         float64            `json:"ratio"`
+	Over          int                `json:"over"`
+	Under         int                `json:"under"`
+	OnTarget      int                `json:"on_target"`
+}
+
+// NewAccuracyReport builds an accuracy report from the tasks that are done,
+// were started and have an original estimate. Actual time is the task's
+// actual duration from GetActualDuration, to the minute.
+func NewAccuracyReport(tasks []*Task) *AccuracyReport {
+	report := &AccuracyReport{Tasks: []EstimateAccuracy{}}
+	for _, task := range tasks {
+		if !IsDoneStatus(task.Status) || !task.IsStarted() || task.OriginalEstimate <= 0 {
+			continue
+		}
+
+		actual := Estimate(task.GetActualDuration().Round(time.Minute))
+		ratio := float64(actual) / float64(task.OriginalEstimate)
+		report.Tasks = append(report.Tasks, EstimateAccuracy{
+			TaskID:   task.ID,
+			Title:    task.Title,
+			Estimate: task.OriginalEstimate,
+			Actual:   actual,
+			Ratio:    ratio,
+		})
+
+		report.TotalEstimate += task.OriginalEstimate
+		report.TotalActual += actual
+		switch {
+		case ratio > 1+estimateTolerance:
+			report.Over++
+		case ratio < 1-estimateTolerance:
+			report.Under++
+		default:
+			report.OnTarget++
+		}
+	}
+
+	if report.TotalEstimate > 0 {
+		report.Ratio = float64(report.TotalActual) / float64(report.TotalEstimate)
+	}
+	return report
+}
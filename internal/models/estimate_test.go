@@ -0,0 +1,140 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: "0m"},
+		{input: "45m", want: "45m"},
+		{input: "4h", want: "4h"},
+		{input: "2h30m", want: "2h30m"},
+		{input: "90m", want: "1h30m"},
+		{input: "1.5h", want: "1h30m"},
+		{input: "-1h", wantErr: true},
+		{input: "3 days", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseEstimate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEstimate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseEstimate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate_JSON(t *testing.T) {
+	task := NewTask("Estimated", "")
+	task.OriginalEstimate = Estimate(150 * time.Minute)
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	if fields["original_estimate"] != "2h30m" {
+		t.Errorf("original_estimate = %v, want 2h30m", fields["original_estimate"])
+	}
+	if _, ok := fields["remaining_estimate"]; ok {
+		t.Errorf("remaining_estimate should be omitted when unset")
+	}
+
+	var decoded Task
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.OriginalEstimate != task.OriginalEstimate {
+		t.Errorf("OriginalEstimate = %s, want %s", decoded.OriginalEstimate, task.OriginalEstimate)
+	}
+
+	for _, invalid := range []string{`{"original_estimate": 3600}`, `{"original_estimate": "-2h"}`} {
+		if err := json.Unmarshal([]byte(invalid), &decoded); err == nil {
+			t.Errorf("Unmarshal(%s) should fail", invalid)
+		}
+	}
+}
+
+func TestHierarchyTask_RollUp(t *testing.T) {
+	newTask := func(status TaskStatus, points float64, original, remaining time.Duration) *HierarchyTask {
+		task := NewTask("Task", "")
+		task.Status = status
+		task.StoryPoints = points
+		task.OriginalEstimate = Estimate(original)
+		task.RemainingEstimate = Estimate(remaining)
+		return &HierarchyTask{Task: task}
+	}
+
+	done := newTask(StatusDone, 3, 4*time.Hour, time.Hour)
+	started := newTask(StatusInProgress, 5, 8*time.Hour, 2*time.Hour)
+	unstarted := newTask(StatusTodo, 2, 3*time.Hour, 0)
+	story := newTask(StatusInProgress, 0, 0, 0)
+	story.ChildTasks = []*HierarchyTask{started, unstarted}
+	epic := newTask(StatusInProgress, 0, 0, 0)
+	epic.ChildTasks = []*HierarchyTask{done, story}
+
+	for _, task := range []*HierarchyTask{done, started, unstarted, story, epic} {
+		task.RollUp()
+	}
+
+	want := Rollup{
+		TotalPoints:       10,
+		CompletedPoints:   3,
+		RemainingPoints:   7,
+		OriginalEstimate:  Estimate(15 * time.Hour),
+		RemainingEstimate: Estimate(5 * time.Hour),
+	}
+	if epic.Rollup != want {
+		t.Errorf("RollUp() = %+v, want %+v", epic.Rollup, want)
+	}
+}
+
+func TestNewAccuracyReport(t *testing.T) {
+	finished := func(estimate, took time.Duration) *Task {
+		task := NewTask("Finished", "")
+		started := time.Now().Add(-48 * time.Hour)
+		completed := started.Add(took)
+		task.Status = StatusDone
+		task.StartedAt = &started
+		task.CompletedAt = &completed
+		task.OriginalEstimate = Estimate(estimate)
+		return task
+	}
+
+	unestimated := finished(0, time.Hour)
+	inProgress := finished(time.Hour, time.Hour)
+	inProgress.Status = StatusInProgress
+
+	report := NewAccuracyReport([]*Task{
+		finished(2*time.Hour, 4*time.Hour),
+		finished(2*time.Hour, time.Hour),
+		finished(4*time.Hour, 4*time.Hour+10*time.Minute),
+		unestimated,
+		inProgress,
+	})
+
+	if len(report.Tasks) != 3 {
+		t.Fatalf("NewAccuracyReport() has %d tasks, want 3", len(report.Tasks))
+	}
+	if report.Over != 1 || report.Under != 1 || report.OnTarget != 1 {
+		t.Errorf("over/under/on target = %d/%d/%d, want 1/1/1", report.Over, report.Under, report.OnTarget)
+	}
+	if report.Tasks[0].Ratio != 2 {
+		t.Errorf("Tasks[0].Ratio = %v, want 2", report.Tasks[0].Ratio)
+	}
+	if report.TotalEstimate.String() != "8h" || report.TotalActual.String() != "9h10m" {
+		t.Errorf("totals = %s estimated, %s actual, want 8h and 9h10m", report.TotalEstimate, report.TotalActual)
+	}
+}
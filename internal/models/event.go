@@ -0,0 +1,144 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// EventSource is the interface a change was made through
+type EventSource string
+
+const (
+	SourceWeb EventSource = "web"
+	SourceAPI EventSource = "api"
+	SourceMCP EventSource = "mcp"
+)
+
+// IsValidEventSource reports whether source is one of the known sources
+func IsValidEventSource(source EventSource) bool {
+	switch source {
+	case SourceWeb, SourceAPI, SourceMCP:
+		return true
+	}
+	return false
+}
+
+// EventAction is what a change did to a task
+type EventAction string
+
+const (
+	ActionCreated EventAction = "created"
+	ActionUpdated EventAction = "updated"
+	ActionDeleted EventAction = "deleted"
+)
+
+// FieldChange is the JSON value of a task field before and after a change.
+// A field that was unset, or the task didn't exist, is null.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// TaskEvent records one change to a task: who made it, through which
+// interface and the fields it changed. TaskTitle is the title at the time,
+// so events stay readable once the task is deleted.
+type TaskEvent struct {
+	ID        string        `json:"id"`
+	TaskID    string        `json:"task_id"`
+	TaskTitle string        `json:"task_title"`
+	Action    EventAction   `json:"action"`
+	ActorID   string        `json:"actor_id,omitempty"`
+	Source    EventSource   `json:"source"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// untrackedFields are maintained by the storage or change with every write,
+// so they aren't part of a task's history
+var untrackedFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// DiffTasks returns the fields that differ between two versions of a task,
+// ordered by field name. A nil before is a task being created and a nil
+// after one being deleted.
+func DiffTasks(before, after *Task) []FieldChange {
+	beforeFields, afterFields := taskFields(before), taskFields(after)
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, name := range names {
+		if untrackedFields[name] || bytes.Equal(beforeFields[name], afterFields[name]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Before: beforeFields[name], After: afterFields[name]})
+	}
+	return changes
+}
+
+// NewTaskEvent works out the event for a change from before to after, or
+// returns nil when no tracked field changed
+func NewTaskEvent(before, after *Task, actorID string, source EventSource, at time.Time) *TaskEvent {
+	changes := DiffTasks(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	event := &TaskEvent{
+		Action:    ActionUpdated,
+		ActorID:   actorID,
+		Source:    source,
+		Changes:   changes,
+		CreatedAt: at,
+	}
+	switch {
+	case before == nil:
+		event.Action = ActionCreated
+	case after == nil:
+		event.Action = ActionDeleted
+	}
+	if after != nil {
+		event.TaskID, event.TaskTitle = after.ID, after.Title
+	} else {
+		event.TaskID, event.TaskTitle = before.ID, before.Title
+	}
+	return event
+}
+
+// taskFields returns the JSON encoding of each field of task that is set
+func taskFields(task *Task) map[string]json.RawMessage {
+	if task == nil {
+		return nil
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	// An empty list is as unset as a missing one
+	for name, value := range fields {
+		if string(value) == "[]" {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTaskEvent(t *testing.T) {
+	now := time.Now()
+	before := &Task{ID: "t1", Title: "Write docs", Status: StatusTodo, Priority: PriorityLow, Type: TypeTask, Version: 1, Children: []string{}, CreatedAt: now}
+	after := *before
+	after.Status = StatusInProgress
+	after.ParentID = "epic"
+	after.Version = 2
+	after.Children = []string{"c1"}
+	after.UpdatedAt = now.Add(time.Minute)
+
+	event := NewTaskEvent(before, &after, "alice", SourceAPI, now)
+	if event == nil || event.Action != ActionUpdated || event.TaskID != "t1" || event.ActorID != "alice" {
+		t.Fatalf("NewTaskEvent() = %+v, want an update by alice", event)
+	}
+	want := []FieldChange{
+		{Field: "children", Before: nil, After: []byte(`["c1"]`)},
+		{Field: "parent_id", Before: nil, After: []byte(`"epic"`)},
+		{Field: "status", Before: []byte(`"todo"`), After: []byte(`"in_progress"`)},
+	}
+	if len(event.Changes) != len(want) {
+		t.Fatalf("Changes = %+v, want children, parent_id and status only", event.Changes)
+	}
+	for i, change := range event.Changes {
+		if change.Field != want[i].Field || string(change.Before) != string(want[i].Before) || string(change.After) != string(want[i].After) {
+			t.Errorf("Changes[%d] = %s %s -> %s, want %s %s -> %s", i, change.Field, change.Before, change.After, want[i].Field, want[i].Before, want[i].After)
+		}
+	}
+
+	// Only untracked fields changed
+	touched := *before
+	touched.Version = 5
+	if event := NewTaskEvent(before, &touched, "", SourceAPI, now); event != nil {
+		t.Errorf("NewTaskEvent() with no tracked change = %+v, want nil", event)
+	}
+
+	created := NewTaskEvent(nil, before, "", SourceMCP, now)
+	deleted := NewTaskEvent(before, nil, "", SourceWeb, now)
+	if created == nil || created.Action != ActionCreated || created.Changes[0].Before != nil {
+		t.Errorf("NewTaskEvent(nil, task) = %+v, want a creation", created)
+	}
+	if deleted == nil || deleted.Action != ActionDeleted || deleted.TaskTitle != "Write docs" || deleted.Changes[0].After != nil {
+		t.Errorf("NewTaskEvent(task, nil) = %+v, want a deletion", deleted)
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultKeyPrefix starts task keys when the workflow doesn't set a prefix
+const DefaultKeyPrefix = "PF"
+
+var (
+	keyPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}$`)
+	taskKeyPattern   = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}-([1-9][0-9]{0,18})$`)
+)
+
+// TaskKey returns the key of the task numbered number, such as PF-123
+func (w *Workflow) TaskKey(number uint64) string {
+	prefix := w.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return fmt.Sprintf("%s-%d", prefix, number)
+}
+
+// ParseTaskKey reports whether s is a task key, ignoring case, and returns
+// it in upper case with its number. Task IDs are never keys.
+func ParseTaskKey(s string) (string, uint64, bool) {
+	key := strings.ToUpper(strings.TrimSpace(s))
+	match := taskKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return "", 0, false
+	}
+	number, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return key, number, true
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestWorkflow_TaskKey(t *testing.T) {
+	if got := (&Workflow{}).TaskKey(7); got != "PF-7" {
+		t.Errorf("TaskKey() without a prefix = %q, want PF-7", got)
+	}
+	if got := (&Workflow{KeyPrefix: "WEB2"}).TaskKey(123); got != "WEB2-123" {
+		t.Errorf("TaskKey() = %q, want WEB2-123", got)
+	}
+}
+
+func TestParseTaskKey(t *testing.T) {
+	tests := []struct {
+		in     string
+		key    string
+		number uint64
+		ok     bool
+	}{
+		{"PF-123", "PF-123", 123, true},
+		{" web2-7 ", "WEB2-7", 7, true},
+		{"PF-0", "", 0, false},
+		{"PF-", "", 0, false},
+		{"2PF-1", "", 0, false},
+		{"PF-99999999999999999999", "", 0, false},
+		{"7c9e6679-7425-40de-944b-e07fc1f90ae7", "", 0, false},
+	}
+	for _, tt := range tests {
+		key, number, ok := ParseTaskKey(tt.in)
+		if key != tt.key || number != tt.number || ok != tt.ok {
+			t.Errorf("ParseTaskKey(%q) = %q, %d, %v, want %q, %d, %v", tt.in, key, number, ok, tt.key, tt.number, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultLabelColor is used for labels created without a colour
+const DefaultLabelColor = "#6c757d"
+
+// Label is a registered tag that tasks can carry, such as a component name
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	labelNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,49}$`)
+	labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// NormalizeLabelName returns the canonical form of a label name: trimmed and
+// lower case
+func NormalizeLabelName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// IsValidLabelName checks if name is a canonical label name: up to 50 lower
+// case letters, digits, dots, dashes and underscores, starting with a letter
+// or digit
+func IsValidLabelName(name string) bool {
+	return labelNamePattern.MatchString(name)
+}
+
+// IsValidLabelColor checks if color is a #rrggbb hex colour
+func IsValidLabelColor(color string) bool {
+	return labelColorPattern.MatchString(color)
+}
+
+// NormalizeLabels returns the canonical, sorted and de-duplicated form of a
+// list of label names
+func NormalizeLabels(labels []string) ([]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(labels))
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		name := NormalizeLabelName(label)
+		if !IsValidLabelName(name) {
+			return nil, fmt.Errorf("invalid label name %q", label)
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// HasLabel reports whether the task carries label
+func (t *Task) HasLabel(label string) bool {
+	for _, existing := range t.Labels {
+		if existing == label {
+			return true
+		}
+	}
+	return false
+}
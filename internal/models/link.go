@@ -0,0 +1,86 @@
+package models
+
+// LinkType describes how a task relates to another task
+type LinkType string
+
+const (
+	LinkBlocks       LinkType = "blocks"
+	LinkBlockedBy    LinkType = "blocked_by"
+	LinkRelatesTo    LinkType = "relates_to"
+	LinkDuplicates   LinkType = "duplicates"
+	LinkDuplicatedBy LinkType = "duplicated_by"
+)
+
+// TaskLink is one end of a link between two tasks. Both tasks store the link,
+// each with the type as seen from its own side.
+type TaskLink struct {
+	Type   LinkType `json:"type"`
+	TaskID string   `json:"task_id"`
+}
+
+// IsValidLinkType checks if the given link type is valid
+func IsValidLinkType(linkType string) bool {
+	switch LinkType(linkType) {
+	case LinkBlocks, LinkBlockedBy, LinkRelatesTo, LinkDuplicates, LinkDuplicatedBy:
+		return true
+	default:
+		return false
+	}
+}
+
+// Inverse returns the link type stored on the other task
+func (l LinkType) Inverse() LinkType {
+	switch l {
+	case LinkBlocks:
+		return LinkBlockedBy
+	case LinkBlockedBy:
+		return LinkBlocks
+	case LinkDuplicates:
+		return LinkDuplicatedBy
+	case LinkDuplicatedBy:
+		return LinkDuplicates
+	default:
+		return l
+	}
+}
+
+// AddLink adds a link to another task, reporting false if it already exists
+func (t *Task) AddLink(linkType LinkType, taskID string) bool {
+	if t.HasLink(linkType, taskID) {
+		return false
+	}
+	t.Links = append(t.Links, TaskLink{Type: linkType, TaskID: taskID})
+	return true
+}
+
+// RemoveLink removes a link to another task, reporting false if it didn't exist
+func (t *Task) RemoveLink(linkType LinkType, taskID string) bool {
+	for i, link := range t.Links {
+		if link.Type == linkType && link.TaskID == taskID {
+			t.Links = append(t.Links[:i], t.Links[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// HasLink reports whether the task has the given link
+func (t *Task) HasLink(linkType LinkType, taskID string) bool {
+	for _, link := range t.Links {
+		if link.Type == linkType && link.TaskID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkedTasks returns the IDs of the tasks linked with linkType
+func (t *Task) LinkedTasks(linkType LinkType) []string {
+	var ids []string
+	for _, link := range t.Links {
+		if link.Type == linkType {
+			ids = append(ids, link.TaskID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+)
+
+// Milestone is a target date that a set of tasks and epics must be done by,
+// such as a release
+type Milestone struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	TargetDate  time.Time `json:"target_date"`
+	// TaskIDs lists the linked tasks; linking an epic or story brings in
+	// its descendants too
+	TaskIDs   []string  `json:"task_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewMilestone creates a milestone with no linked tasks
+func NewMilestone(name, description string, target time.Time) *Milestone {
+	now := time.Now()
+	return &Milestone{
+		Name:        name,
+		Description: description,
+		TargetDate:  target,
+		TaskIDs:     []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// SetTargetDate sets the target date from a string in YYYY-MM-DD format
+func (m *Milestone) SetTargetDate(dateStr string) error {
+	parsed, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return err
+	}
+	m.TargetDate = parsed
+	return nil
+}
+
+// MilestoneProgress is how far a milestone's tasks have got
+type MilestoneProgress struct {
+	Total   int     `json:"total"`
+	Done    int     `json:"done"`
+	Percent float64 `json:"percent"`
+	// Overdue lists the unfinished tasks past their due date
+	Overdue []string `json:"overdue"`
+	// AtRisk is set when a task is overdue or the target day has ended
+	// with work left
+	AtRisk bool `json:"at_risk"`
+	// ProjectedDate is when the work will be done at the rate it has been
+	// done so far, or when the last task was completed. It is unset until a
+	// task is done, and for finished work with no recorded completion time.
+	ProjectedDate *time.Time `json:"projected_date,omitempty"`
+}
+
+// MilestoneReport is a milestone with its progress
+type MilestoneReport struct {
+	*Milestone
+	Progress MilestoneProgress `json:"progress"`
+}
+
+// NewMilestoneReport works out a milestone's progress from tasks, which must
+// include the linked tasks and their descendants. Linked tasks that no
+// longer exist are skipped.
+func NewMilestoneReport(milestone *Milestone, tasks []*Task, now time.Time) *MilestoneReport {
+	byID := make(map[string]*Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	// Walk down from the linked tasks, counting each task once
+	seen := make(map[string]bool)
+	var scope []*Task
+	pending := append([]string{}, milestone.TaskIDs...)
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		task, ok := byID[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		scope = append(scope, task)
+		pending = append(pending, task.Children...)
+	}
+
+	progress := MilestoneProgress{Total: len(scope), Overdue: []string{}}
+	var begun time.Time
+	var finished time.Time
+	for _, task := range scope {
+		if begun.IsZero() || task.CreatedAt.Before(begun) {
+			begun = task.CreatedAt
+		}
+		if IsDoneStatus(task.Status) {
+			progress.Done++
+			if task.CompletedAt != nil && task.CompletedAt.After(finished) {
+				finished = *task.CompletedAt
+			}
+		}
+		if task.IsOverdueAt(now) {
+			progress.Overdue = append(progress.Overdue, task.ID)
+		}
+	}
+	if progress.Total == 0 {
+		return &MilestoneReport{Milestone: milestone, Progress: progress}
+	}
+
+	progress.Percent = float64(progress.Done) / float64(progress.Total) * 100
+	complete := progress.Done == progress.Total
+	// Work is late once the whole target day has gone
+	year, month, day := milestone.TargetDate.Date()
+	deadline := time.Date(year, month, day+1, 0, 0, 0, 0, milestone.TargetDate.Location())
+	progress.AtRisk = len(progress.Overdue) > 0 || (!complete && !now.Before(deadline))
+
+	switch {
+	case complete:
+		if !finished.IsZero() {
+			progress.ProjectedDate = &finished
+		}
+	case progress.Done > 0:
+		// Extrapolate from the rate tasks have been done since the first
+		// one was created
+		elapsed := now.Sub(begun)
+		projected := begun.Add(time.Duration(float64(elapsed) * float64(progress.Total) / float64(progress.Done)))
+		progress.ProjectedDate = &projected
+	}
+	return &MilestoneReport{Milestone: milestone, Progress: progress}
+}
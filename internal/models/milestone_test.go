@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMilestoneReport(t *testing.T) {
+	now := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	created := now.AddDate(0, 0, -10)
+	completed := now.AddDate(0, 0, -2)
+	due := now.AddDate(0, 0, -1)
+
+	epic := &Task{ID: "epic", Status: StatusInProgress, Children: []string{"done", "open"}, CreatedAt: created}
+	tasks := []*Task{
+		epic,
+		{ID: "done", Status: StatusDone, ParentID: "epic", CompletedAt: &completed, CreatedAt: created},
+		{ID: "open", Status: StatusTodo, ParentID: "epic", CreatedAt: created},
+		{ID: "late", Status: StatusTodo, DueDate: &due, CreatedAt: created},
+		{ID: "unlinked", Status: StatusDone, CreatedAt: created},
+	}
+
+	// Linking the epic brings in its children; "open" is linked twice and
+	// "gone" no longer exists
+	milestone := NewMilestone("1.0", "", now.AddDate(0, 0, 30))
+	milestone.TaskIDs = []string{"epic", "late", "open", "gone"}
+
+	progress := NewMilestoneReport(milestone, tasks, now).Progress
+	if progress.Total != 4 || progress.Done != 1 || progress.Percent != 25 {
+		t.Errorf("progress = %d of %d done (%g%%), want 1 of 4 (25%%)", progress.Done, progress.Total, progress.Percent)
+	}
+	if !progress.AtRisk || len(progress.Overdue) != 1 || progress.Overdue[0] != "late" {
+		t.Errorf("progress overdue = %v, at risk %v, want the late task and at risk", progress.Overdue, progress.AtRisk)
+	}
+	// A quarter done in 10 days projects 40 days in all
+	if want := created.AddDate(0, 0, 40); progress.ProjectedDate == nil || !progress.ProjectedDate.Equal(want) {
+		t.Errorf("ProjectedDate = %v, want %v", progress.ProjectedDate, want)
+	}
+
+	// The late task isn't overdue in a report from before its due date
+	earlier := now.AddDate(0, 0, -3)
+	progress = NewMilestoneReport(milestone, tasks, earlier).Progress
+	if progress.AtRisk || len(progress.Overdue) != 0 {
+		t.Errorf("progress as of %v overdue = %v, at risk %v, want nothing overdue", earlier, progress.Overdue, progress.AtRisk)
+	}
+
+	// Once everything is done the projection is when the last task was done
+	for _, task := range tasks {
+		task.Status = StatusDone
+		task.CompletedAt = &completed
+	}
+	progress = NewMilestoneReport(milestone, tasks, now).Progress
+	if progress.Percent != 100 || progress.AtRisk || progress.ProjectedDate == nil || !progress.ProjectedDate.Equal(completed) {
+		t.Errorf("progress = %+v, want complete on %v and not at risk", progress, completed)
+	}
+	if later := NewMilestoneReport(milestone, tasks, now.AddDate(0, 0, 7)).Progress; later.ProjectedDate == nil || !later.ProjectedDate.Equal(completed) {
+		t.Errorf("ProjectedDate a week later = %v, want %v", later.ProjectedDate, completed)
+	}
+
+	// Finished work without completion times has nothing to report
+	for _, task := range tasks {
+		task.CompletedAt = nil
+	}
+	if progress = NewMilestoneReport(milestone, tasks, now).Progress; progress.ProjectedDate != nil {
+		t.Errorf("ProjectedDate without completion times = %v, want none", progress.ProjectedDate)
+	}
+
+	// The target day itself isn't late
+	today := NewMilestone("0.8", "", now)
+	today.TaskIDs = []string{"fresh"}
+	fresh := []*Task{{ID: "fresh", Status: StatusTodo, CreatedAt: created}}
+	if progress = NewMilestoneReport(today, fresh, now.Add(23*time.Hour)).Progress; progress.AtRisk {
+		t.Errorf("progress on the target day = %+v, want not at risk", progress)
+	}
+	if progress = NewMilestoneReport(today, fresh, now.AddDate(0, 0, 1)).Progress; !progress.AtRisk {
+		t.Errorf("progress the day after the target = %+v, want at risk", progress)
+	}
+
+	// Nothing done yet can't be projected, and a passed target is at risk
+	late := NewMilestone("0.9", "", now.AddDate(0, 0, -1))
+	late.TaskIDs = []string{"fresh"}
+	progress = NewMilestoneReport(late, fresh, now).Progress
+	if progress.ProjectedDate != nil || !progress.AtRisk {
+		t.Errorf("progress = %+v, want no projection and at risk", progress)
+	}
+}
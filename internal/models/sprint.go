@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+)
+
+// SprintState is where a sprint is in its life: planned sprints haven't
+// started, at most one sprint is active, and closed sprints are history
+type SprintState string
+
+const (
+	SprintPlanned SprintState = "planned"
+	SprintActive  SprintState = "active"
+	SprintClosed  SprintState = "closed"
+)
+
+// Sprint is a time-boxed iteration that tasks are planned into. The
+// commitment is recorded when it starts and the outcome when it closes.
+type Sprint struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Goal      string      `json:"goal,omitempty"`
+	StartDate time.Time   `json:"start_date"`
+	EndDate   time.Time   `json:"end_date"`
+	State     SprintState `json:"state"`
+	// Committed lists the tasks in the sprint when it started
+	Committed       []string `json:"committed,omitempty"`
+	CommittedPoints float64  `json:"committed_points,omitempty"`
+	// Completed lists the tasks done when it closed, and CarriedOver those
+	// moved on to CarriedOverTo, or to the backlog when that is empty
+	Completed       []string   `json:"completed,omitempty"`
+	CompletedPoints float64    `json:"completed_points,omitempty"`
+	CarriedOver     []string   `json:"carried_over,omitempty"`
+	CarriedOverTo   string     `json:"carried_over_to,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// NewSprint creates a planned sprint
+func NewSprint(name, goal string, start, end time.Time) *Sprint {
+	now := time.Now()
+	return &Sprint{
+		Name:      name,
+		Goal:      goal,
+		StartDate: start,
+		EndDate:   end,
+		State:     SprintPlanned,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsValidSprintState checks if the given sprint state is valid
+func IsValidSprintState(state string) bool {
+	switch SprintState(state) {
+	case SprintPlanned, SprintActive, SprintClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetStartDate sets the start date from a string in YYYY-MM-DD format
+func (s *Sprint) SetStartDate(dateStr string) error {
+	parsed, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return err
+	}
+	s.StartDate = parsed
+	return nil
+}
+
+// SetEndDate sets the end date from a string in YYYY-MM-DD format
+func (s *Sprint) SetEndDate(dateStr string) error {
+	parsed, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return err
+	}
+	s.EndDate = parsed
+	return nil
+}
+
+// Start makes a planned sprint active, committing to tasks, the tasks
+// planned into it
+func (s *Sprint) Start(tasks []*Task, now time.Time) {
+	s.State = SprintActive
+	s.StartedAt = &now
+	s.UpdatedAt = now
+	s.Committed, s.CommittedPoints = sprintTasks(tasks)
+}
+
+// Close closes an active sprint, recording which of tasks, the tasks in it,
+// were done. The unfinished tasks are moved to the sprint nextID, or to the
+// backlog when nextID is empty, and returned.
+func (s *Sprint) Close(tasks []*Task, nextID string, now time.Time) []*Task {
+	var done, carried []*Task
+	for _, task := range tasks {
+		if IsDoneStatus(task.Status) {
+			done = append(done, task)
+		} else {
+			carried = append(carried, task)
+		}
+	}
+
+	s.State = SprintClosed
+	s.ClosedAt = &now
+	s.UpdatedAt = now
+	s.Completed, s.CompletedPoints = sprintTasks(done)
+	s.CarriedOver, _ = sprintTasks(carried)
+	s.CarriedOverTo = ""
+	if len(carried) > 0 {
+		s.CarriedOverTo = nextID
+	}
+
+	for _, task := range carried {
+		task.SprintID = nextID
+		task.UpdatedAt = now
+	}
+	return carried
+}
+
+// Clone returns a deep copy of the sprint
+func (s *Sprint) Clone() *Sprint {
+	clone := *s
+	if s.Committed != nil {
+		clone.Committed = append([]string{}, s.Committed...)
+	}
+	if s.Completed != nil {
+		clone.Completed = append([]string{}, s.Completed...)
+	}
+	if s.CarriedOver != nil {
+		clone.CarriedOver = append([]string{}, s.CarriedOver...)
+	}
+	clone.StartedAt = cloneTime(s.StartedAt)
+	clone.ClosedAt = cloneTime(s.ClosedAt)
+	return &clone
+}
+
+// sprintTasks returns the IDs of tasks and their total story points
+func sprintTasks(tasks []*Task) ([]string, float64) {
+	if len(tasks) == 0 {
+		return nil, 0
+	}
+	ids := make([]string, 0, len(tasks))
+	var points float64
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+		points += task.StoryPoints
+	}
+	return ids, points
+}
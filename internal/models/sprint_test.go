@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSprintStartAndClose(t *testing.T) {
+	now := time.Now()
+	sprint := NewSprint("Sprint 1", "Ship it", now, now.AddDate(0, 0, 13))
+	tasks := []*Task{
+		{ID: "a", Status: StatusDone, StoryPoints: 3, SprintID: "s1"},
+		{ID: "b", Status: StatusInProgress, StoryPoints: 5, SprintID: "s1"},
+		{ID: "c", Status: StatusTodo, SprintID: "s1"},
+	}
+
+	sprint.Start(tasks, now)
+	if sprint.State != SprintActive || sprint.StartedAt == nil ||
+		len(sprint.Committed) != 3 || sprint.CommittedPoints != 8 {
+		t.Errorf("Start() = %+v, want active with 3 tasks and 8 points committed", sprint)
+	}
+
+	carried := sprint.Close(tasks, "s2", now.Add(time.Hour))
+	if sprint.State != SprintClosed || sprint.ClosedAt == nil {
+		t.Errorf("Close() state = %s, want closed with a close time", sprint.State)
+	}
+	if len(sprint.Completed) != 1 || sprint.Completed[0] != "a" || sprint.CompletedPoints != 3 {
+		t.Errorf("Close() completed %v worth %v points, want a worth 3", sprint.Completed, sprint.CompletedPoints)
+	}
+	if len(carried) != 2 || carried[0].SprintID != "s2" || carried[1].SprintID != "s2" || sprint.CarriedOverTo != "s2" {
+		t.Errorf("Close() carried over %v to %q, want b and c moved to s2", sprint.CarriedOver, sprint.CarriedOverTo)
+	}
+	if tasks[0].SprintID != "s1" {
+		t.Errorf("Close() moved the done task to %q, want it kept in the sprint", tasks[0].SprintID)
+	}
+
+	// A sprint with everything done carries nothing over
+	done := NewSprint("Sprint 2", "", now, now)
+	done.Start(tasks[:1], now)
+	if carried := done.Close(tasks[:1], "s3", now); len(carried) != 0 || done.CarriedOverTo != "" {
+		t.Errorf("Close() carried %d tasks to %q, want none", len(carried), done.CarriedOverTo)
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"time"
 )
 
-// TaskStatus represents the status of a task
+// TaskStatus represents the status of a task. The valid statuses come from
+// the current Workflow; these are the ones the default workflow defines.
 type TaskStatus string
 
 const (
@@ -36,19 +37,30 @@ const (
 
 // Task represents a work item in the system
 type Task struct {
-	ID          string       `json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Status      TaskStatus   `json:"status"`
-	Priority    TaskPriority `json:"priority"`
-	Type        TaskType     `json:"type"`
-	ParentID    string       `json:"parent_id,omitempty"`
-	Children    []string     `json:"children"`
-	StartedAt   *time.Time   `json:"started_at,omitempty"`
-	DueDate     *time.Time   `json:"due_date,omitempty"`
-	CompletedAt *time.Time   `json:"completed_at,omitempty"`
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	ID                string       `json:"id"`
+	Key               string       `json:"key,omitempty"`
+	Title             string       `json:"title"`
+	Description       string       `json:"description"`
+	Status            TaskStatus   `json:"status"`
+	Priority          TaskPriority `json:"priority"`
+	Type              TaskType     `json:"type"`
+	ParentID          string       `json:"parent_id,omitempty"`
+	AssigneeID        string       `json:"assignee_id,omitempty"`
+	ReporterID        string       `json:"reporter_id,omitempty"`
+	Labels            []string     `json:"labels,omitempty"`
+	SprintID          string       `json:"sprint_id,omitempty"`
+	CustomFields      FieldValues  `json:"custom_fields,omitempty"`
+	StoryPoints       float64      `json:"story_points,omitempty"`
+	OriginalEstimate  Estimate     `json:"original_estimate,omitempty"`
+	RemainingEstimate Estimate     `json:"remaining_estimate,omitempty"`
+	Children          []string     `json:"children"`
+	Links             []TaskLink   `json:"links,omitempty"`
+	Version           int64        `json:"version"`
+	StartedAt         *time.Time   `json:"started_at,omitempty"`
+	DueDate           *time.Time   `json:"due_date,omitempty"`
+	CompletedAt       *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
 }
 
 // NewTask creates a new task with default values
@@ -57,7 +69,7 @@ func NewTask(title, description string) *Task {
 	return &Task{
 		Title:       title,
 		Description: description,
-		Status:      StatusTodo,
+		Status:      CurrentWorkflow().Initial,
 		Priority:    PriorityMedium,
 		Type:        TypeTask,
 		Children:    []string{},
@@ -88,14 +100,40 @@ func (t *Task) RemoveChild(childID string) {
 	}
 }
 
-// IsValidStatus checks if the given status is valid
-func IsValidStatus(status string) bool {
-	switch TaskStatus(status) {
-	case StatusTodo, StatusInProgress, StatusDone, StatusBlocked:
-		return true
-	default:
-		return false
+// Clone returns a deep copy of the task
+func (t *Task) Clone() *Task {
+	clone := *t
+	clone.Children = append([]string{}, t.Children...)
+	if t.Links != nil {
+		clone.Links = append([]TaskLink{}, t.Links...)
+	}
+	if t.Labels != nil {
+		clone.Labels = append([]string{}, t.Labels...)
+	}
+	if t.CustomFields != nil {
+		clone.CustomFields = make(FieldValues, len(t.CustomFields))
+		for name, value := range t.CustomFields {
+			clone.CustomFields[name] = value
+		}
+	}
+	clone.StartedAt = cloneTime(t.StartedAt)
+	clone.DueDate = cloneTime(t.DueDate)
+	clone.CompletedAt = cloneTime(t.CompletedAt)
+	return &clone
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
+	copied := *t
+	return &copied
+}
+
+// IsValidStatus checks if the given status is defined by the current workflow
+func IsValidStatus(status string) bool {
+	_, ok := CurrentWorkflow().Status(TaskStatus(status))
+	return ok
 }
 
 // IsValidPriority checks if the given priority is valid
@@ -120,10 +158,15 @@ func IsValidType(taskType string) bool {
 
 // IsOverdue checks if the task is overdue
 func (t *Task) IsOverdue() bool {
-	if t.DueDate == nil || t.Status == StatusDone {
+	return t.IsOverdueAt(time.Now())
+}
+
+// IsOverdueAt checks if the task was overdue at the given time
+func (t *Task) IsOverdueAt(now time.Time) bool {
+	if t.DueDate == nil || IsDoneStatus(t.Status) {
 		return false
 	}
-	return time.Now().After(*t.DueDate)
+	return now.After(*t.DueDate)
 }
 
 // DaysUntilDue returns the number of days until the task is due
@@ -244,7 +287,7 @@ func (t *Task) GetActualDuration() time.Duration {
 	endTime := time.Now()
 	if t.CompletedAt != nil {
 		endTime = *t.CompletedAt
-	} else if t.Status == StatusDone && t.UpdatedAt.After(*t.StartedAt) {
+	} else if IsDoneStatus(t.Status) && t.UpdatedAt.After(*t.StartedAt) {
 		// Backward compatibility: use UpdatedAt for completed tasks without CompletedAt
 		endTime = t.UpdatedAt
 	}
@@ -321,4 +364,5 @@ func (t *Task) GetDeliveryVarianceDays() int {
 type HierarchyTask struct {
 	*Task
 	ChildTasks []*HierarchyTask `json:"child_tasks"`
+	Rollup     Rollup           `json:"rollup"`
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -653,3 +654,67 @@ func TestTask_DeliveryPerformance(t *testing.T) {
 		t.Errorf("Task.GetDeliveryVarianceDays() = %v, want %v", days, expectedDays)
 	}
 }
+
+func TestTask_Clone(t *testing.T) {
+	due := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	task := NewTask("Original", "")
+	task.Children = []string{"a", "b"}
+	task.DueDate = &due
+
+	clone := task.Clone()
+	clone.Children[0] = "changed"
+	*clone.DueDate = due.AddDate(0, 0, 1)
+	clone.Title = "Changed"
+
+	if task.Children[0] != "a" {
+		t.Error("Clone() should copy Children")
+	}
+	if !task.DueDate.Equal(due) {
+		t.Error("Clone() should copy DueDate")
+	}
+	if task.Title != "Original" {
+		t.Error("Clone() should not share fields with the original")
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			labels: nil,
+			want:   nil,
+		},
+		{
+			name:   "sorted and de-duplicated",
+			labels: []string{"web-ui", " Backend ", "backend"},
+			want:   []string{"backend", "web-ui"},
+		},
+		{
+			name:    "invalid name",
+			labels:  []string{"front end"},
+			wantErr: true,
+		},
+		{
+			name:    "path separator",
+			labels:  []string{"../labels"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeLabels(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+				t.Errorf("NormalizeLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
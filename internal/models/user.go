@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// UserKind tells people apart from AI agents
+type UserKind string
+
+const (
+	UserHuman UserKind = "human"
+	UserAgent UserKind = "agent"
+)
+
+// User is someone, or some agent, who reports and works on tasks
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Kind      UserKind  `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewUser creates a new user with default values
+func NewUser(name string, kind UserKind) *User {
+	now := time.Now()
+	return &User{
+		Name:      name,
+		Kind:      kind,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsValidUserKind checks if the given user kind is valid
+func IsValidUserKind(kind string) bool {
+	switch UserKind(kind) {
+	case UserHuman, UserAgent:
+		return true
+	default:
+		return false
+	}
+}
+
+// Clone returns a copy of the user
+func (u *User) Clone() *User {
+	clone := *u
+	return &clone
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// WorkflowFileName is the workflow definition looked for in a project's data directory
+const WorkflowFileName = "workflow.json"
+
+// StatusCategory groups workflow statuses by how far along the work is
+type StatusCategory string
+
+const (
+	CategoryTodo       StatusCategory = "todo"
+	CategoryInProgress StatusCategory = "in_progress"
+	CategoryDone       StatusCategory = "done"
+)
+
+// TransitionGuard names a condition a task must meet to take a transition
+type TransitionGuard string
+
+const (
+	// GuardChildrenDone requires every child task to be in a done status
+	GuardChildrenDone TransitionGuard = "children_done"
+	// GuardBlockersDone requires every task blocking this one to be in a done status
+	GuardBlockersDone TransitionGuard = "blockers_done"
+)
+
+// AnyStatus in a transition's From list matches every status
+const AnyStatus TaskStatus = "*"
+
+// WorkflowStatus is a status tasks can be in
+type WorkflowStatus struct {
+	ID       TaskStatus     `json:"id"`
+	Name     string         `json:"name"`
+	Category StatusCategory `json:"category"`
+}
+
+// WorkflowTransition allows a task to move from any status in From to To
+// once every guard passes
+type WorkflowTransition struct {
+	From   []TaskStatus      `json:"from"`
+	To     TaskStatus        `json:"to"`
+	Guards []TransitionGuard `json:"guards,omitempty"`
+}
+
+// Workflow defines a project's statuses and the moves allowed between them.
+// Statuses are listed in board order. Without transitions a task can move
+// between any two statuses. ParentTypes maps each task type to the types its
+// parent may have; a type with no entry can only be a root task.
+// CustomFields defines the custom fields each task type carries, and
+// KeyPrefix starts the keys given to new tasks.
+type Workflow struct {
+	Initial      TaskStatus                 `json:"initial"`
+	Statuses     []WorkflowStatus           `json:"statuses"`
+	Transitions  []WorkflowTransition       `json:"transitions,omitempty"`
+	ParentTypes  map[TaskType][]TaskType    `json:"parent_types"`
+	CustomFields map[TaskType][]CustomField `json:"custom_fields,omitempty"`
+	KeyPrefix    string                     `json:"key_prefix,omitempty"`
+}
+
+// DefaultParentTypes returns the usual nesting: epics are roots, stories sit
+// under epics, tasks under stories and subtasks under tasks
+func DefaultParentTypes() map[TaskType][]TaskType {
+	return map[TaskType][]TaskType{
+		TypeStory:   {TypeEpic},
+		TypeTask:    {TypeStory},
+		TypeSubtask: {TypeTask},
+	}
+}
+
+// DefaultWorkflow returns the built-in statuses with every move allowed
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Initial: StatusTodo,
+		Statuses: []WorkflowStatus{
+			{ID: StatusTodo, Name: "To Do", Category: CategoryTodo},
+			{ID: StatusInProgress, Name: "In Progress", Category: CategoryInProgress},
+			{ID: StatusBlocked, Name: "Blocked", Category: CategoryTodo},
+			{ID: StatusDone, Name: "Done", Category: CategoryDone},
+		},
+		ParentTypes: DefaultParentTypes(),
+		KeyPrefix:   DefaultKeyPrefix,
+	}
+}
+
+// ParseWorkflow parses and validates a JSON workflow definition. Without
+// parent_types the default nesting applies, and without key_prefix task keys
+// start with DefaultKeyPrefix.
+func ParseWorkflow(data []byte) (*Workflow, error) {
+	var workflow Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if workflow.ParentTypes == nil {
+		workflow.ParentTypes = DefaultParentTypes()
+	}
+	if workflow.KeyPrefix == "" {
+		workflow.KeyPrefix = DefaultKeyPrefix
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// LoadWorkflow reads a workflow definition file. A missing file selects the
+// default workflow.
+func LoadWorkflow(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultWorkflow(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+	return ParseWorkflow(data)
+}
+
+// Validate checks that the workflow is internally consistent
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("workflow has no statuses")
+	}
+
+	seen := make(map[TaskStatus]bool, len(w.Statuses))
+	hasDone := false
+	for _, status := range w.Statuses {
+		if status.ID == "" || status.ID == AnyStatus {
+			return fmt.Errorf("workflow status %q is not a valid ID", status.ID)
+		}
+		if seen[status.ID] {
+			return fmt.Errorf("workflow status %q is defined twice", status.ID)
+		}
+		seen[status.ID] = true
+
+		switch status.Category {
+		case CategoryTodo, CategoryInProgress:
+		case CategoryDone:
+			hasDone = true
+		default:
+			return fmt.Errorf("workflow status %q has invalid category %q", status.ID, status.Category)
+		}
+	}
+	if !hasDone {
+		return errors.New("workflow needs at least one status in the done category")
+	}
+	if !seen[w.Initial] {
+		return fmt.Errorf("workflow initial status %q is not defined", w.Initial)
+	}
+	if w.KeyPrefix != "" && !keyPrefixPattern.MatchString(w.KeyPrefix) {
+		return fmt.Errorf("workflow key_prefix %q must be up to 10 upper case letters or digits, starting with a letter", w.KeyPrefix)
+	}
+
+	for _, transition := range w.Transitions {
+		if !seen[transition.To] {
+			return fmt.Errorf("workflow transition to unknown status %q", transition.To)
+		}
+		if len(transition.From) == 0 {
+			return fmt.Errorf("workflow transition to %q has no from statuses", transition.To)
+		}
+		for _, from := range transition.From {
+			if from != AnyStatus && !seen[from] {
+				return fmt.Errorf("workflow transition from unknown status %q", from)
+			}
+		}
+		for _, guard := range transition.Guards {
+			if guard != GuardChildrenDone && guard != GuardBlockersDone {
+				return fmt.Errorf("workflow transition to %q has unknown guard %q", transition.To, guard)
+			}
+		}
+	}
+
+	for child, parents := range w.ParentTypes {
+		if !IsValidType(string(child)) {
+			return fmt.Errorf("workflow parent_types has unknown task type %q", child)
+		}
+		for _, parent := range parents {
+			if !IsValidType(string(parent)) {
+				return fmt.Errorf("workflow parent_types for %q has unknown task type %q", child, parent)
+			}
+		}
+	}
+
+	for taskType, fields := range w.CustomFields {
+		if !IsValidType(string(taskType)) {
+			return fmt.Errorf("workflow custom_fields has unknown task type %q", taskType)
+		}
+		names := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			if err := field.validate(); err != nil {
+				return fmt.Errorf("workflow custom_fields for %q: %w", taskType, err)
+			}
+			if names[field.Name] {
+				return fmt.Errorf("workflow custom_fields for %q defines %q twice", taskType, field.Name)
+			}
+			names[field.Name] = true
+		}
+	}
+	return nil
+}
+
+// Status returns the definition of a status
+func (w *Workflow) Status(id TaskStatus) (WorkflowStatus, bool) {
+	for _, status := range w.Statuses {
+		if status.ID == id {
+			return status, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// StatusIDs returns the status IDs in board order
+func (w *Workflow) StatusIDs() []string {
+	ids := make([]string, len(w.Statuses))
+	for i, status := range w.Statuses {
+		ids[i] = string(status.ID)
+	}
+	return ids
+}
+
+// AllowsParent reports whether a task of type child may be nested under a task
+// of type parent
+func (w *Workflow) AllowsParent(child, parent TaskType) bool {
+	for _, allowed := range w.ParentTypes[child] {
+		if allowed == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns the transition that allows moving from one status to
+// another. Without transitions every move to a defined status is allowed.
+func (w *Workflow) Transition(from, to TaskStatus) (WorkflowTransition, bool) {
+	if _, ok := w.Status(to); !ok {
+		return WorkflowTransition{}, false
+	}
+	if len(w.Transitions) == 0 {
+		return WorkflowTransition{From: []TaskStatus{AnyStatus}, To: to}, true
+	}
+
+	for _, transition := range w.Transitions {
+		if transition.To != to {
+			continue
+		}
+		for _, candidate := range transition.From {
+			if candidate == from || candidate == AnyStatus {
+				return transition, true
+			}
+		}
+	}
+	return WorkflowTransition{}, false
+}
+
+var (
+	currentWorkflow atomic.Pointer[Workflow]
+	defaultWorkflow = DefaultWorkflow()
+)
+
+// SetWorkflow makes workflow the one used to validate task statuses
+func SetWorkflow(workflow *Workflow) {
+	currentWorkflow.Store(workflow)
+}
+
+// CurrentWorkflow returns the workflow in use, the default one unless
+// SetWorkflow has been called
+func CurrentWorkflow() *Workflow {
+	if workflow := currentWorkflow.Load(); workflow != nil {
+		return workflow
+	}
+	return defaultWorkflow
+}
+
+// CategoryOf returns the category of a status in the current workflow, or an
+// empty category for unknown statuses
+func CategoryOf(status TaskStatus) StatusCategory {
+	definition, _ := CurrentWorkflow().Status(status)
+	return definition.Category
+}
+
+// IsDoneStatus reports whether status is in the done category
+func IsDoneStatus(status TaskStatus) bool {
+	return CategoryOf(status) == CategoryDone
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const reviewWorkflow = `{
+	"initial": "backlog",
+	"statuses": [
+		{"id": "backlog", "name": "Backlog", "category": "todo"},
+		{"id": "doing", "name": "Doing", "category": "in_progress"},
+		{"id": "review", "name": "In Review", "category": "in_progress"},
+		{"id": "shipped", "name": "Shipped", "category": "done"}
+	],
+	"transitions": [
+		{"from": ["backlog"], "to": "doing"},
+		{"from": ["doing"], "to": "review"},
+		{"from": ["review"], "to": "shipped", "guards": ["children_done"]},
+		{"from": ["*"], "to": "backlog"}
+	]
+}`
+
+func TestParseWorkflow(t *testing.T) {
+	workflow, err := ParseWorkflow([]byte(reviewWorkflow))
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+	if got := workflow.StatusIDs(); len(got) != 4 || got[2] != "review" {
+		t.Errorf("StatusIDs() = %v, want board order", got)
+	}
+
+	tests := []struct {
+		name     string
+		from, to TaskStatus
+		want     bool
+		guards   int
+	}{
+		{"listed transition", "backlog", "doing", true, 0},
+		{"guarded transition", "review", "shipped", true, 1},
+		{"wildcard from", "shipped", "backlog", true, 0},
+		{"skipping review", "doing", "shipped", false, 0},
+		{"unknown status", "backlog", "done", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition, ok := workflow.Transition(tt.from, tt.to)
+			if ok != tt.want || len(transition.Guards) != tt.guards {
+				t.Errorf("Transition(%s, %s) = %v, %v, want %v with %d guards", tt.from, tt.to, transition, ok, tt.want, tt.guards)
+			}
+		})
+	}
+}
+
+func TestParseWorkflow_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow string
+	}{
+		{"malformed", `{"statuses":`},
+		{"no statuses", `{"initial": "todo"}`},
+		{"duplicate status", `{"initial": "a", "statuses": [{"id": "a", "category": "todo"}, {"id": "a", "category": "done"}]}`},
+		{"unknown category", `{"initial": "a", "statuses": [{"id": "a", "category": "later"}, {"id": "b", "category": "done"}]}`},
+		{"no done status", `{"initial": "a", "statuses": [{"id": "a", "category": "todo"}]}`},
+		{"unknown initial", `{"initial": "x", "statuses": [{"id": "a", "category": "done"}]}`},
+		{"transition to unknown status", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "transitions": [{"from": ["a"], "to": "b"}]}`},
+		{"transition without from", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "transitions": [{"to": "a"}]}`},
+		{"unknown guard", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "transitions": [{"from": ["*"], "to": "a", "guards": ["tests_pass"]}]}`},
+		{"unknown child type", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "parent_types": {"bug": ["story"]}}`},
+		{"unknown parent type", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "parent_types": {"task": ["feature"]}}`},
+		{"custom fields on unknown type", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "custom_fields": {"bug": [{"name": "severity", "type": "string"}]}}`},
+		{"invalid custom field name", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "custom_fields": {"task": [{"name": "Severity", "type": "string"}]}}`},
+		{"unknown custom field type", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "custom_fields": {"task": [{"name": "size", "type": "integer"}]}}`},
+		{"enum without options", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "custom_fields": {"task": [{"name": "severity", "type": "enum"}]}}`},
+		{"duplicate custom field", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "custom_fields": {"task": [{"name": "owner", "type": "user"}, {"name": "owner", "type": "string"}]}}`},
+		{"lower case key prefix", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "key_prefix": "pf"}`},
+		{"key prefix with a dash", `{"initial": "a", "statuses": [{"id": "a", "category": "done"}], "key_prefix": "PF-1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWorkflow([]byte(tt.workflow)); err == nil {
+				t.Error("ParseWorkflow() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	dir := t.TempDir()
+
+	workflow, err := LoadWorkflow(filepath.Join(dir, WorkflowFileName))
+	if err != nil {
+		t.Fatalf("LoadWorkflow() of missing file error = %v", err)
+	}
+	if workflow.Initial != StatusTodo || len(workflow.Statuses) != 4 {
+		t.Errorf("LoadWorkflow() of missing file = %+v, want the default workflow", workflow)
+	}
+
+	path := filepath.Join(dir, "custom.json")
+	if err := os.WriteFile(path, []byte(reviewWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workflow, err = LoadWorkflow(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflow() error = %v", err)
+	}
+
+	SetWorkflow(workflow)
+	t.Cleanup(func() { SetWorkflow(nil) })
+
+	if !IsValidStatus("review") || IsValidStatus("todo") {
+		t.Error("IsValidStatus() should follow the current workflow")
+	}
+	if NewTask("Task", "").Status != "backlog" {
+		t.Error("NewTask() should use the workflow's initial status")
+	}
+	if !IsDoneStatus("shipped") || IsDoneStatus("review") {
+		t.Error("IsDoneStatus() should follow status categories")
+	}
+}
+
+func TestWorkflow_AllowsParent(t *testing.T) {
+	defaults, err := ParseWorkflow([]byte(`{"initial": "a", "statuses": [{"id": "a", "category": "done"}]}`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+	flat, err := ParseWorkflow([]byte(`{"initial": "a", "statuses": [{"id": "a", "category": "done"}],
+		"parent_types": {"task": ["epic", "story", "task"]}}`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		workflow      *Workflow
+		child, parent TaskType
+		want          bool
+	}{
+		{"story under epic", defaults, TypeStory, TypeEpic, true},
+		{"task under story", defaults, TypeTask, TypeStory, true},
+		{"subtask under task", defaults, TypeSubtask, TypeTask, true},
+		{"epic under anything", defaults, TypeEpic, TypeEpic, false},
+		{"task under epic", defaults, TypeTask, TypeEpic, false},
+		{"epic under subtask", defaults, TypeEpic, TypeSubtask, false},
+		{"configured task under task", flat, TypeTask, TypeTask, true},
+		{"unlisted child type is root only", flat, TypeSubtask, TypeTask, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.workflow.AllowsParent(tt.child, tt.parent); got != tt.want {
+				t.Errorf("AllowsParent(%s, %s) = %v, want %v", tt.child, tt.parent, got, tt.want)
+			}
+		})
+	}
+}
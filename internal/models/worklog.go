@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+)
+
+// WorkLog records time a user spent on a task. A work log without an End is
+// a running timer.
+type WorkLog struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	UserID    string     `json:"user_id"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	Duration  Estimate   `json:"duration"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewWorkLog creates a work log for a user on a task, starting now. Without
+// an end or a duration it is a running timer.
+func NewWorkLog(taskID, userID string) *WorkLog {
+	now := time.Now()
+	return &WorkLog{
+		TaskID:    taskID,
+		UserID:    userID,
+		Start:     now,
+		CreatedAt: now,
+	}
+}
+
+// IsRunning returns true if the work log is a timer that hasn't been stopped
+func (w *WorkLog) IsRunning() bool {
+	return w.End == nil
+}
+
+// Stop ends a running timer at end, to the second
+func (w *WorkLog) Stop(end time.Time) {
+	end = end.Round(time.Second)
+	if end.Before(w.Start) {
+		end = w.Start
+	}
+	w.End = &end
+	w.Duration = Estimate(end.Sub(w.Start).Round(time.Second))
+}
+
+// Elapsed returns the time logged, counting a running timer up to now
+func (w *WorkLog) Elapsed(now time.Time) time.Duration {
+	if w.IsRunning() {
+		if now.Before(w.Start) {
+			return 0
+		}
+		return now.Sub(w.Start).Round(time.Second)
+	}
+	return w.Duration.Duration()
+}
+
+// TimeSummary compares the time logged against a task with its estimates and
+// the actual duration from GetActualDuration
+type TimeSummary struct {
+	TaskID string `json:"task_id"`
+	// Logged is the time logged against the task itself, SubtreeLogged adds
+	// the time logged against its descendants. Both count running timers up
+	// to now.
+	Logged            Estimate `json:"logged"`
+	SubtreeLogged     Estimate `json:"subtree_logged"`
+	RunningTimers     int      `json:"running_timers"`
+	Actual            Estimate `json:"actual"`
+	OriginalEstimate  Estimate `json:"original_estimate"`
+	RemainingEstimate Estimate `json:"remaining_estimate"`
+}
+
+// NewTimeSummary summarises the work logs of task and its descendants; subtree
+// holds the IDs of the descendants
+func NewTimeSummary(task *Task, subtree map[string]bool, logs []*WorkLog, now time.Time) *TimeSummary {
+	summary := &TimeSummary{
+		TaskID:            task.ID,
+		Actual:            Estimate(task.GetActualDuration().Round(time.Minute)),
+		OriginalEstimate:  task.OriginalEstimate,
+		RemainingEstimate: task.RemainingWork(),
+	}
+
+	for _, log := range logs {
+		if log.TaskID != task.ID && !subtree[log.TaskID] {
+			continue
+		}
+		elapsed := Estimate(log.Elapsed(now))
+		summary.SubtreeLogged += elapsed
+		if log.TaskID == task.ID {
+			summary.Logged += elapsed
+		}
+		if log.IsRunning() {
+			summary.RunningTimers++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,340 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single RFC 6902 operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is an RFC 6902 patch: operations applied in order, all or nothing
+type JSONPatch []Operation
+
+// ParseJSONPatch parses and checks a JSON Patch document
+func ParseJSONPatch(data []byte) (JSONPatch, error) {
+	var ops JSONPatch
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d (%s) needs a value", ErrInvalidPatch, i, op.Op)
+			}
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+	}
+	return ops, nil
+}
+
+// Apply returns doc with every operation applied
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+	value, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	for i, op := range p {
+		value, err = op.apply(value)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(value)
+}
+
+// apply runs the operation against doc and returns the new document
+func (op Operation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+
+	case "remove":
+		return remove(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(doc, path, deepCopy(value))
+		}
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrCannotApply)
+		}
+		doc, err = remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at path
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+		}
+	}
+	return doc, nil
+}
+
+// add inserts value at path, replacing an existing object member
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modify(doc, path, func(node interface{}, token string) (interface{}, error) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("%w: cannot add %q to a scalar", ErrCannotApply, token)
+	})
+}
+
+// remove deletes the value at path, which must exist
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrCannotApply)
+	}
+	return modify(doc, path, func(node interface{}, token string) (interface{}, error) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+			}
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], node[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+	})
+}
+
+// replace swaps the value at path, which must exist, for value
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	doc, err := remove(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	return add(doc, path, value)
+}
+
+// modify walks to the parent of the last token of path and replaces it with
+// the result of fn
+func modify(doc interface{}, path []string, fn func(node interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+		}
+		updated, err := modify(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := modify(node[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = updated
+		return node, nil
+	}
+	return nil, fmt.Errorf("%w: %q not found", ErrCannotApply, token)
+}
+
+// arrayIndex parses an array index token no greater than max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrCannotApply, token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > max {
+		return 0, fmt.Errorf("%w: array index %s is out of range", ErrCannotApply, token)
+	}
+	return i, nil
+}
+
+// isProperPrefix reports whether prefix is an ancestor of path
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares two decoded JSON values, treating numbers by value
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okA := new(big.Float).SetString(a.String())
+		y, okB := new(big.Float).SetString(b.String())
+		return okA && okB && x.Cmp(y) == 0
+	default:
+		return a == b
+	}
+}
+
+// deepCopy copies a decoded JSON value so the copy can be changed separately
+func deepCopy(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			copied[key] = deepCopy(v)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, v := range value {
+			copied[i] = deepCopy(v)
+		}
+		return copied
+	default:
+		return value
+	}
+}
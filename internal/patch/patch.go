@@ -0,0 +1,97 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902)
+// documents, and uses them to edit tasks.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media types of the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for a patch document that is malformed
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrCannotApply is returned when a well-formed patch doesn't fit the
+	// document, such as a path that doesn't exist
+	ErrCannotApply = errors.New("patch cannot be applied")
+	// ErrTestFailed is returned when a JSON Patch test operation doesn't match
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Patch changes a JSON document
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// MergePatch is an RFC 7396 merge patch. Objects are merged key by key, null
+// removes a key and any other value replaces the target.
+type MergePatch struct {
+	value interface{}
+}
+
+// ParseMergePatch parses a merge patch document
+func ParseMergePatch(data []byte) (*MergePatch, error) {
+	value, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return &MergePatch{value: value}, nil
+}
+
+// NewMergePatch returns a merge patch that sets each key of fields. Nil
+// values remove the key.
+func NewMergePatch(fields map[string]interface{}) *MergePatch {
+	return &MergePatch{value: fields}
+}
+
+// Apply returns doc with the patch merged in
+func (p *MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return json.Marshal(merge(target, p.value))
+}
+
+// merge implements the MergePatch algorithm of RFC 7396 section 2
+func merge(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+	for key, value := range fields {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = merge(result[key], value)
+		}
+	}
+	return result
+}
+
+// decode parses a single JSON value, keeping numbers exact
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
@@ -0,0 +1,141 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+)
+
+// sameJSON reports whether two JSON documents hold the same value
+func sameJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	x, err := decode([]byte(a))
+	if err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	y, err := decode([]byte(b))
+	if err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return equal(x, y)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396 appendix A
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			p, err := ParseMergePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParseMergePatch() error = %v", err)
+			}
+			got, err := p.Apply([]byte(tt.target))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !sameJSON(t, string(got), tt.want) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseMergePatch([]byte(`{"a":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("ParseMergePatch() of malformed JSON error = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"baz"}]`, `{"foo":["bar","baz"]}`},
+		{"add null value", `{}`, `[{"op":"add","path":"/foo","value":null}]`, `{"foo":null}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			`{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{"test then replace", `{"n":1}`, `[{"op":"test","path":"/n","value":1.0},{"op":"replace","path":"/n","value":2}]`, `{"n":2}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+		{"replace whole document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParseJSONPatch() error = %v", err)
+			}
+			got, err := p.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !sameJSON(t, string(got), tt.want) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  error
+	}{
+		{"unknown op", `{}`, `[{"op":"frob","path":"/a"}]`, ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, ErrInvalidPatch},
+		{"relative path", `{}`, `[{"op":"remove","path":"a"}]`, ErrInvalidPatch},
+		{"not an array", `{}`, `{"op":"remove","path":"/a"}`, ErrInvalidPatch},
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ErrCannotApply},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, ErrCannotApply},
+		{"add past the end", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, ErrCannotApply},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, ErrCannotApply},
+		{"add below a missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, ErrCannotApply},
+		{"move into itself", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ErrCannotApply},
+		{"test mismatch", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"y"}]`, ErrTestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseJSONPatch([]byte(tt.patch))
+			if err == nil {
+				_, err = p.Apply([]byte(tt.doc))
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// ErrInvalidTask is returned when a patch leaves the task in an invalid state
+var ErrInvalidTask = errors.New("invalid task")
+
+// readOnlyFields are task fields that storage maintains; a patch may not change them
+var readOnlyFields = []string{"id", "key", "children", "links", "version", "created_at", "updated_at"}
+
+// Task applies p to the JSON form of task and returns the patched copy. A
+// due_date may be given as YYYY-MM-DD. Moving the task to an in progress
+// status sets started_at when it is missing, and updated_at is set to now.
+func Task(task *models.Task, p Patch) (*models.Task, error) {
+	original, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", err)
+	}
+	patched, err := p.Apply(original)
+	if err != nil {
+		return nil, err
+	}
+
+	before, _ := decode(original)
+	after, err := decode(patched)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCannotApply, err)
+	}
+	fields, ok := after.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: a task must be a JSON object", ErrInvalidTask)
+	}
+	for _, name := range readOnlyFields {
+		if !equal(before.(map[string]interface{})[name], fields[name]) {
+			return nil, fmt.Errorf("%w: %s is read-only", ErrInvalidTask, name)
+		}
+	}
+
+	// Accept the date-only form the rest of the API uses for due dates
+	if due, ok := fields["due_date"].(string); ok {
+		if date, err := time.Parse("2006-01-02", due); err == nil {
+			fields["due_date"] = date.Format(time.RFC3339)
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var result models.Task
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+
+	if err := validate(task, &result); err != nil {
+		return nil, err
+	}
+
+	if models.CategoryOf(result.Status) == models.CategoryInProgress && result.StartedAt == nil {
+		now := time.Now()
+		result.StartedAt = &now
+	}
+	result.UpdatedAt = time.Now()
+	return &result, nil
+}
+
+// validate checks the fields the patch changed, so stored tasks that predate a
+// rule can still be edited
+func validate(before, after *models.Task) error {
+	if after.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidTask)
+	}
+	if after.Status != before.Status && !models.IsValidStatus(string(after.Status)) {
+		return fmt.Errorf("%w: status %q is not valid", ErrInvalidTask, after.Status)
+	}
+	if after.Priority != before.Priority && !models.IsValidPriority(string(after.Priority)) {
+		return fmt.Errorf("%w: priority %q is not valid", ErrInvalidTask, after.Priority)
+	}
+	if after.Type != before.Type && !models.IsValidType(string(after.Type)) {
+		return fmt.Errorf("%w: type %q is not valid", ErrInvalidTask, after.Type)
+	}
+	if after.Type != before.Type || !reflect.DeepEqual(after.CustomFields, before.CustomFields) {
+		if err := models.CurrentWorkflow().CheckCustomFields(after); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTask, err)
+		}
+	}
+	if after.StoryPoints != before.StoryPoints && after.StoryPoints < 0 {
+		return fmt.Errorf("%w: story_points must not be negative", ErrInvalidTask)
+	}
+	if after.ParentID != "" && after.ParentID == after.ID {
+		return fmt.Errorf("%w: a task cannot be its own parent", ErrInvalidTask)
+	}
+	return nil
+}
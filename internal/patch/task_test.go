@@ -0,0 +1,100 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func newPatchTask() *models.Task {
+	task := models.NewTask("Write docs", "Cover the patch endpoint")
+	task.ID = "task-1"
+	task.ParentID = "epic-1"
+	task.Version = 3
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	task.DueDate = &due
+	return task
+}
+
+func TestTask_MergePatchClearsFields(t *testing.T) {
+	task := newPatchTask()
+
+	p, err := ParseMergePatch([]byte(`{"description":null,"due_date":null,"parent_id":null,"priority":"high"}`))
+	if err != nil {
+		t.Fatalf("ParseMergePatch() error = %v", err)
+	}
+	got, err := Task(task, p)
+	if err != nil {
+		t.Fatalf("Task() error = %v", err)
+	}
+
+	if got.Description != "" || got.DueDate != nil || got.ParentID != "" {
+		t.Errorf("fields not cleared: description %q, due date %v, parent %q", got.Description, got.DueDate, got.ParentID)
+	}
+	if got.Priority != models.PriorityHigh || got.Title != task.Title || got.Version != task.Version {
+		t.Errorf("Task() = %+v, want only the priority changed", got)
+	}
+	if task.Description == "" {
+		t.Errorf("Task() changed the original task")
+	}
+}
+
+func TestTask_JSONPatch(t *testing.T) {
+	task := newPatchTask()
+
+	p, err := ParseJSONPatch([]byte(`[
+		{"op":"test","path":"/version","value":3},
+		{"op":"replace","path":"/due_date","value":"2025-04-15"},
+		{"op":"replace","path":"/status","value":"in_progress"}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseJSONPatch() error = %v", err)
+	}
+	got, err := Task(task, p)
+	if err != nil {
+		t.Fatalf("Task() error = %v", err)
+	}
+
+	if got.GetDueDateString() != "2025-04-15" {
+		t.Errorf("due date = %q, want 2025-04-15", got.GetDueDateString())
+	}
+	if got.StartedAt == nil {
+		t.Errorf("moving to in_progress didn't set started_at")
+	}
+}
+
+func TestTask_Rejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  error
+	}{
+		{"read-only field", `{"id":"other"}`, ErrInvalidTask},
+		{"read-only children", `{"children":["x"]}`, ErrInvalidTask},
+		{"read-only key", `{"key":"PF-9"}`, ErrInvalidTask},
+		{"cleared title", `{"title":null}`, ErrInvalidTask},
+		{"cleared status", `{"status":null}`, ErrInvalidTask},
+		{"invalid priority", `{"priority":"urgent"}`, ErrInvalidTask},
+		{"unknown field", `{"owner":"sam"}`, ErrInvalidTask},
+		{"wrong type", `{"title":5}`, ErrInvalidTask},
+		{"own parent", `{"parent_id":"task-1"}`, ErrInvalidTask},
+		{"negative story points", `{"story_points":-1}`, ErrInvalidTask},
+		{"invalid estimate", `{"original_estimate":"two hours"}`, ErrInvalidTask},
+		{"undefined custom field", `{"custom_fields":{"severity":"high"}}`, ErrInvalidTask},
+		{"not an object", `["title"]`, ErrInvalidTask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseMergePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParseMergePatch() error = %v", err)
+			}
+			if _, err := Task(newPatchTask(), p); !errors.Is(err, tt.want) {
+				t.Errorf("Task() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Marker wraps matched words when highlighting. Escape, when set, is applied
+// to the surrounding text so markers can be HTML tags.
+type Marker struct {
+	Pre    string
+	Post   string
+	Escape func(string) string
+}
+
+// HTMLMarker highlights matches with <mark> tags and escapes the rest
+func HTMLMarker(escape func(string) string) Marker {
+	return Marker{Pre: "<mark>", Post: "</mark>", Escape: escape}
+}
+
+// TextMarker highlights matches Markdown style for plain text output
+var TextMarker = Marker{Pre: "**", Post: "**"}
+
+// Highlight returns text with every span wrapped in the marker
+func Highlight(text string, spans []Span, marker Marker) string {
+	escape := marker.Escape
+	if escape == nil {
+		escape = func(s string) string { return s }
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		if span.Start < pos || span.End > len(text) {
+			continue
+		}
+		b.WriteString(escape(text[pos:span.Start]))
+		b.WriteString(marker.Pre)
+		b.WriteString(escape(text[span.Start:span.End]))
+		b.WriteString(marker.Post)
+		pos = span.End
+	}
+	b.WriteString(escape(text[pos:]))
+	return b.String()
+}
+
+// Snippet returns an excerpt of text of about maxLen bytes around the first
+// match, highlighted with the marker. Cut ends are marked with an ellipsis.
+// Without matches the excerpt is taken from the start of text.
+func Snippet(text string, spans []Span, maxLen int, marker Marker) string {
+	if len(text) <= maxLen {
+		return Highlight(text, spans, marker)
+	}
+
+	start := 0
+	if len(spans) > 0 {
+		// Show a little context before the first match
+		start = spans[0].Start - maxLen/4
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + maxLen
+	if end > len(text) {
+		end = len(text)
+		start = end - maxLen
+	}
+
+	// Move the cuts to word boundaries where possible
+	if start > 0 {
+		if i := strings.IndexByte(text[start:], ' '); i >= 0 && start+i < end {
+			start += i + 1
+		}
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[start:end], ' '); i > 0 {
+			end = start + i
+		}
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	var window []Span
+	for _, span := range spans {
+		if span.Start >= start && span.End <= end {
+			window = append(window, Span{Start: span.Start - start, End: span.End - start})
+		}
+	}
+
+	snippet := Highlight(text[start:end], window, marker)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters. Title words count titleWeight times as much as words in
+// the description.
+const (
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	titleWeight = 2
+)
+
+// termFrequency counts how often a term appears in each field of a document
+type termFrequency struct {
+	title       int
+	description int
+}
+
+func (tf termFrequency) weighted() float64 {
+	return float64(titleWeight*tf.title + tf.description)
+}
+
+type document struct {
+	title       string
+	description string
+	terms       map[string]termFrequency
+	length      float64
+}
+
+// Span is the byte range of a matched word
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Hit is a document matched by a search, with the words that matched in its
+// title and description
+type Hit struct {
+	ID                 string
+	Score              float64
+	TitleMatches       []Span
+	DescriptionMatches []Span
+}
+
+// Index is an inverted index over document titles and descriptions, ranked
+// with BM25. It is not safe for concurrent use.
+type Index struct {
+	docs        map[string]*document
+	postings    map[string]map[string]struct{}
+	totalLength float64
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Add indexes a document, replacing any earlier version with the same ID
+func (idx *Index) Add(id, title, description string) {
+	idx.Remove(id)
+
+	doc := &document{
+		title:       title,
+		description: description,
+		terms:       make(map[string]termFrequency),
+	}
+	for _, token := range Tokenize(title) {
+		tf := doc.terms[token.Term]
+		tf.title++
+		doc.terms[token.Term] = tf
+	}
+	for _, token := range Tokenize(description) {
+		tf := doc.terms[token.Term]
+		tf.description++
+		doc.terms[token.Term] = tf
+	}
+
+	for term, tf := range doc.terms {
+		doc.length += tf.weighted()
+		ids, ok := idx.postings[term]
+		if !ok {
+			ids = make(map[string]struct{})
+			idx.postings[term] = ids
+		}
+		ids[id] = struct{}{}
+	}
+
+	idx.docs[id] = doc
+	idx.totalLength += doc.length
+}
+
+// Remove drops a document from the index
+func (idx *Index) Remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, id)
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search returns the documents matching any word of query, best match first.
+// Documents matching more of the words, rarer words or words in the title
+// rank higher. A limit of zero returns every match.
+func (idx *Index) Search(query string, limit int) []Hit {
+	terms := parseQuery(query)
+	if len(terms) == 0 || len(idx.docs) == 0 {
+		return nil
+	}
+
+	averageLength := idx.totalLength / float64(len(idx.docs))
+	scores := make(map[string]float64)
+	for _, qt := range terms {
+		// A prefix can match several indexed terms; each document scores
+		// by its best one so a prefix counts like a single word
+		best := make(map[string]float64)
+		for _, term := range idx.expand(qt) {
+			idf := idx.idf(term)
+			for id := range idx.postings[term] {
+				doc := idx.docs[id]
+				tf := doc.terms[term].weighted()
+				score := idf * tf * (bm25K1 + 1) /
+					(tf + bm25K1*(1-bm25B+bm25B*doc.length/averageLength))
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		doc := idx.docs[hits[i].ID]
+		hits[i].TitleMatches = matchSpans(doc.title, terms)
+		hits[i].DescriptionMatches = matchSpans(doc.description, terms)
+	}
+	return hits
+}
+
+// expand returns the indexed terms matched by a query term
+func (idx *Index) expand(qt queryTerm) []string {
+	if qt.prefix == "" {
+		if _, ok := idx.postings[qt.stem]; ok {
+			return []string{qt.stem}
+		}
+		return nil
+	}
+
+	var terms []string
+	for term := range idx.postings {
+		if qt.matches(term) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// idf is the BM25 inverse document frequency of term
+func (idx *Index) idf(term string) float64 {
+	n := float64(len(idx.docs))
+	df := float64(len(idx.postings[term]))
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// matchSpans returns the words of text matched by any query term
+func matchSpans(text string, terms []queryTerm) []Span {
+	var spans []Span
+	for _, token := range Tokenize(text) {
+		for _, qt := range terms {
+			if qt.matches(token.Term) {
+				spans = append(spans, Span{Start: token.Start, End: token.End})
+				break
+			}
+		}
+	}
+	return spans
+}
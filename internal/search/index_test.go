@@ -0,0 +1,113 @@
+package search
+
+import (
+	"html"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Add("pay", "Card payments", "Accept card payments at checkout")
+	idx.Add("refund", "Refunds", "Refund a card payment from the admin page")
+	idx.Add("receipt", "Email receipts", "Send a receipt after checkout")
+	idx.Add("login", "Login page", "Let users sign in with a password")
+	return idx
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"stemmed forms match", "payment ", []string{"pay", "refund"}},
+		{"title matches rank first", "checkout receipt ", []string{"receipt", "pay"}},
+		{"prefix of the last word", "pass", []string{"login"}},
+		{"explicit prefix", "rec* page ", []string{"receipt", "login", "refund"}},
+		{"stop words are ignored", "the ", nil},
+		{"no match", "kubernetes ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitIDs(idx.Search(tt.query, 0))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_AddReplacesAndRemove(t *testing.T) {
+	idx := newTestIndex()
+
+	idx.Add("login", "Single sign-on", "Log in through the company identity provider")
+	if hits := idx.Search("password ", 0); len(hits) != 0 {
+		t.Errorf("Search() found %v after the document was replaced", hitIDs(hits))
+	}
+	if hits := idx.Search("identity ", 0); len(hits) != 1 {
+		t.Errorf("Search() = %v, want the replaced document", hitIDs(hits))
+	}
+
+	idx.Remove("login")
+	if hits := idx.Search("identity ", 0); len(hits) != 0 {
+		t.Errorf("Search() found %v after the document was removed", hitIDs(hits))
+	}
+	if idx.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", idx.Len())
+	}
+}
+
+func TestIndex_Limit(t *testing.T) {
+	idx := newTestIndex()
+	if hits := idx.Search("card checkout ", 1); len(hits) != 1 || hits[0].ID != "pay" {
+		t.Errorf("Search() with limit 1 = %v, want [pay]", hitIDs(hits))
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("xss", "Fix <script> payments", "")
+
+	hits := idx.Search("payment ", 0)
+	if len(hits) != 1 {
+		t.Fatalf("Search() = %v, want one hit", hitIDs(hits))
+	}
+
+	got := Highlight("Fix <script> payments", hits[0].TitleMatches, HTMLMarker(html.EscapeString))
+	want := "Fix &lt;script&gt; <mark>payments</mark>"
+	if got != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := "The first part of this description talks about nothing in particular, " +
+		"then mentions invoices once, and goes on for a while longer afterwards."
+	spans := matchSpans(text, parseQuery("invoice "))
+
+	got := Snippet(text, spans, 60, TextMarker)
+	want := "…then mentions **invoices** once, and goes on for a while…"
+	if got != want {
+		t.Errorf("Snippet() = %q, want %q", got, want)
+	}
+
+	if got := Snippet("Short", nil, 60, TextMarker); got != "Short" {
+		t.Errorf("Snippet() = %q, want the whole text", got)
+	}
+}
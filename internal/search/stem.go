@@ -0,0 +1,232 @@
+package search
+
+import "sort"
+
+// Stem reduces an English word to its stem using the Porter algorithm, so
+// that "payments", "paying" and "paid" style variants share index terms.
+// Words that aren't plain lowercase ASCII are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	word = step1a(word)
+	word = step1b(word)
+	word = step1c(word)
+	word = applyRules(word, step2Rules, 0)
+	word = applyRules(word, step3Rules, 0)
+	word = step4(word)
+	word = step5(word)
+	return word
+}
+
+// isConsonant reports whether w[i] acts as a consonant. A y is a consonant at
+// the start of a word or after a vowel.
+func isConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	default:
+		return true
+	}
+}
+
+// measure counts the vowel-consonant sequences in w, the m of [C](VC)^m[V]
+func measure(w string) int {
+	m, i := 0, 0
+	for i < len(w) && isConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !isConsonant(w, i) {
+			i++
+		}
+		if i >= len(w) {
+			break
+		}
+		for i < len(w) && isConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func hasVowel(w string) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDoubleConsonant reports whether w ends in a repeated consonant
+func endsDoubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports whether w ends consonant-vowel-consonant where the final
+// consonant is not w, x or y
+func endsCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	switch w[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func hasSuffix(w, suffix string) bool {
+	return len(w) > len(suffix) && w[len(w)-len(suffix):] == suffix
+}
+
+func step1a(w string) string {
+	switch {
+	case hasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func step1b(w string) string {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem string
+	switch {
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return stem + "e"
+	case endsDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		}
+		return stem[:len(stem)-1]
+	case measure(stem) == 1 && endsCVC(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+func step1c(w string) string {
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		return w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+// suffixRule replaces suffix with replacement when the remaining stem has a
+// measure greater than the rule set's minimum
+type suffixRule struct {
+	suffix      string
+	replacement string
+}
+
+var step2Rules = sortedRules([]suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+})
+
+var step3Rules = sortedRules([]suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+})
+
+var step4Suffixes = sortedRules([]suffixRule{
+	{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+	{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+	{"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""},
+	{"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+})
+
+// sortedRules orders rules longest suffix first so the longest match wins
+func sortedRules(rules []suffixRule) []suffixRule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].suffix) > len(rules[j].suffix)
+	})
+	return rules
+}
+
+// applyRules applies the first rule whose suffix matches w. Only the longest
+// matching suffix is considered, even when its measure condition fails.
+func applyRules(w string, rules []suffixRule, minMeasure int) string {
+	for _, rule := range rules {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(rule.suffix)]
+		if measure(stem) > minMeasure {
+			return stem + rule.replacement
+		}
+		return w
+	}
+	return w
+}
+
+func step4(w string) string {
+	for _, rule := range step4Suffixes {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(rule.suffix)]
+		if rule.suffix == "ion" {
+			last := stem[len(stem)-1]
+			if last != 's' && last != 't' {
+				return w
+			}
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+func step5(w string) string {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		m := measure(stem)
+		if m > 1 || (m == 1 && !endsCVC(stem)) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && endsDoubleConsonant(w) && w[len(w)-1] == 'l' {
+		w = w[:len(w)-1]
+	}
+	return w
+}
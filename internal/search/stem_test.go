@@ -0,0 +1,56 @@
+package search
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"payments", "payment"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"running", "run"},
+		{"hopping", "hop"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"adjustment", "adjust"},
+		{"adoption", "adopt"},
+		{"controll", "control"},
+		{"rate", "rate"},
+		{"is", "is"},
+		{"v2", "v2"},
+		{"café", "café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Stem(tt.word); got != tt.want {
+				t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStem_SharesStemAcrossForms(t *testing.T) {
+	forms := [][]string{
+		{"connect", "connected", "connecting", "connection", "connections"},
+		{"deploy", "deploys", "deployed", "deploying"},
+	}
+	for _, group := range forms {
+		stem := Stem(group[0])
+		for _, word := range group[1:] {
+			if got := Stem(word); got != stem {
+				t.Errorf("Stem(%q) = %q, want %q like %q", word, got, stem, group[0])
+			}
+		}
+	}
+}
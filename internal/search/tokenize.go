@@ -0,0 +1,93 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a word found in a piece of text
+type Token struct {
+	// Term is the normalised form used in the index
+	Term string
+	// Start and End are the byte offsets of the word in the original text
+	Start int
+	End   int
+}
+
+// stopWords are common English words that are left out of the index
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "so": true, "such": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// Tokenize splits text into lowercased, stemmed words, skipping stop words.
+// Letters and digits form words; everything else separates them.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	for _, word := range splitWords(text) {
+		lower := strings.ToLower(text[word.Start:word.End])
+		if stopWords[lower] {
+			continue
+		}
+		tokens = append(tokens, Token{Term: Stem(lower), Start: word.Start, End: word.End})
+	}
+	return tokens
+}
+
+// splitWords returns the byte ranges of the words in text
+func splitWords(text string) []Token {
+	var words []Token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			words = append(words, Token{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, Token{Start: start, End: len(text)})
+	}
+	return words
+}
+
+// queryTerm is one word of a search query. It matches indexed terms equal to
+// stem or, when prefix is set, starting with prefix.
+type queryTerm struct {
+	stem   string
+	prefix string
+}
+
+func (q queryTerm) matches(term string) bool {
+	return term == q.stem || (q.prefix != "" && strings.HasPrefix(term, q.prefix))
+}
+
+// parseQuery splits a query into terms. A word ending in * is a prefix, as is
+// the final word of two or more letters when the query doesn't end in a
+// space, so results can be shown while the user is still typing. Prefixes are lowercased but not
+// stemmed, since a partial word has no meaningful stem.
+func parseQuery(query string) []queryTerm {
+	trailingSpace := query == "" || strings.HasSuffix(query, " ")
+	words := splitWords(query)
+
+	var terms []queryTerm
+	for i, word := range words {
+		lower := strings.ToLower(query[word.Start:word.End])
+		switch {
+		case strings.HasPrefix(query[word.End:], "*"):
+			terms = append(terms, queryTerm{prefix: lower})
+		case i == len(words)-1 && !trailingSpace && len(lower) > 1:
+			terms = append(terms, queryTerm{stem: Stem(lower), prefix: lower})
+		case !stopWords[lower]:
+			terms = append(terms, queryTerm{stem: Stem(lower)})
+		}
+	}
+	return terms
+}
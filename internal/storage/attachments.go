@@ -0,0 +1,431 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxAttachmentSize is the size limit used when none is configured
+const DefaultMaxAttachmentSize = 10 << 20
+
+// AttachmentStore keeps the files attached to tasks. Content is stored once
+// however often it is attached, keyed by its SHA-256, and is removed when the
+// last attachment using it is deleted. Stores don't check that tasks exist;
+// callers check before attaching and call DeleteTaskAttachments when they
+// delete a task.
+type AttachmentStore interface {
+	// CreateAttachment stores the content read from r for attachment,
+	// setting its ID, Size and SHA256. An empty ContentType is worked out
+	// from the file name or the content. Content over the store's size limit
+	// is refused with ErrAttachmentTooLarge.
+	CreateAttachment(attachment *models.Attachment, r io.Reader) error
+	GetAttachment(id string) (*models.Attachment, error)
+	// OpenAttachment returns the attachment's content, which the caller closes
+	OpenAttachment(id string) (*models.Attachment, io.ReadCloser, error)
+	// ListAttachments returns the attachments to a task, oldest first
+	ListAttachments(taskID string) ([]*models.Attachment, error)
+	DeleteAttachment(id string) error
+	// DeleteTaskAttachments deletes every attachment to a task
+	DeleteTaskAttachments(taskID string) error
+	// MaxSize returns the largest attachment accepted, in bytes
+	MaxSize() int64
+}
+
+// DeleteAttachmentsOf deletes the attachments to every task a delete removed.
+// It carries on past failures and returns the first.
+func DeleteAttachmentsOf(attachments AttachmentStore, result *DeleteResult) error {
+	var first error
+	for _, taskID := range result.Deleted {
+		if err := attachments.DeleteTaskAttachments(taskID); err != nil && first == nil {
+			first = fmt.Errorf("failed to delete attachments of task %s: %w", taskID, err)
+		}
+	}
+	return first
+}
+
+// attachmentLockFileName serialises processes sharing the attachments
+// directory, so content isn't removed while another process attaches it
+const attachmentLockFileName = ".lock"
+
+// FileAttachmentStore implements AttachmentStore in the attachments directory
+// under the data directory. Content lives in blobs/, named by its SHA-256,
+// and each attachment's metadata in meta/.
+type FileAttachmentStore struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// NewFileAttachmentStore creates a file-based attachment store rooted at
+// dataDir/attachments that accepts files of up to maxSize bytes
+func NewFileAttachmentStore(dataDir string, maxSize int64) (*FileAttachmentStore, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAttachmentSize
+	}
+	dir := filepath.Join(dataDir, "attachments")
+	for _, sub := range []string{"blobs", "meta"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+		}
+	}
+
+	as := &FileAttachmentStore{dir: dir, maxSize: maxSize}
+
+	unlock, err := as.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := removeTempFiles(filepath.Join(dir, "meta")); err != nil {
+		return nil, err
+	}
+	if err := removeStaleUploads(dir); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// MaxSize returns the largest attachment the store accepts, in bytes
+func (as *FileAttachmentStore) MaxSize() int64 {
+	return as.maxSize
+}
+
+// CreateAttachment stores an attachment's content and metadata
+func (as *FileAttachmentStore) CreateAttachment(attachment *models.Attachment, r io.Reader) error {
+	if attachment.TaskID == "" {
+		return fmt.Errorf("%w: task_id is required", ErrInvalidAttachment)
+	}
+	if attachment.Filename = models.CleanFilename(attachment.Filename); attachment.Filename == "" {
+		return fmt.Errorf("%w: file name is required", ErrInvalidAttachment)
+	}
+
+	// Copy the content to a temporary file first, hashing it on the way, so
+	// the lock is only held to move it into place
+	tmp, err := os.CreateTemp(as.dir, ".upload.tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create upload file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	hash := sha256.New()
+	head := &headBuffer{limit: 512}
+	size, err := io.Copy(io.MultiWriter(tmp, hash, head), io.LimitReader(r, as.maxSize+1))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write upload file: %w", err)
+	}
+	if size > as.maxSize {
+		return fmt.Errorf("%w: the limit is %d bytes", ErrAttachmentTooLarge, as.maxSize)
+	}
+
+	attachment.ID = uuid.New().String()
+	attachment.Size = size
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	attachment.ContentType = detectContentType(attachment.ContentType, attachment.Filename, head.Bytes())
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	unlock, err := as.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Content that is already stored is shared rather than stored again
+	blobPath := as.blobPath(attachment.SHA256)
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+			return fmt.Errorf("failed to create blob directory: %w", err)
+		}
+		if err := os.Chmod(tmpPath, 0644); err != nil {
+			return fmt.Errorf("failed to store attachment: %w", err)
+		}
+		if err := os.Rename(tmpPath, blobPath); err != nil {
+			return fmt.Errorf("failed to store attachment: %w", err)
+		}
+	}
+
+	return as.saveAttachmentUnsafe(attachment)
+}
+
+// GetAttachment retrieves an attachment's metadata by ID
+func (as *FileAttachmentStore) GetAttachment(id string) (*models.Attachment, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	return as.getAttachmentUnsafe(id)
+}
+
+// OpenAttachment returns an attachment's metadata and content
+func (as *FileAttachmentStore) OpenAttachment(id string) (*models.Attachment, io.ReadCloser, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	unlock, err := as.lock()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	attachment, err := as.getAttachmentUnsafe(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Once open, the content stays readable even if it is deleted
+	f, err := os.Open(as.blobPath(attachment.SHA256))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment content: %w", err)
+	}
+	return attachment, f, nil
+}
+
+// ListAttachments returns the attachments to a task, oldest first
+func (as *FileAttachmentStore) ListAttachments(taskID string) ([]*models.Attachment, error) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	attachments, err := as.listAttachmentsUnsafe()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*models.Attachment{}
+	for _, attachment := range attachments {
+		if attachment.TaskID == taskID {
+			matched = append(matched, attachment)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	return matched, nil
+}
+
+// DeleteAttachment deletes an attachment, and its content if no other
+// attachment uses it
+func (as *FileAttachmentStore) DeleteAttachment(id string) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	unlock, err := as.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	attachment, err := as.getAttachmentUnsafe(id)
+	if err != nil {
+		return err
+	}
+	return as.deleteAttachmentsUnsafe([]*models.Attachment{attachment})
+}
+
+// DeleteTaskAttachments deletes every attachment to a task
+func (as *FileAttachmentStore) DeleteTaskAttachments(taskID string) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	unlock, err := as.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	attachments, err := as.listAttachmentsUnsafe()
+	if err != nil {
+		return err
+	}
+	var deleted []*models.Attachment
+	for _, attachment := range attachments {
+		if attachment.TaskID == taskID {
+			deleted = append(deleted, attachment)
+		}
+	}
+	return as.deleteAttachmentsUnsafe(deleted)
+}
+
+// lock takes the lock shared with other processes using the directory
+func (as *FileAttachmentStore) lock() (func(), error) {
+	f, err := os.OpenFile(filepath.Join(as.dir, attachmentLockFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(f, true); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock attachments directory: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// blobPath returns the file content with the given SHA-256 is stored in,
+// spread over subdirectories by the first two hex digits
+func (as *FileAttachmentStore) blobPath(sum string) string {
+	return filepath.Join(as.dir, "blobs", sum[:2], sum)
+}
+
+// metaPath returns the file an attachment's metadata is stored in. Callers
+// check the ID is a UUID first, so it can't escape the meta directory.
+func (as *FileAttachmentStore) metaPath(id string) string {
+	return filepath.Join(as.dir, "meta", id+".json")
+}
+
+func (as *FileAttachmentStore) getAttachmentUnsafe(id string) (*models.Attachment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentNotFound, id)
+	}
+	data, err := os.ReadFile(as.metaPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrAttachmentNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read attachment file: %w", err)
+	}
+
+	var attachment models.Attachment
+	if err := json.Unmarshal(data, &attachment); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal attachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+func (as *FileAttachmentStore) saveAttachmentUnsafe(attachment *models.Attachment) error {
+	data, err := json.MarshalIndent(attachment, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal attachment: %w", err)
+	}
+
+	if err := writeFileAtomic(as.metaPath(attachment.ID), data); err != nil {
+		return fmt.Errorf("failed to write attachment file: %w", err)
+	}
+	return nil
+}
+
+func (as *FileAttachmentStore) listAttachmentsUnsafe() ([]*models.Attachment, error) {
+	entries, err := os.ReadDir(filepath.Join(as.dir, "meta"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments directory: %w", err)
+	}
+
+	attachments := []*models.Attachment{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			attachment, err := as.getAttachmentUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				attachments = append(attachments, attachment)
+			}
+		}
+	}
+	return attachments, nil
+}
+
+// deleteAttachmentsUnsafe removes the metadata of attachments, then any
+// content no remaining attachment uses
+func (as *FileAttachmentStore) deleteAttachmentsUnsafe(attachments []*models.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	for _, attachment := range attachments {
+		if err := os.Remove(as.metaPath(attachment.ID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete attachment file: %w", err)
+		}
+	}
+
+	remaining, err := as.listAttachmentsUnsafe()
+	if err != nil {
+		return err
+	}
+	inUse := make(map[string]bool, len(remaining))
+	for _, attachment := range remaining {
+		inUse[attachment.SHA256] = true
+	}
+	for _, attachment := range attachments {
+		if inUse[attachment.SHA256] {
+			continue
+		}
+		if err := os.Remove(as.blobPath(attachment.SHA256)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete attachment content: %w", err)
+		}
+	}
+	return nil
+}
+
+// staleUploadAge is how old an upload file must be before it is taken to be
+// left over from a crash. Uploads are copied without holding the lock, so a
+// newer one may belong to another process.
+const staleUploadAge = time.Hour
+
+// removeStaleUploads removes upload files left in dir by crashed processes
+func removeStaleUploads(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, ".upload.tmp*"))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || time.Since(info.ModTime()) < staleUploadAge {
+			continue
+		}
+		if err := os.Remove(match); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove upload file: %w", err)
+		}
+	}
+	return nil
+}
+
+// detectContentType returns the declared content type if it is a valid one
+// other than the generic application/octet-stream, and otherwise works the
+// type out from the file name's extension or, failing that, the content
+func detectContentType(declared, filename string, head []byte) string {
+	if mediaType, params, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mime.FormatMediaType(mediaType, params)
+	}
+	if byExtension := mime.TypeByExtension(filepath.Ext(filename)); byExtension != "" {
+		return byExtension
+	}
+	return http.DetectContentType(head)
+}
+
+// headBuffer keeps the first limit bytes written to it
+type headBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if room := h.limit - h.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		h.Buffer.Write(p[:room])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestFileAttachmentStore(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileAttachmentStore(dataDir, 64)
+	if err != nil {
+		t.Fatalf("NewFileAttachmentStore() error = %v", err)
+	}
+
+	attach := func(taskID, filename, contentType, content string) *models.Attachment {
+		t.Helper()
+		attachment := models.NewAttachment(taskID, filename, contentType)
+		if err := store.CreateAttachment(attachment, strings.NewReader(content)); err != nil {
+			t.Fatalf("CreateAttachment() error = %v", err)
+		}
+		return attachment
+	}
+	blobs := func() int {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(dataDir, "attachments", "blobs", "*", "*"))
+		if err != nil {
+			t.Fatalf("Glob() error = %v", err)
+		}
+		return len(matches)
+	}
+
+	screenshot := attach("task-1", "../../screen.png", "", "\x89PNG\r\n\x1a\nimage data")
+	if screenshot.Filename != "screen.png" || screenshot.ContentType != "image/png" || screenshot.Size != 18 {
+		t.Errorf("CreateAttachment() = %+v, want screen.png, image/png and 18 bytes", screenshot)
+	}
+	log := attach("task-1", "build-output", "application/octet-stream", "log line\n")
+	copied := attach("task-2", "copy.log", "text/x-log", "log line\n")
+	if log.SHA256 != copied.SHA256 || blobs() != 2 {
+		t.Errorf("identical content should be stored once, got %d blobs", blobs())
+	}
+	if log.ContentType != "text/plain; charset=utf-8" || copied.ContentType != "text/x-log" {
+		t.Errorf("content types = %q and %q, want sniffed text and the declared type", log.ContentType, copied.ContentType)
+	}
+
+	tooLarge := models.NewAttachment("task-1", "big.bin", "")
+	if err := store.CreateAttachment(tooLarge, strings.NewReader(strings.Repeat("x", 65))); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("CreateAttachment() over the limit error = %v, want ErrAttachmentTooLarge", err)
+	}
+	if err := store.CreateAttachment(models.NewAttachment("task-1", "..", ""), strings.NewReader("x")); !errors.Is(err, ErrInvalidAttachment) {
+		t.Errorf("CreateAttachment() without a file name error = %v, want ErrInvalidAttachment", err)
+	}
+
+	attachments, err := store.ListAttachments("task-1")
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+	if len(attachments) != 2 || attachments[0].ID != screenshot.ID || attachments[1].ID != log.ID {
+		t.Errorf("ListAttachments() = %v, want the screenshot and the log", attachments)
+	}
+
+	_, content, err := store.OpenAttachment(log.ID)
+	if err != nil {
+		t.Fatalf("OpenAttachment() error = %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "log line\n" {
+		t.Errorf("OpenAttachment() content = %q", data)
+	}
+
+	// Shared content outlives the task that attached it first
+	if err := store.DeleteTaskAttachments("task-1"); err != nil {
+		t.Fatalf("DeleteTaskAttachments() error = %v", err)
+	}
+	if _, err := store.GetAttachment(log.ID); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("GetAttachment() after delete error = %v, want ErrAttachmentNotFound", err)
+	}
+	if blobs() != 1 {
+		t.Errorf("after deleting task-1 there are %d blobs, want the shared one", blobs())
+	}
+	if _, content, err := store.OpenAttachment(copied.ID); err != nil {
+		t.Errorf("OpenAttachment() of shared content error = %v", err)
+	} else {
+		content.Close()
+	}
+
+	if err := store.DeleteAttachment(copied.ID); err != nil {
+		t.Fatalf("DeleteAttachment() error = %v", err)
+	}
+	if blobs() != 0 {
+		t.Errorf("after deleting every attachment there are %d blobs, want 0", blobs())
+	}
+	if err := store.DeleteAttachment("missing"); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("DeleteAttachment(missing) error = %v, want ErrAttachmentNotFound", err)
+	}
+
+	// Nothing is left behind by the uploads
+	leftovers, _ := filepath.Glob(filepath.Join(dataDir, "attachments", ".upload.tmp*"))
+	if len(leftovers) != 0 {
+		t.Errorf("upload files left behind: %v", leftovers)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "attachments", "meta")); err != nil {
+		t.Errorf("meta directory missing: %v", err)
+	}
+}
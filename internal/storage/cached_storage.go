@@ -0,0 +1,833 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/search"
+)
+
+// taskIndex maps a field value to the IDs of the tasks that have it
+type taskIndex map[string]map[string]struct{}
+
+func (idx taskIndex) add(key, id string) {
+	ids, ok := idx[key]
+	if !ok {
+		ids = make(map[string]struct{})
+		idx[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (idx taskIndex) remove(key, id string) {
+	delete(idx[key], id)
+	if len(idx[key]) == 0 {
+		delete(idx, key)
+	}
+}
+
+// sorted returns the IDs stored under key in ID order
+func (idx taskIndex) sorted(key string) []string {
+	ids := make([]string, 0, len(idx[key]))
+	for id := range idx[key] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// union returns the IDs stored under any of keys, each once
+func (idx taskIndex) union(keys []string) []string {
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, key := range keys {
+		for id := range idx[key] {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// CachedStorage wraps a Storage and serves reads from an in-memory copy of
+// every task, indexed by ID, key, parent, status, type, priority, assignee,
+// label and sprint and by the words of its title and description. Writes go through to
+// the wrapped backend. When the backend implements ChangeTracker
+// the cache reloads whenever another process has written to it; otherwise it
+// assumes all writes go through the cache.
+type CachedStorage struct {
+	*cacheState
+	backend Storage
+	tracker ChangeTracker
+}
+
+// cacheState is shared by a CachedStorage and its views from WithActor
+type cacheState struct {
+	mu         sync.RWMutex
+	loaded     bool
+	generation uint64
+	tasks      map[string]*models.Task
+	byKey      taskIndex
+	byParent   taskIndex
+	byStatus   taskIndex
+	byType     taskIndex
+	byPriority taskIndex
+	byAssignee taskIndex
+	byLabel    taskIndex
+	bySprint   taskIndex
+	search     *search.Index
+}
+
+// NewCachedStorage creates a cache over backend. Tasks are loaded on first use.
+func NewCachedStorage(backend Storage) *CachedStorage {
+	cache := &CachedStorage{cacheState: &cacheState{}, backend: backend}
+	cache.tracker, _ = backend.(ChangeTracker)
+	return cache
+}
+
+// WithActor returns a view of the cache whose changes the backend records in
+// the task history as made by actor
+func (c *CachedStorage) WithActor(actor Actor) Storage {
+	return &CachedStorage{
+		cacheState: c.cacheState,
+		backend:    WithActor(c.backend, actor),
+		tracker:    c.tracker,
+	}
+}
+
+// CreateTask creates a new task and assigns it an ID
+func (c *CachedStorage) CreateTask(task *models.Task) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	if err := c.backend.CreateTask(task); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, task.ID, task.ParentID)
+	return nil
+}
+
+// GetTask retrieves a task by ID
+func (c *CachedStorage) GetTask(id string) (*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	task, ok := c.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	return task.Clone(), nil
+}
+
+// GetTaskByKey retrieves a task by key
+func (c *CachedStorage) GetTaskByKey(key string) (*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	ids := c.byKey.sorted(key)
+	if key == "" || len(ids) == 0 {
+		return nil, fmt.Errorf("task not found: %s", key)
+	}
+	return c.tasks[ids[0]].Clone(), nil
+}
+
+// UpdateTask updates an existing task
+func (c *CachedStorage) UpdateTask(task *models.Task) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	var oldParentID string
+	if existing, ok := c.tasks[task.ID]; ok {
+		oldParentID = existing.ParentID
+	}
+
+	if err := c.backend.UpdateTask(task); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, task.ID, oldParentID, task.ParentID)
+	return nil
+}
+
+// DeleteTask deletes a task together with its entire subtree
+func (c *CachedStorage) DeleteTask(id string) error {
+	_, err := c.DeleteTaskWithStrategy(id, DeleteCascade, 0)
+	return err
+}
+
+// DeleteTaskWithStrategy deletes a task, removes it from its parent's children
+// and deletes or moves its children according to strategy
+func (c *CachedStorage) DeleteTaskWithStrategy(id string, strategy DeleteStrategy, expectedVersion int64) (*DeleteResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return nil, err
+	}
+
+	var parentID string
+	if existing, ok := c.tasks[id]; ok {
+		parentID = existing.ParentID
+	}
+
+	result, err := c.backend.DeleteTaskWithStrategy(id, strategy, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Moved children now hang off the deleted task's parent or the root, and
+	// tasks linked to a deleted task lost that link
+	changed := append([]string{parentID}, result.Moved...)
+	for _, deletedID := range result.Deleted {
+		if deleted, ok := c.tasks[deletedID]; ok {
+			for _, link := range deleted.Links {
+				changed = append(changed, link.TaskID)
+			}
+		}
+	}
+	c.syncLocked(result.Deleted, changed...)
+	return result, nil
+}
+
+// LinkTasks links fromID to toID, storing the inverse link on toID
+func (c *CachedStorage) LinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	if err := c.backend.LinkTasks(fromID, linkType, toID); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, fromID, toID)
+	return nil
+}
+
+// UnlinkTasks removes a link and its inverse
+func (c *CachedStorage) UnlinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	if err := c.backend.UnlinkTasks(fromID, linkType, toID); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, fromID, toID)
+	return nil
+}
+
+// ListTasks returns all tasks
+func (c *CachedStorage) ListTasks() ([]*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.tasks))
+	for id := range c.tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return c.cloneLocked(ids), nil
+}
+
+// QueryTasks returns the page of tasks selected by query. The smallest
+// matching index picks the candidates the rest of the query is applied to.
+func (c *CachedStorage) QueryTasks(query TaskQuery) (*TaskPage, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	var candidates []string
+	narrowed := false
+	narrow := func(ids []string) {
+		if !narrowed || len(ids) < len(candidates) {
+			candidates = ids
+			narrowed = true
+		}
+	}
+	if query.ParentID != nil {
+		narrow(c.byParent.sorted(*query.ParentID))
+	}
+	if len(query.Status) > 0 {
+		narrow(c.byStatus.union(toStrings(query.Status)))
+	}
+	if len(query.Type) > 0 {
+		narrow(c.byType.union(toStrings(query.Type)))
+	}
+	if len(query.Priority) > 0 {
+		narrow(c.byPriority.union(toStrings(query.Priority)))
+	}
+	if query.AssigneeID != nil {
+		narrow(c.byAssignee.sorted(*query.AssigneeID))
+	}
+	if len(query.Labels) > 0 {
+		narrow(c.byLabel.union(query.Labels))
+	}
+	if query.SprintID != nil {
+		narrow(c.bySprint.sorted(*query.SprintID))
+	}
+
+	tasks := make([]*models.Task, 0, len(c.tasks))
+	if narrowed {
+		for _, id := range candidates {
+			tasks = append(tasks, c.tasks[id])
+		}
+	} else {
+		for _, task := range c.tasks {
+			tasks = append(tasks, task)
+		}
+	}
+
+	page, err := ApplyQuery(tasks, query)
+	if err != nil {
+		return nil, err
+	}
+	for i, task := range page.Tasks {
+		page.Tasks[i] = task.Clone()
+	}
+	return page, nil
+}
+
+// SearchTasks ranks tasks by how well they match query using the cache's
+// full-text index
+func (c *CachedStorage) SearchTasks(query string, limit int) ([]*SearchResult, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	results := searchResults(c.search.Search(query, limit), c.tasks)
+	for _, result := range results {
+		result.Task = result.Task.Clone()
+	}
+	return results, nil
+}
+
+// TasksByStatus returns the tasks with the given status
+func (c *CachedStorage) TasksByStatus(status models.TaskStatus) ([]*models.Task, error) {
+	return c.lookup(c.byStatus, string(status))
+}
+
+// TasksByType returns the tasks of the given type
+func (c *CachedStorage) TasksByType(taskType models.TaskType) ([]*models.Task, error) {
+	return c.lookup(c.byType, string(taskType))
+}
+
+// TasksByPriority returns the tasks with the given priority
+func (c *CachedStorage) TasksByPriority(priority models.TaskPriority) ([]*models.Task, error) {
+	return c.lookup(c.byPriority, string(priority))
+}
+
+// CreateUser creates a new user in the backend. Users aren't cached.
+func (c *CachedStorage) CreateUser(user *models.User) error {
+	return c.backend.CreateUser(user)
+}
+
+// GetUser retrieves a user from the backend
+func (c *CachedStorage) GetUser(id string) (*models.User, error) {
+	return c.backend.GetUser(id)
+}
+
+// UpdateUser updates a user in the backend
+func (c *CachedStorage) UpdateUser(user *models.User) error {
+	return c.backend.UpdateUser(user)
+}
+
+// DeleteUser deletes a user from the backend
+func (c *CachedStorage) DeleteUser(id string) error {
+	return c.backend.DeleteUser(id)
+}
+
+// ListUsers returns every user in the backend ordered by name
+func (c *CachedStorage) ListUsers() ([]*models.User, error) {
+	return c.backend.ListUsers()
+}
+
+// CreateLabel registers a new label in the backend. Labels aren't cached.
+func (c *CachedStorage) CreateLabel(label *models.Label) error {
+	return c.backend.CreateLabel(label)
+}
+
+// GetLabel retrieves a label from the backend
+func (c *CachedStorage) GetLabel(name string) (*models.Label, error) {
+	return c.backend.GetLabel(name)
+}
+
+// UpdateLabel updates a label in the backend
+func (c *CachedStorage) UpdateLabel(label *models.Label) error {
+	return c.backend.UpdateLabel(label)
+}
+
+// DeleteLabel deletes a label from the backend, which also removes it from
+// the tasks carrying it
+func (c *CachedStorage) DeleteLabel(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	labelled := c.byLabel.sorted(name)
+	if err := c.backend.DeleteLabel(name); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, labelled...)
+	return nil
+}
+
+// ListLabels returns every label in the backend ordered by name
+func (c *CachedStorage) ListLabels() ([]*models.Label, error) {
+	return c.backend.ListLabels()
+}
+
+// TasksByLabel returns the tasks carrying the given label
+func (c *CachedStorage) TasksByLabel(label string) ([]*models.Task, error) {
+	return c.lookup(c.byLabel, label)
+}
+
+// LabelTasks adds and removes labels on several tasks
+func (c *CachedStorage) LabelTasks(taskIDs []string, add, remove []string) ([]*models.Task, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return nil, err
+	}
+
+	tasks, err := c.backend.LabelTasks(taskIDs, add, remove)
+	if err != nil {
+		return nil, err
+	}
+
+	c.syncLocked(nil, taskIDs...)
+	return tasks, nil
+}
+
+// CreateWorkLog logs work or starts a timer in the backend. Work logs aren't
+// cached.
+func (c *CachedStorage) CreateWorkLog(log *models.WorkLog) error {
+	return c.backend.CreateWorkLog(log)
+}
+
+// GetWorkLog retrieves a work log from the backend
+func (c *CachedStorage) GetWorkLog(id string) (*models.WorkLog, error) {
+	return c.backend.GetWorkLog(id)
+}
+
+// DeleteWorkLog deletes a work log from the backend
+func (c *CachedStorage) DeleteWorkLog(id string) error {
+	return c.backend.DeleteWorkLog(id)
+}
+
+// ListWorkLogs returns the work logs in the backend matching query
+func (c *CachedStorage) ListWorkLogs(query WorkLogQuery) ([]*models.WorkLog, error) {
+	return c.backend.ListWorkLogs(query)
+}
+
+// StopTimer stops the user's running timer in the backend
+func (c *CachedStorage) StopTimer(userID string, end time.Time) (*models.WorkLog, error) {
+	return c.backend.StopTimer(userID, end)
+}
+
+// CreateComment adds a comment in the backend. Comments aren't cached.
+func (c *CachedStorage) CreateComment(comment *models.Comment) error {
+	return c.backend.CreateComment(comment)
+}
+
+// GetComment retrieves a comment from the backend
+func (c *CachedStorage) GetComment(id string) (*models.Comment, error) {
+	return c.backend.GetComment(id)
+}
+
+// UpdateComment edits a comment in the backend
+func (c *CachedStorage) UpdateComment(comment *models.Comment) error {
+	return c.backend.UpdateComment(comment)
+}
+
+// DeleteComment deletes a comment and its replies from the backend
+func (c *CachedStorage) DeleteComment(id string) error {
+	return c.backend.DeleteComment(id)
+}
+
+// ListComments returns the comments on a task from the backend
+func (c *CachedStorage) ListComments(taskID string) ([]*models.Comment, error) {
+	return c.backend.ListComments(taskID)
+}
+
+// CreateSprint plans a new sprint in the backend. Sprints aren't cached.
+func (c *CachedStorage) CreateSprint(sprint *models.Sprint) error {
+	return c.backend.CreateSprint(sprint)
+}
+
+// GetSprint retrieves a sprint from the backend
+func (c *CachedStorage) GetSprint(id string) (*models.Sprint, error) {
+	return c.backend.GetSprint(id)
+}
+
+// UpdateSprint updates a sprint in the backend
+func (c *CachedStorage) UpdateSprint(sprint *models.Sprint) error {
+	return c.backend.UpdateSprint(sprint)
+}
+
+// DeleteSprint deletes a sprint from the backend, which also moves its tasks
+// to the backlog
+func (c *CachedStorage) DeleteSprint(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return err
+	}
+
+	members := c.bySprint.sorted(id)
+	if err := c.backend.DeleteSprint(id); err != nil {
+		return err
+	}
+
+	c.syncLocked(nil, members...)
+	return nil
+}
+
+// ListSprints returns every sprint in the backend ordered by start date
+func (c *CachedStorage) ListSprints() ([]*models.Sprint, error) {
+	return c.backend.ListSprints()
+}
+
+// StartSprint starts a sprint in the backend. Starting a sprint leaves its
+// tasks alone.
+func (c *CachedStorage) StartSprint(id string) (*models.Sprint, error) {
+	return c.backend.StartSprint(id)
+}
+
+// CloseSprint closes a sprint in the backend, which carries its unfinished
+// tasks over
+func (c *CachedStorage) CloseSprint(id, nextID string) (*models.Sprint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshLocked(); err != nil {
+		return nil, err
+	}
+
+	members := c.bySprint.sorted(id)
+	sprint, err := c.backend.CloseSprint(id, nextID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.syncLocked(nil, members...)
+	return sprint, nil
+}
+
+// CreateMilestone creates a milestone in the backend. Milestones aren't
+// cached.
+func (c *CachedStorage) CreateMilestone(milestone *models.Milestone) error {
+	return c.backend.CreateMilestone(milestone)
+}
+
+// GetMilestone retrieves a milestone from the backend
+func (c *CachedStorage) GetMilestone(id string) (*models.Milestone, error) {
+	return c.backend.GetMilestone(id)
+}
+
+// UpdateMilestone updates a milestone in the backend
+func (c *CachedStorage) UpdateMilestone(milestone *models.Milestone) error {
+	return c.backend.UpdateMilestone(milestone)
+}
+
+// DeleteMilestone deletes a milestone from the backend
+func (c *CachedStorage) DeleteMilestone(id string) error {
+	return c.backend.DeleteMilestone(id)
+}
+
+// ListMilestones returns every milestone in the backend ordered by target
+// date
+func (c *CachedStorage) ListMilestones() ([]*models.Milestone, error) {
+	return c.backend.ListMilestones()
+}
+
+// AppendEvents appends task events in the backend. Events aren't cached.
+func (c *CachedStorage) AppendEvents(events []*models.TaskEvent) error {
+	return c.backend.AppendEvents(events)
+}
+
+// ListEvents returns task events from the backend
+func (c *CachedStorage) ListEvents(query EventQuery) ([]*models.TaskEvent, error) {
+	return c.backend.ListEvents(query)
+}
+
+// GetTaskChildren returns all direct children of a task
+func (c *CachedStorage) GetTaskChildren(parentID string) ([]*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	parent, ok := c.tasks[parentID]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", parentID)
+	}
+
+	var children []*models.Task
+	for _, childID := range parent.Children {
+		if child, ok := c.tasks[childID]; ok {
+			children = append(children, child.Clone())
+		}
+	}
+
+	return children, nil
+}
+
+// GetTaskParent returns the parent task of a given task
+func (c *CachedStorage) GetTaskParent(childID string) (*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	child, ok := c.tasks[childID]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", childID)
+	}
+
+	if child.ParentID == "" {
+		return nil, fmt.Errorf("task has no parent")
+	}
+
+	parent, ok := c.tasks[child.ParentID]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %s", child.ParentID)
+	}
+	return parent.Clone(), nil
+}
+
+// GetTaskHierarchy returns all tasks organized in hierarchical structure
+func (c *CachedStorage) GetTaskHierarchy() ([]*models.HierarchyTask, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	taskMap := make(map[string]*models.Task, len(c.tasks))
+	for id, task := range c.tasks {
+		taskMap[id] = task.Clone()
+	}
+
+	var rootTasks []*models.HierarchyTask
+	for _, id := range c.byParent.sorted("") {
+		rootTasks = append(rootTasks, buildHierarchyTask(taskMap[id], taskMap))
+	}
+
+	return rootTasks, nil
+}
+
+// TaskExists checks if a task exists
+func (c *CachedStorage) TaskExists(id string) bool {
+	if err := c.rlock(); err != nil {
+		return false
+	}
+	defer c.mu.RUnlock()
+
+	_, ok := c.tasks[id]
+	return ok
+}
+
+// Close closes the wrapped backend
+func (c *CachedStorage) Close() error {
+	return c.backend.Close()
+}
+
+// Internal methods
+
+// lookup returns clones of the tasks stored under key in idx
+func (c *CachedStorage) lookup(idx taskIndex, key string) ([]*models.Task, error) {
+	if err := c.rlock(); err != nil {
+		return nil, err
+	}
+	defer c.mu.RUnlock()
+
+	return c.cloneLocked(idx.sorted(key)), nil
+}
+
+func (c *CachedStorage) cloneLocked(ids []string) []*models.Task {
+	tasks := make([]*models.Task, 0, len(ids))
+	for _, id := range ids {
+		tasks = append(tasks, c.tasks[id].Clone())
+	}
+	return tasks
+}
+
+// rlock takes the read lock, reloading the cache first if it is out of date
+func (c *CachedStorage) rlock() error {
+	generation, err := c.backendGeneration()
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	if c.loaded && generation == c.generation {
+		return nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	err = c.refreshLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	return nil
+}
+
+// refreshLocked reloads the cache if it is empty or the backend has changed.
+// Must be called with the write lock held.
+func (c *CachedStorage) refreshLocked() error {
+	// Read the generation before the tasks so the data is never older than it
+	generation, err := c.backendGeneration()
+	if err != nil {
+		return err
+	}
+	if c.loaded && generation == c.generation {
+		return nil
+	}
+
+	tasks, err := c.backend.ListTasks()
+	if err != nil {
+		c.loaded = false
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	c.tasks = make(map[string]*models.Task, len(tasks))
+	c.byKey = make(taskIndex)
+	c.byParent = make(taskIndex)
+	c.byStatus = make(taskIndex)
+	c.byType = make(taskIndex)
+	c.byPriority = make(taskIndex)
+	c.byAssignee = make(taskIndex)
+	c.byLabel = make(taskIndex)
+	c.bySprint = make(taskIndex)
+	c.search = search.NewIndex()
+	for _, task := range tasks {
+		c.putLocked(task)
+	}
+
+	c.generation = generation
+	c.loaded = true
+	return nil
+}
+
+// syncLocked updates the cache after a write made through it by dropping the
+// removed tasks and re-reading the changed ones from the backend. If the
+// generation moved by more than this one write another process wrote too,
+// so the whole cache is marked for reload instead. Must be called with the
+// write lock held.
+func (c *CachedStorage) syncLocked(removed []string, changed ...string) {
+	generation, err := c.backendGeneration()
+	if err != nil || (c.tracker != nil && generation != c.generation+1) {
+		c.loaded = false
+		return
+	}
+
+	for _, id := range removed {
+		c.removeLocked(id)
+	}
+
+	for _, id := range changed {
+		if id == "" {
+			continue
+		}
+		task, err := c.backend.GetTask(id)
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				c.loaded = false
+				return
+			}
+			c.removeLocked(id)
+			continue
+		}
+		c.putLocked(task)
+	}
+
+	c.generation = generation
+}
+
+// putLocked adds or replaces a task and its index entries
+func (c *CachedStorage) putLocked(task *models.Task) {
+	c.removeLocked(task.ID)
+
+	c.tasks[task.ID] = task
+	c.byKey.add(task.Key, task.ID)
+	c.byParent.add(task.ParentID, task.ID)
+	c.byStatus.add(string(task.Status), task.ID)
+	c.byType.add(string(task.Type), task.ID)
+	c.byPriority.add(string(task.Priority), task.ID)
+	c.byAssignee.add(task.AssigneeID, task.ID)
+	for _, label := range task.Labels {
+		c.byLabel.add(label, task.ID)
+	}
+	c.bySprint.add(task.SprintID, task.ID)
+	c.search.Add(task.ID, task.Title, task.Description)
+}
+
+// removeLocked drops a task and its index entries
+func (c *CachedStorage) removeLocked(id string) {
+	task, ok := c.tasks[id]
+	if !ok {
+		return
+	}
+
+	delete(c.tasks, id)
+	c.byKey.remove(task.Key, id)
+	c.byParent.remove(task.ParentID, id)
+	c.byStatus.remove(string(task.Status), id)
+	c.byType.remove(string(task.Type), id)
+	c.byPriority.remove(string(task.Priority), id)
+	c.byAssignee.remove(task.AssigneeID, id)
+	for _, label := range task.Labels {
+		c.byLabel.remove(label, id)
+	}
+	c.bySprint.remove(task.SprintID, id)
+	c.search.Remove(id)
+}
+
+// backendGeneration returns the backend's write counter, or zero when it
+// doesn't track changes
+func (c *CachedStorage) backendGeneration() (uint64, error) {
+	if c.tracker == nil {
+		return 0, nil
+	}
+	return c.tracker.Generation()
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// countingStorage counts full loads so tests can tell when the cache reloads
+type countingStorage struct {
+	*FileStorage
+	lists int
+}
+
+func (cs *countingStorage) ListTasks() ([]*models.Task, error) {
+	cs.lists++
+	return cs.FileStorage.ListTasks()
+}
+
+func newCountingCache(t *testing.T, dataDir string) (*CachedStorage, *countingStorage) {
+	t.Helper()
+	backend, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	counting := &countingStorage{FileStorage: backend}
+	return NewCachedStorage(counting), counting
+}
+
+func TestCachedStorage_WritesDoNotReload(t *testing.T) {
+	cache, backend := newCountingCache(t, t.TempDir())
+
+	parent := models.NewTask("Parent", "")
+	if err := cache.CreateTask(parent); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	child := models.NewTask("Child", "")
+	child.Type = models.TypeSubtask
+	child.ParentID = parent.ID
+	if err := cache.CreateTask(child); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	child.Status = models.StatusDone
+	if err := cache.UpdateTask(child); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	children, err := cache.GetTaskChildren(parent.ID)
+	if err != nil {
+		t.Fatalf("GetTaskChildren() error = %v", err)
+	}
+	if len(children) != 1 || children[0].Status != models.StatusDone {
+		t.Errorf("GetTaskChildren() = %v, want the updated child", children)
+	}
+
+	if backend.lists != 1 {
+		t.Errorf("backend was loaded %d times, want 1", backend.lists)
+	}
+}
+
+func TestCachedStorage_SeesChangesFromOtherProcess(t *testing.T) {
+	dataDir := t.TempDir()
+	cache, backend := newCountingCache(t, dataDir)
+	other, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	task := models.NewTask("Original", "")
+	if err := cache.CreateTask(task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	// Write behind the cache's back, as another process sharing the directory would
+	external, err := other.GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	external.Title = "Changed elsewhere"
+	if err := other.UpdateTask(external); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	added := models.NewTask("Added elsewhere", "")
+	if err := other.CreateTask(added); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	got, err := cache.GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if got.Title != "Changed elsewhere" {
+		t.Errorf("GetTask() title = %q, want the external change", got.Title)
+	}
+	if !cache.TaskExists(added.ID) {
+		t.Error("TaskExists() should see a task created by another process")
+	}
+
+	// A write through the cache after an external one must not hide either
+	mine := models.NewTask("Mine", "")
+	if err := other.DeleteTask(added.ID); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if err := cache.CreateTask(mine); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	tasks, err := cache.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("ListTasks() returned %d tasks, want 2", len(tasks))
+	}
+
+	loads := backend.lists
+	if _, err := cache.ListTasks(); err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if backend.lists != loads {
+		t.Error("ListTasks() reloaded an unchanged backend")
+	}
+}
+
+func TestCachedStorage_Indexes(t *testing.T) {
+	cache, _ := newCountingCache(t, t.TempDir())
+
+	epic := models.NewTask("Epic", "")
+	epic.Type = models.TypeEpic
+	epic.Priority = models.PriorityHigh
+	if err := cache.CreateTask(epic); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	story := models.NewTask("Story", "")
+	story.Type = models.TypeStory
+	story.ParentID = epic.ID
+	if err := cache.CreateTask(story); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	story.Status = models.StatusInProgress
+	story.Priority = models.PriorityHigh
+	if err := cache.UpdateTask(story); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() ([]*models.Task, error)
+		want   []string
+	}{
+		{"status todo", func() ([]*models.Task, error) { return cache.TasksByStatus(models.StatusTodo) }, []string{epic.ID}},
+		{"status in progress", func() ([]*models.Task, error) { return cache.TasksByStatus(models.StatusInProgress) }, []string{story.ID}},
+		{"type story", func() ([]*models.Task, error) { return cache.TasksByType(models.TypeStory) }, []string{story.ID}},
+		{"priority high", func() ([]*models.Task, error) { return cache.TasksByPriority(models.PriorityHigh) }, []string{epic.ID, story.ID}},
+		{"priority medium", func() ([]*models.Task, error) { return cache.TasksByPriority(models.PriorityMedium) }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := tt.lookup()
+			if err != nil {
+				t.Fatalf("lookup error = %v", err)
+			}
+			ids := make([]string, 0, len(tasks))
+			for _, task := range tasks {
+				ids = append(ids, task.ID)
+			}
+			if !equalIDs(ids, tt.want) {
+				t.Errorf("lookup = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	hierarchy, err := cache.GetTaskHierarchy()
+	if err != nil {
+		t.Fatalf("GetTaskHierarchy() error = %v", err)
+	}
+	if len(hierarchy) != 1 || len(hierarchy[0].ChildTasks) != 1 {
+		t.Fatalf("GetTaskHierarchy() = %v, want the epic with one story", hierarchy)
+	}
+}
+
+func TestCachedStorage_ReturnsCopies(t *testing.T) {
+	cache, _ := newCountingCache(t, t.TempDir())
+
+	task := models.NewTask("Original", "")
+	if err := cache.CreateTask(task); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	got, err := cache.GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	got.Title = "Mutated"
+
+	again, err := cache.GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if again.Title != "Original" {
+		t.Error("GetTask() should return a copy that callers can't change in place")
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkCommentBody checks a comment body is neither blank nor too long
+func checkCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidComment)
+	}
+	if len(body) > models.MaxCommentLength {
+		return fmt.Errorf("%w: body is longer than %d bytes", ErrInvalidComment, models.MaxCommentLength)
+	}
+	return nil
+}
+
+// checkComment checks a new comment refers to an existing task and author,
+// and that a reply's parent is a comment on the same task
+func checkComment(taskExists, userExists func(id string) bool, getComment func(id string) (*models.Comment, error), comment *models.Comment) error {
+	if !taskExists(comment.TaskID) {
+		return fmt.Errorf("task not found: %s", comment.TaskID)
+	}
+	if !userExists(comment.AuthorID) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, comment.AuthorID)
+	}
+	if err := checkCommentBody(comment.Body); err != nil {
+		return err
+	}
+	if comment.ParentID != "" {
+		parent, err := getComment(comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.TaskID != comment.TaskID {
+			return fmt.Errorf("%w: parent comment %s is on another task", ErrInvalidComment, comment.ParentID)
+		}
+	}
+	return nil
+}
+
+// commentReplies returns the IDs of the replies to comment id among
+// comments, and of the replies to those, and so on
+func commentReplies(comments []*models.Comment, id string) []string {
+	children := make(map[string][]string)
+	for _, comment := range comments {
+		if comment.ParentID != "" {
+			children[comment.ParentID] = append(children[comment.ParentID], comment.ID)
+		}
+	}
+
+	var replies []string
+	pending := append([]string{}, children[id]...)
+	for len(pending) > 0 {
+		reply := pending[0]
+		pending = pending[1:]
+		replies = append(replies, reply)
+		pending = append(pending, children[reply]...)
+	}
+	return replies
+}
+
+// sortComments orders comments oldest first, then by ID
+func sortComments(comments []*models.Comment) {
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestComments(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := models.NewUser("Alice", models.UserHuman)
+			if err := store.CreateUser(alice); err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+			tasks := createTasks(t, store, "First", "Second")
+			first, second := tasks[0], tasks[1]
+
+			addComment := func(taskID, parentID, body string) *models.Comment {
+				t.Helper()
+				comment := models.NewComment(taskID, alice.ID, body)
+				comment.ParentID = parentID
+				if err := store.CreateComment(comment); err != nil {
+					t.Fatalf("CreateComment() error = %v", err)
+				}
+				return comment
+			}
+			question := addComment(first.ID, "", "Which **database**?")
+			answer := addComment(first.ID, question.ID, "SQLite")
+			followUp := addComment(first.ID, answer.ID, "Thanks")
+			other := addComment(first.ID, "", "Unrelated")
+			addComment(second.ID, "", "On another task")
+
+			invalid := []struct {
+				name    string
+				comment *models.Comment
+				wantErr error
+			}{
+				{"blank body", models.NewComment(first.ID, alice.ID, "  \n"), ErrInvalidComment},
+				{"long body", models.NewComment(first.ID, alice.ID, strings.Repeat("x", models.MaxCommentLength+1)), ErrInvalidComment},
+				{"unknown author", models.NewComment(first.ID, "missing", "Hi"), ErrUserNotFound},
+				{"unknown parent", &models.Comment{TaskID: first.ID, AuthorID: alice.ID, ParentID: "missing", Body: "Hi"}, ErrCommentNotFound},
+				{"parent on another task", &models.Comment{TaskID: second.ID, AuthorID: alice.ID, ParentID: question.ID, Body: "Hi"}, ErrInvalidComment},
+			}
+			for _, tt := range invalid {
+				if err := store.CreateComment(tt.comment); !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateComment() with %s error = %v, want %v", tt.name, err, tt.wantErr)
+				}
+			}
+			if err := store.CreateComment(models.NewComment("missing", alice.ID, "Hi")); err == nil {
+				t.Error("CreateComment() with unknown task expected error")
+			}
+
+			comments, err := store.ListComments(first.ID)
+			if err != nil {
+				t.Fatalf("ListComments() error = %v", err)
+			}
+			if len(comments) != 4 || comments[0].ID != question.ID || comments[3].ID != other.ID {
+				t.Errorf("ListComments() = %v, want the first task's four comments oldest first", comments)
+			}
+
+			// Only the body and edit time change
+			edit := *answer
+			edit.AuthorID = "someone else"
+			edit.Edit("SQLite, for now")
+			if err := store.UpdateComment(&edit); err != nil {
+				t.Fatalf("UpdateComment() error = %v", err)
+			}
+			got, err := store.GetComment(answer.ID)
+			if err != nil {
+				t.Fatalf("GetComment() error = %v", err)
+			}
+			if got.Body != "SQLite, for now" || got.EditedAt == nil || got.AuthorID != alice.ID {
+				t.Errorf("GetComment() after edit = %+v", got)
+			}
+			edit.Body = ""
+			if err := store.UpdateComment(&edit); !errors.Is(err, ErrInvalidComment) {
+				t.Errorf("UpdateComment() with blank body error = %v, want ErrInvalidComment", err)
+			}
+
+			// Deleting a comment deletes the thread below it
+			if err := store.DeleteComment(answer.ID); err != nil {
+				t.Fatalf("DeleteComment() error = %v", err)
+			}
+			for _, id := range []string{answer.ID, followUp.ID} {
+				if _, err := store.GetComment(id); !errors.Is(err, ErrCommentNotFound) {
+					t.Errorf("GetComment(%s) after delete error = %v, want ErrCommentNotFound", id, err)
+				}
+			}
+			comments, err = store.ListComments(first.ID)
+			if err != nil {
+				t.Fatalf("ListComments() error = %v", err)
+			}
+			if len(comments) != 2 {
+				t.Errorf("ListComments() after delete = %v, want 2 comments", comments)
+			}
+			if err := store.DeleteComment("missing"); !errors.Is(err, ErrCommentNotFound) {
+				t.Errorf("DeleteComment(missing) error = %v, want ErrCommentNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// deletePlan holds the task writes and deletions needed to delete a task
+type deletePlan struct {
+	writes []*models.Task
+	result *DeleteResult
+}
+
+// planDelete works out every change needed to delete id with strategy, reading
+// tasks through get. Backends apply the plan in a single atomic unit.
+func planDelete(get func(id string) (*models.Task, error), id string, strategy DeleteStrategy, expectedVersion int64) (*deletePlan, error) {
+	switch strategy {
+	case DeleteCascade, DeleteReparentToGrandparent, DeleteOrphanToRoot:
+	default:
+		return nil, fmt.Errorf("invalid delete strategy: %s", strategy)
+	}
+
+	task, err := get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(task, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	plan := &deletePlan{
+		result: &DeleteResult{Deleted: []string{}, Moved: []string{}},
+	}
+
+	// Remove from parent's children if it has a parent
+	var parent *models.Task
+	if task.ParentID != "" {
+		parent, err = get(task.ParentID)
+		if err == nil {
+			plan.writes = append(plan.writes, parent)
+		} else {
+			parent = nil
+		}
+	}
+
+	switch strategy {
+	case DeleteCascade:
+		plan.result.Deleted = collectSubtree(get, task)
+
+	case DeleteReparentToGrandparent, DeleteOrphanToRoot:
+		newParentID := ""
+		if strategy == DeleteReparentToGrandparent && parent != nil {
+			newParentID = parent.ID
+		}
+
+		var moved []string
+		for _, childID := range task.Children {
+			child, err := get(childID)
+			if err != nil {
+				// Dangling child reference; nothing to move
+				continue
+			}
+			if newParentID != "" {
+				if err := checkParentType(child, parent); err != nil {
+					return nil, err
+				}
+			}
+			child.ParentID = newParentID
+			child.UpdatedAt = time.Now()
+			plan.writes = append(plan.writes, child)
+			moved = append(moved, childID)
+		}
+
+		// Children take the deleted task's place among its siblings
+		if newParentID != "" {
+			parent.Children = replaceChild(parent.Children, id, moved)
+			parent.UpdatedAt = time.Now()
+		}
+		plan.result.Moved = append(plan.result.Moved, moved...)
+		plan.result.Deleted = []string{id}
+	}
+
+	if parent != nil && strategy != DeleteReparentToGrandparent {
+		parent.RemoveChild(id)
+	}
+
+	unlinkDeleted(get, plan)
+	bumpVersions(plan.writes)
+	return plan, nil
+}
+
+// collectSubtree returns the ID of task and of every task below it, children
+// before their parents. Dangling child references are skipped.
+func collectSubtree(get func(id string) (*models.Task, error), task *models.Task) []string {
+	visited := make(map[string]bool)
+	var ids []string
+
+	var walk func(t *models.Task)
+	walk = func(t *models.Task) {
+		visited[t.ID] = true
+		for _, childID := range t.Children {
+			if visited[childID] {
+				continue
+			}
+			child, err := get(childID)
+			if err != nil {
+				continue
+			}
+			walk(child)
+		}
+		ids = append(ids, t.ID)
+	}
+	walk(task)
+
+	return ids
+}
+
+// replaceChild replaces id in children with replacements, keeping its position
+func replaceChild(children []string, id string, replacements []string) []string {
+	result := make([]string, 0, len(children)+len(replacements))
+	for _, childID := range children {
+		if childID == id {
+			result = append(result, replacements...)
+			continue
+		}
+		result = append(result, childID)
+	}
+	return result
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// deleteFixture is a three-level tree: root -> parent -> child -> grandchild,
+// plus a sibling of parent under root. The levels are an epic, stories, a
+// task and a subtask.
+type deleteFixture struct {
+	root, parent, sibling, child, grandchild *models.Task
+}
+
+func newDeleteFixture(t *testing.T, store Storage) *deleteFixture {
+	t.Helper()
+	create := func(title string, taskType models.TaskType, parentID string) *models.Task {
+		task := models.NewTask(title, "")
+		task.Type = taskType
+		task.ParentID = parentID
+		if err := store.CreateTask(task); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		return task
+	}
+
+	f := &deleteFixture{}
+	f.root = create("Root", models.TypeEpic, "")
+	f.parent = create("Parent", models.TypeStory, f.root.ID)
+	f.sibling = create("Sibling", models.TypeStory, f.root.ID)
+	f.child = create("Child", models.TypeTask, f.parent.ID)
+	f.grandchild = create("Grandchild", models.TypeSubtask, f.child.ID)
+	return f
+}
+
+func storageBackends(t *testing.T) map[string]Storage {
+	t.Helper()
+	fileStore, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create file storage: %v", err)
+	}
+	sqliteStore, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create sqlite storage: %v", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+	cachedSQLiteStore, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create sqlite storage: %v", err)
+	}
+	t.Cleanup(func() { cachedSQLiteStore.Close() })
+	cachedFileStore, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create file storage: %v", err)
+	}
+
+	return map[string]Storage{
+		BackendFile:               fileStore,
+		BackendSQLite:             sqliteStore,
+		"cached-" + BackendFile:   NewCachedStorage(cachedFileStore),
+		"cached-" + BackendSQLite: NewCachedStorage(cachedSQLiteStore),
+	}
+}
+
+func sortedIDs(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func equalIDs(a, b []string) bool {
+	a, b = sortedIDs(a), sortedIDs(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDeleteTaskWithStrategy_Cascade(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			result, err := store.DeleteTaskWithStrategy(f.parent.ID, DeleteCascade, 0)
+			if err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+
+			wantDeleted := []string{f.parent.ID, f.child.ID, f.grandchild.ID}
+			if !equalIDs(result.Deleted, wantDeleted) {
+				t.Errorf("Deleted = %v, want %v", result.Deleted, wantDeleted)
+			}
+			if len(result.Moved) != 0 {
+				t.Errorf("Moved = %v, want none", result.Moved)
+			}
+			for _, id := range wantDeleted {
+				if store.TaskExists(id) {
+					t.Errorf("task %s should have been deleted", id)
+				}
+			}
+
+			root, err := store.GetTask(f.root.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalIDs(root.Children, []string{f.sibling.ID}) {
+				t.Errorf("root children = %v, want [%s]", root.Children, f.sibling.ID)
+			}
+		})
+	}
+}
+
+func TestDeleteTaskWithStrategy_ReparentToGrandparent(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			// The default workflow doesn't allow the task under the epic
+			if _, err := store.DeleteTaskWithStrategy(f.parent.ID, DeleteReparentToGrandparent, 0); !errors.Is(err, ErrInvalidParentType) {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v, want %v", err, ErrInvalidParentType)
+			}
+			setParentTypes(t, map[models.TaskType][]models.TaskType{
+				models.TypeStory:   {models.TypeEpic},
+				models.TypeTask:    {models.TypeEpic, models.TypeStory},
+				models.TypeSubtask: {models.TypeTask},
+			})
+
+			result, err := store.DeleteTaskWithStrategy(f.parent.ID, DeleteReparentToGrandparent, 0)
+			if err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+
+			if !equalIDs(result.Deleted, []string{f.parent.ID}) {
+				t.Errorf("Deleted = %v, want [%s]", result.Deleted, f.parent.ID)
+			}
+			if !equalIDs(result.Moved, []string{f.child.ID}) {
+				t.Errorf("Moved = %v, want [%s]", result.Moved, f.child.ID)
+			}
+
+			root, err := store.GetTask(f.root.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if len(root.Children) != 2 || root.Children[0] != f.child.ID {
+				t.Errorf("root children = %v, want child in parent's place", root.Children)
+			}
+
+			child, err := store.GetTask(f.child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if child.ParentID != f.root.ID {
+				t.Errorf("child ParentID = %q, want %q", child.ParentID, f.root.ID)
+			}
+			if !store.TaskExists(f.grandchild.ID) {
+				t.Error("grandchild should stay under the moved child")
+			}
+		})
+	}
+}
+
+func TestDeleteTaskWithStrategy_OrphanToRoot(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			result, err := store.DeleteTaskWithStrategy(f.parent.ID, DeleteOrphanToRoot, 0)
+			if err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+
+			if !equalIDs(result.Moved, []string{f.child.ID}) {
+				t.Errorf("Moved = %v, want [%s]", result.Moved, f.child.ID)
+			}
+
+			child, err := store.GetTask(f.child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if child.ParentID != "" {
+				t.Errorf("child ParentID = %q, want empty", child.ParentID)
+			}
+
+			root, err := store.GetTask(f.root.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalIDs(root.Children, []string{f.sibling.ID}) {
+				t.Errorf("root children = %v, want [%s]", root.Children, f.sibling.ID)
+			}
+
+			hierarchy, err := store.GetTaskHierarchy()
+			if err != nil {
+				t.Fatalf("GetTaskHierarchy() error = %v", err)
+			}
+			if len(hierarchy) != 2 {
+				t.Errorf("GetTaskHierarchy() returned %d roots, want 2", len(hierarchy))
+			}
+		})
+	}
+}
+
+func TestParseDeleteStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    DeleteStrategy
+		wantErr bool
+	}{
+		{name: "", want: DeleteCascade},
+		{name: "cascade", want: DeleteCascade},
+		{name: "reparent-to-grandparent", want: DeleteReparentToGrandparent},
+		{name: "orphan-to-root", want: DeleteOrphanToRoot},
+		{name: "shred", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDeleteStrategy(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDeleteStrategy(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDeleteStrategy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,20 +1,45 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/aykay76/projectflow/internal/models"
 	"github.com/google/uuid"
 )
 
-// FileStorage implements the Storage interface using the file system
+// lockFileName is the advisory lock file shared by every process that opens
+// the same data directory
+const lockFileName = ".lock"
+
+// generationFileName holds the write counter reported by Generation
+const generationFileName = ".generation"
+
+// taskKeyFileName holds the number of the last task key given out
+const taskKeyFileName = ".task-key"
+
+// FileStorage implements the Storage interface using the file system.
+// mu serialises goroutines within this process, while an flock on the
+// data directory's lock file serialises separate processes.
 type FileStorage struct {
+	*fileState
+	// actor is who changes made through this view are recorded as made by;
+	// nil records no history
+	actor *Actor
+}
+
+// fileState is shared by a FileStorage and its views from WithActor
+type fileState struct {
 	dataDir string
 	mu      sync.RWMutex
+	search  searchIndex
 }
 
 // NewFileStorage creates a new file-based storage instance
@@ -30,9 +55,100 @@ func NewFileStorage(dataDir string) (*FileStorage, error) {
 		return nil, fmt.Errorf("failed to create tasks directory: %w", err)
 	}
 
-	return &FileStorage{
-		dataDir: dataDir,
-	}, nil
+	// Create users subdirectory
+	usersDir := filepath.Join(dataDir, "users")
+	if err := os.MkdirAll(usersDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create users directory: %w", err)
+	}
+
+	// Create labels subdirectory
+	labelsDir := filepath.Join(dataDir, "labels")
+	if err := os.MkdirAll(labelsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create labels directory: %w", err)
+	}
+
+	// Create worklogs subdirectory
+	workLogsDir := filepath.Join(dataDir, "worklogs")
+	if err := os.MkdirAll(workLogsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create worklogs directory: %w", err)
+	}
+
+	// Create comments subdirectory
+	commentsDir := filepath.Join(dataDir, "comments")
+	if err := os.MkdirAll(commentsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create comments directory: %w", err)
+	}
+
+	// Create sprints subdirectory
+	sprintsDir := filepath.Join(dataDir, "sprints")
+	if err := os.MkdirAll(sprintsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sprints directory: %w", err)
+	}
+
+	// Create milestones subdirectory
+	milestonesDir := filepath.Join(dataDir, "milestones")
+	if err := os.MkdirAll(milestonesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create milestones directory: %w", err)
+	}
+
+	// Create keys subdirectory
+	keysDir := filepath.Join(dataDir, "keys")
+	if err := os.MkdirAll(keysDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	// Create history subdirectory
+	historyDir := filepath.Join(dataDir, "history")
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	fs := &FileStorage{
+		fileState: &fileState{dataDir: dataDir},
+	}
+
+	// Taking the write lock replays any journal left by a crash
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := removeTempFiles(tasksDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(usersDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(labelsDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(workLogsDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(commentsDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(sprintsDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(milestonesDir); err != nil {
+		return nil, err
+	}
+	if err := removeTempFiles(keysDir); err != nil {
+		return nil, err
+	}
+	if err := fs.assignKeysUnsafe(); err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// WithActor returns a view of the storage that records its changes in the
+// task history as made by actor
+func (fs *FileStorage) WithActor(actor Actor) Storage {
+	return &FileStorage{fileState: fs.fileState, actor: &actor}
 }
 
 // CreateTask creates a new task and assigns it an ID
@@ -40,77 +156,219 @@ func (fs *FileStorage) CreateTask(task *models.Task) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	// Generate UUID for new task
-	task.ID = uuid.New().String()
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	// If this task has a parent, add it to parent's children
+	if err := checkUsers(fs.userExistsUnsafe, task); err != nil {
+		return err
+	}
+	if err := checkLabels(fs.labelExistsUnsafe, task); err != nil {
+		return err
+	}
+	if err := checkTaskSprint(fs.getSprintUnsafe, task, ""); err != nil {
+		return err
+	}
+
+	var parent *models.Task
 	if task.ParentID != "" {
-		parent, err := fs.getTaskUnsafe(task.ParentID)
+		var err error
+		parent, err = fs.getTaskUnsafe(task.ParentID)
 		if err != nil {
-			return fmt.Errorf("parent task not found: %w", err)
+			return fmt.Errorf("%w: %s", ErrParentNotFound, task.ParentID)
 		}
+		if err := checkParentType(task, parent); err != nil {
+			return err
+		}
+	}
+
+	// Generate UUID for new task, and take a key only once the task is
+	// known to be valid so rejected creates don't leave gaps
+	task.ID = uuid.New().String()
+	task.Version = 1
+	trackCompletion(nil, task)
+	number, err := fs.nextKeyNumberUnsafe()
+	if err != nil {
+		return err
+	}
+	task.Key = models.CurrentWorkflow().TaskKey(number)
+
+	// If this task has a parent, add it to parent's children
+	if parent != nil {
 		parent.AddChild(task.ID)
-		if err := fs.saveTaskUnsafe(parent); err != nil {
-			return fmt.Errorf("failed to update parent task: %w", err)
+		parent.Version++
+		if err := fs.commitUnsafe([]*models.Task{parent, task}, nil); err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
 		}
+		return nil
 	}
 
-	return fs.saveTaskUnsafe(task)
+	return fs.commitUnsafe([]*models.Task{task}, nil)
 }
 
 // GetTask retrieves a task by ID
 func (fs *FileStorage) GetTask(id string) (*models.Task, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	return fs.getTaskUnsafe(id)
 }
 
+// GetTaskByKey retrieves a task by key
+func (fs *FileStorage) GetTaskByKey(key string) (*models.Task, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getTaskByKeyUnsafe(key)
+}
+
 // UpdateTask updates an existing task
 func (fs *FileStorage) UpdateTask(task *models.Task) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	if !fs.taskExistsUnsafe(task.ID) {
-		return fmt.Errorf("task not found: %s", task.ID)
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkUsers(fs.userExistsUnsafe, task); err != nil {
+		return err
+	}
+	if err := checkLabels(fs.labelExistsUnsafe, task); err != nil {
+		return err
+	}
+	if err := checkTaskSprint(fs.getSprintUnsafe, task, storedSprintID(fs.getTaskUnsafe, task.ID)); err != nil {
+		return err
+	}
+
+	// Re-read the stored tasks under the lock so changes made by another
+	// process since the caller read the task are not overwritten
+	writes, err := planUpdate(fs.getTaskUnsafe, task)
+	if err != nil {
+		return err
 	}
 
-	return fs.saveTaskUnsafe(task)
+	return fs.commitUnsafe(writes, nil)
 }
 
-// DeleteTask deletes a task and removes it from parent's children
+// DeleteTask deletes a task together with its entire subtree
 func (fs *FileStorage) DeleteTask(id string) error {
+	_, err := fs.DeleteTaskWithStrategy(id, DeleteCascade, 0)
+	return err
+}
+
+// DeleteTaskWithStrategy deletes a task, removes it from its parent's children
+// and deletes or moves its children according to strategy
+func (fs *FileStorage) DeleteTaskWithStrategy(id string, strategy DeleteStrategy, expectedVersion int64) (*DeleteResult, error) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	task, err := fs.getTaskUnsafe(id)
+	unlock, err := fs.lockDir(true)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer unlock()
 
-	// Remove from parent's children if it has a parent
-	if task.ParentID != "" {
-		parent, err := fs.getTaskUnsafe(task.ParentID)
-		if err == nil {
-			parent.RemoveChild(id)
-			fs.saveTaskUnsafe(parent)
-		}
+	plan, err := planDelete(fs.getTaskUnsafe, id, strategy, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fs.commitUnsafe(plan.writes, plan.result.Deleted); err != nil {
+		return nil, err
 	}
+	return plan.result, nil
+}
+
+// LinkTasks links fromID to toID, storing the inverse link on toID
+func (fs *FileStorage) LinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	return fs.writeLinks(func(get func(id string) (*models.Task, error)) ([]*models.Task, error) {
+		return planLink(get, fromID, linkType, toID)
+	})
+}
+
+// UnlinkTasks removes a link and its inverse
+func (fs *FileStorage) UnlinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	return fs.writeLinks(func(get func(id string) (*models.Task, error)) ([]*models.Task, error) {
+		return planUnlink(get, fromID, linkType, toID)
+	})
+}
+
+// writeLinks commits the tasks planned by plan through the journal
+func (fs *FileStorage) writeLinks(plan func(get func(id string) (*models.Task, error)) ([]*models.Task, error)) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 
-	// Delete all children recursively
-	for _, childID := range task.Children {
-		fs.deleteTaskUnsafe(childID)
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	return fs.deleteTaskUnsafe(id)
+	writes, err := plan(fs.getTaskUnsafe)
+	if err != nil {
+		return err
+	}
+	return fs.commitUnsafe(writes, nil)
 }
 
 // ListTasks returns all tasks
 func (fs *FileStorage) ListTasks() ([]*models.Task, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	return fs.listTasksUnsafe()
 }
 
+// QueryTasks returns the page of tasks selected by query
+func (fs *FileStorage) QueryTasks(query TaskQuery) (*TaskPage, error) {
+	tasks, err := fs.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	return ApplyQuery(tasks, query)
+}
+
+// SearchTasks ranks tasks by how well they match query
+func (fs *FileStorage) SearchTasks(query string, limit int) ([]*SearchResult, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	generation, err := fs.Generation()
+	if err != nil {
+		return nil, err
+	}
+	return fs.search.search(generation, fs.listTasksUnsafe, fs.getTaskUnsafe, query, limit)
+}
+
 // listTasksUnsafe returns all tasks (must be called with mutex held)
 func (fs *FileStorage) listTasksUnsafe() ([]*models.Task, error) {
 	tasksDir := filepath.Join(fs.dataDir, "tasks")
@@ -133,143 +391,1157 @@ func (fs *FileStorage) listTasksUnsafe() ([]*models.Task, error) {
 	return tasks, nil
 }
 
-// GetTaskChildren returns all direct children of a task
-func (fs *FileStorage) GetTaskChildren(parentID string) ([]*models.Task, error) {
+// CreateUser creates a new user and assigns it an ID. Users aren't cached, so
+// user writes leave the generation alone.
+func (fs *FileStorage) CreateUser(user *models.User) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	user.ID = uuid.New().String()
+	return fs.saveUserUnsafe(user)
+}
+
+// GetUser retrieves a user by ID
+func (fs *FileStorage) GetUser(id string) (*models.User, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	parent, err := fs.getTaskUnsafe(parentID)
+	unlock, err := fs.lockDir(false)
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	var children []*models.Task
-	for _, childID := range parent.Children {
-		child, err := fs.getTaskUnsafe(childID)
-		if err == nil {
-			children = append(children, child)
-		}
+	return fs.getUserUnsafe(id)
+}
+
+// UpdateUser updates an existing user
+func (fs *FileStorage) UpdateUser(user *models.User) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	return children, nil
+	if !fs.userExistsUnsafe(user.ID) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, user.ID)
+	}
+	return fs.saveUserUnsafe(user)
 }
 
-// GetTaskParent returns the parent task of a given task
-func (fs *FileStorage) GetTaskParent(childID string) (*models.Task, error) {
-	fs.mu.RLock()
-	defer fs.mu.RUnlock()
+// DeleteUser deletes a user that no task refers to
+func (fs *FileStorage) DeleteUser(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 
-	child, err := fs.getTaskUnsafe(childID)
+	unlock, err := fs.lockDir(true)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer unlock()
 
-	if child.ParentID == "" {
-		return nil, fmt.Errorf("task has no parent")
+	if !fs.userExistsUnsafe(id) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, id)
+	}
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return err
+	}
+	if refersToUser(tasks, id) {
+		return fmt.Errorf("%w: %s", ErrUserInUse, id)
 	}
 
-	return fs.getTaskUnsafe(child.ParentID)
+	if err := os.Remove(fs.userPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete user file: %w", err)
+	}
+	return nil
 }
 
-// GetTaskHierarchy returns all tasks organized in hierarchical structure
-// Returns only top-level tasks (epics without parents) with their nested children
-func (fs *FileStorage) GetTaskHierarchy() ([]*models.HierarchyTask, error) {
+// ListUsers returns every user ordered by name
+func (fs *FileStorage) ListUsers() ([]*models.User, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	// Get all tasks first
-	allTasks, err := fs.listTasksUnsafe()
+	unlock, err := fs.lockDir(false)
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	// Create a map for quick lookup
-	taskMap := make(map[string]*models.Task)
-	for _, task := range allTasks {
-		taskMap[task.ID] = task
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "users"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users directory: %w", err)
 	}
 
-	// Build the hierarchy by finding root tasks (no parent) and recursively building children
-	var rootTasks []*models.HierarchyTask
-	for _, task := range allTasks {
-		if task.ParentID == "" {
-			hierarchyTask := fs.buildHierarchyTask(task, taskMap)
-			rootTasks = append(rootTasks, hierarchyTask)
+	users := []*models.User{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			user, err := fs.getUserUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				users = append(users, user)
+			}
 		}
 	}
 
-	return rootTasks, nil
+	sortUsers(users)
+	return users, nil
 }
 
-// buildHierarchyTask recursively builds a HierarchyTask with its children
-func (fs *FileStorage) buildHierarchyTask(task *models.Task, taskMap map[string]*models.Task) *models.HierarchyTask {
-	hierarchyTask := &models.HierarchyTask{
-		Task:       task,
-		ChildTasks: []*models.HierarchyTask{},
+// CreateLabel registers a new label. Like users, labels aren't cached.
+func (fs *FileStorage) CreateLabel(label *models.Label) error {
+	if err := checkLabel(label); err != nil {
+		return err
 	}
 
-	// Recursively build children
-	for _, childID := range task.Children {
-		if childTask, exists := taskMap[childID]; exists {
-			childHierarchyTask := fs.buildHierarchyTask(childTask, taskMap)
-			hierarchyTask.ChildTasks = append(hierarchyTask.ChildTasks, childHierarchyTask)
-		}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	return hierarchyTask
+	if fs.labelExistsUnsafe(label.Name) {
+		return fmt.Errorf("%w: %s", ErrLabelExists, label.Name)
+	}
+	return fs.saveLabelUnsafe(label)
 }
 
-// TaskExists checks if a task exists
-func (fs *FileStorage) TaskExists(id string) bool {
+// GetLabel retrieves a label by name
+func (fs *FileStorage) GetLabel(name string) (*models.Label, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
-	return fs.taskExistsUnsafe(id)
-}
 
-// Close closes the storage (no-op for file storage)
-func (fs *FileStorage) Close() error {
-	return nil
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getLabelUnsafe(name)
 }
 
-// Internal unsafe methods (must be called with mutex held)
+// UpdateLabel updates an existing label's colour and description
+func (fs *FileStorage) UpdateLabel(label *models.Label) error {
+	if err := checkLabel(label); err != nil {
+		return err
+	}
 
-func (fs *FileStorage) getTaskUnsafe(id string) (*models.Task, error) {
-	filePath := filepath.Join(fs.dataDir, "tasks", id+".json")
-	data, err := os.ReadFile(filePath)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("task not found: %s", id)
-		}
-		return nil, fmt.Errorf("failed to read task file: %w", err)
+		return err
 	}
+	defer unlock()
 
-	var task models.Task
-	if err := json.Unmarshal(data, &task); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	if !fs.labelExistsUnsafe(label.Name) {
+		return fmt.Errorf("%w: %s", ErrLabelNotFound, label.Name)
 	}
-
-	return &task, nil
+	return fs.saveLabelUnsafe(label)
 }
 
-func (fs *FileStorage) saveTaskUnsafe(task *models.Task) error {
-	filePath := filepath.Join(fs.dataDir, "tasks", task.ID+".json")
-	data, err := json.MarshalIndent(task, "", "  ")
+// DeleteLabel removes a label from every task that carries it, then deletes it
+func (fs *FileStorage) DeleteLabel(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		return err
 	}
+	defer unlock()
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write task file: %w", err)
+	if !fs.labelExistsUnsafe(name) {
+		return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+	}
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return err
+	}
+	if err := fs.commitUnsafe(stripLabel(tasks, name), nil); err != nil {
+		return err
 	}
 
+	if err := os.Remove(fs.labelPath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete label file: %w", err)
+	}
 	return nil
 }
 
-func (fs *FileStorage) deleteTaskUnsafe(id string) error {
-	filePath := filepath.Join(fs.dataDir, "tasks", id+".json")
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete task file: %w", err)
-	}
+// ListLabels returns every label ordered by name
+func (fs *FileStorage) ListLabels() ([]*models.Label, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// ReadDir sorts by file name, which is the label name
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "labels"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels directory: %w", err)
+	}
+
+	labels := []*models.Label{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			label, err := fs.getLabelUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels, nil
+}
+
+// LabelTasks adds and removes labels on several tasks through the journal
+func (fs *FileStorage) LabelTasks(taskIDs []string, add, remove []string) ([]*models.Task, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	writes, tasks, err := planLabels(fs.getTaskUnsafe, fs.labelExistsUnsafe, taskIDs, add, remove)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.commitUnsafe(writes, nil); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// CreateWorkLog logs work or starts a timer and assigns it an ID. Like users,
+// work logs aren't cached.
+func (fs *FileStorage) CreateWorkLog(log *models.WorkLog) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkWorkLog(fs.taskExistsUnsafe, fs.userExistsUnsafe, log); err != nil {
+		return err
+	}
+	if log.IsRunning() {
+		logs, err := fs.listWorkLogsUnsafe()
+		if err != nil {
+			return err
+		}
+		if running := findRunningTimer(logs, log.UserID); running != nil {
+			return fmt.Errorf("%w: on task %s", ErrTimerRunning, running.TaskID)
+		}
+	}
+
+	log.ID = uuid.New().String()
+	return fs.saveWorkLogUnsafe(log)
+}
+
+// GetWorkLog retrieves a work log by ID
+func (fs *FileStorage) GetWorkLog(id string) (*models.WorkLog, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getWorkLogUnsafe(id)
+}
+
+// DeleteWorkLog deletes a work log
+func (fs *FileStorage) DeleteWorkLog(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := fs.getWorkLogUnsafe(id); err != nil {
+		return err
+	}
+	if err := os.Remove(fs.workLogPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete work log file: %w", err)
+	}
+	return nil
+}
+
+// ListWorkLogs returns the work logs matching query ordered by start
+func (fs *FileStorage) ListWorkLogs(query WorkLogQuery) ([]*models.WorkLog, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	logs, err := fs.listWorkLogsUnsafe()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*models.WorkLog{}
+	for _, log := range logs {
+		if query.matches(log) {
+			matched = append(matched, log)
+		}
+	}
+	sortWorkLogs(matched)
+	return matched, nil
+}
+
+// StopTimer stops the user's running timer at end
+func (fs *FileStorage) StopTimer(userID string, end time.Time) (*models.WorkLog, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	logs, err := fs.listWorkLogsUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	running := findRunningTimer(logs, userID)
+	if running == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoTimerRunning, userID)
+	}
+
+	running.Stop(end)
+	if err := fs.saveWorkLogUnsafe(running); err != nil {
+		return nil, err
+	}
+	return running, nil
+}
+
+// CreateComment adds a comment and assigns it an ID. Like users, comments
+// aren't cached.
+func (fs *FileStorage) CreateComment(comment *models.Comment) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkComment(fs.taskExistsUnsafe, fs.userExistsUnsafe, fs.getCommentUnsafe, comment); err != nil {
+		return err
+	}
+
+	comment.ID = uuid.New().String()
+	return fs.saveCommentUnsafe(comment)
+}
+
+// GetComment retrieves a comment by ID
+func (fs *FileStorage) GetComment(id string) (*models.Comment, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getCommentUnsafe(id)
+}
+
+// UpdateComment saves a comment's body and edit time
+func (fs *FileStorage) UpdateComment(comment *models.Comment) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := fs.getCommentUnsafe(comment.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkCommentBody(comment.Body); err != nil {
+		return err
+	}
+
+	stored.Body = comment.Body
+	stored.EditedAt = comment.EditedAt
+	if err := fs.saveCommentUnsafe(stored); err != nil {
+		return err
+	}
+	*comment = *stored
+	return nil
+}
+
+// DeleteComment deletes a comment and its replies
+func (fs *FileStorage) DeleteComment(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	comment, err := fs.getCommentUnsafe(id)
+	if err != nil {
+		return err
+	}
+	comments, err := fs.listCommentsUnsafe(comment.TaskID)
+	if err != nil {
+		return err
+	}
+
+	// Replies go first, deepest first, so a failure never leaves a reply
+	// without its parent
+	ids := append([]string{id}, commentReplies(comments, id)...)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := os.Remove(fs.commentPath(ids[i])); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete comment file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListComments returns the comments on a task, oldest first
+func (fs *FileStorage) ListComments(taskID string) ([]*models.Comment, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	comments, err := fs.listCommentsUnsafe(taskID)
+	if err != nil {
+		return nil, err
+	}
+	sortComments(comments)
+	return comments, nil
+}
+
+// CreateSprint plans a new sprint and assigns it an ID. Like users, sprints
+// aren't cached.
+func (fs *FileStorage) CreateSprint(sprint *models.Sprint) error {
+	if err := checkSprint(sprint); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sprint.ID = uuid.New().String()
+	sprint.State = models.SprintPlanned
+	return fs.saveSprintUnsafe(sprint)
+}
+
+// GetSprint retrieves a sprint by ID
+func (fs *FileStorage) GetSprint(id string) (*models.Sprint, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getSprintUnsafe(id)
+}
+
+// UpdateSprint updates a sprint's name, goal and dates
+func (fs *FileStorage) UpdateSprint(sprint *models.Sprint) error {
+	if err := checkSprint(sprint); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := fs.getSprintUnsafe(sprint.ID)
+	if err != nil {
+		return err
+	}
+	updateSprint(stored, sprint)
+	return fs.saveSprintUnsafe(sprint)
+}
+
+// DeleteSprint moves a sprint's tasks to the backlog through the journal,
+// then deletes it
+func (fs *FileStorage) DeleteSprint(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sprint, err := fs.getSprintUnsafe(id)
+	if err != nil {
+		return err
+	}
+	if sprint.State == models.SprintActive {
+		return fmt.Errorf("%w: sprint %q is active", ErrSprintState, sprint.Name)
+	}
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return err
+	}
+	if err := fs.commitUnsafe(leaveSprint(tasks, id), nil); err != nil {
+		return err
+	}
+
+	if err := os.Remove(fs.sprintPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete sprint file: %w", err)
+	}
+	return nil
+}
+
+// ListSprints returns every sprint ordered by start date
+func (fs *FileStorage) ListSprints() ([]*models.Sprint, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.listSprintsUnsafe()
+}
+
+// StartSprint makes a planned sprint active and records its commitment
+func (fs *FileStorage) StartSprint(id string) (*models.Sprint, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	sprint, err := fs.getSprintUnsafe(id)
+	if err != nil {
+		return nil, err
+	}
+	sprints, err := fs.listSprintsUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := startSprint(sprint, sprints, tasksInSprint(tasks, id)); err != nil {
+		return nil, err
+	}
+	if err := fs.saveSprintUnsafe(sprint); err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// CloseSprint closes the active sprint, carrying its unfinished tasks over
+// through the journal before the sprint itself is saved
+func (fs *FileStorage) CloseSprint(id, nextID string) (*models.Sprint, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	sprint, err := fs.getSprintUnsafe(id)
+	if err != nil {
+		return nil, err
+	}
+	sprints, err := fs.listSprintsUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := closeSprint(sprint, nextID, sprints, tasksInSprint(tasks, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.commitUnsafe(writes, nil); err != nil {
+		return nil, err
+	}
+	if err := fs.saveSprintUnsafe(sprint); err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// CreateMilestone creates a milestone and assigns it an ID. Like users,
+// milestones aren't cached.
+func (fs *FileStorage) CreateMilestone(milestone *models.Milestone) error {
+	if err := checkMilestone(milestone); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkMilestoneTasks(fs.getTaskUnsafe, milestone, nil); err != nil {
+		return err
+	}
+	milestone.ID = uuid.New().String()
+	return fs.saveMilestoneUnsafe(milestone)
+}
+
+// GetMilestone retrieves a milestone by ID
+func (fs *FileStorage) GetMilestone(id string) (*models.Milestone, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.getMilestoneUnsafe(id)
+}
+
+// UpdateMilestone updates an existing milestone
+func (fs *FileStorage) UpdateMilestone(milestone *models.Milestone) error {
+	if err := checkMilestone(milestone); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored, err := fs.getMilestoneUnsafe(milestone.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkMilestoneTasks(fs.getTaskUnsafe, milestone, stored.TaskIDs); err != nil {
+		return err
+	}
+	updateMilestone(stored, milestone)
+	return fs.saveMilestoneUnsafe(milestone)
+}
+
+// DeleteMilestone deletes a milestone, leaving its tasks alone
+func (fs *FileStorage) DeleteMilestone(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := fs.getMilestoneUnsafe(id); err != nil {
+		return err
+	}
+	if err := os.Remove(fs.milestonePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete milestone file: %w", err)
+	}
+	return nil
+}
+
+// ListMilestones returns every milestone ordered by target date
+func (fs *FileStorage) ListMilestones() ([]*models.Milestone, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "milestones"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read milestones directory: %w", err)
+	}
+
+	milestones := []*models.Milestone{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			milestone, err := fs.getMilestoneUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				milestones = append(milestones, milestone)
+			}
+		}
+	}
+
+	sortMilestones(milestones)
+	return milestones, nil
+}
+
+// AppendEvents appends task events to the history file of each task
+func (fs *FileStorage) AppendEvents(events []*models.TaskEvent) error {
+	if err := prepareEvents(events); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lockDir(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fs.appendEventsUnsafe(events)
+}
+
+// appendEventsUnsafe appends events to the history file of each task. Must
+// be called with the exclusive lock held.
+func (fs *FileStorage) appendEventsUnsafe(events []*models.TaskEvent) error {
+	// Group the events by task so each file is appended to once
+	var taskIDs []string
+	lines := make(map[string][]byte)
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal task event: %w", err)
+		}
+		if _, ok := lines[event.TaskID]; !ok {
+			taskIDs = append(taskIDs, event.TaskID)
+		}
+		lines[event.TaskID] = append(append(lines[event.TaskID], data...), '\n')
+	}
+	for _, taskID := range taskIDs {
+		if err := appendLines(fs.historyPath(taskID), lines[taskID]); err != nil {
+			return fmt.Errorf("failed to write history file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListEvents returns the task events matching query, newest first
+func (fs *FileStorage) ListEvents(query EventQuery) ([]*models.TaskEvent, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var events []*models.TaskEvent
+	if query.TaskID != "" {
+		if _, err := uuid.Parse(query.TaskID); err != nil {
+			return []*models.TaskEvent{}, nil
+		}
+		events, err = fs.readHistoryUnsafe(query.TaskID)
+	} else {
+		events, err = fs.listEventsUnsafe()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return selectEvents(events, query), nil
+}
+
+// GetTaskChildren returns all direct children of a task
+func (fs *FileStorage) GetTaskChildren(parentID string) ([]*models.Task, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	parent, err := fs.getTaskUnsafe(parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []*models.Task
+	for _, childID := range parent.Children {
+		child, err := fs.getTaskUnsafe(childID)
+		if err == nil {
+			children = append(children, child)
+		}
+	}
+
+	return children, nil
+}
+
+// GetTaskParent returns the parent task of a given task
+func (fs *FileStorage) GetTaskParent(childID string) (*models.Task, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	child, err := fs.getTaskUnsafe(childID)
+	if err != nil {
+		return nil, err
+	}
+
+	if child.ParentID == "" {
+		return nil, fmt.Errorf("task has no parent")
+	}
+
+	return fs.getTaskUnsafe(child.ParentID)
+}
+
+// GetTaskHierarchy returns all tasks organized in hierarchical structure
+// Returns only top-level tasks (epics without parents) with their nested children
+func (fs *FileStorage) GetTaskHierarchy() ([]*models.HierarchyTask, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Get all tasks first
+	allTasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return nil, err
+	}
+
+	return buildHierarchy(allTasks), nil
+}
+
+// TaskExists checks if a task exists
+func (fs *FileStorage) TaskExists(id string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	unlock, err := fs.lockDir(false)
+	if err != nil {
+		return false
+	}
+	defer unlock()
+
+	return fs.taskExistsUnsafe(id)
+}
+
+// Close closes the storage (no-op for file storage)
+func (fs *FileStorage) Close() error {
+	return nil
+}
+
+// Generation returns the number of writes made to the data directory by any
+// process. It is read without the lock; a reader that then loads tasks sees
+// data at least as new as the generation returned.
+func (fs *FileStorage) Generation() (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(fs.dataDir, generationFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read generation: %w", err)
+	}
+
+	generation, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse generation: %w", err)
+	}
+	return generation, nil
+}
+
+// bumpGenerationUnsafe increments the write counter and returns its new
+// value. Must be called with the exclusive lock held.
+func (fs *FileStorage) bumpGenerationUnsafe() (uint64, error) {
+	generation, err := fs.Generation()
+	if err != nil {
+		// A damaged counter is replaced; readers just see a change
+		generation = 0
+	}
+
+	path := filepath.Join(fs.dataDir, generationFileName)
+	if err := writeFileAtomic(path, []byte(strconv.FormatUint(generation+1, 10))); err != nil {
+		return 0, fmt.Errorf("failed to write generation: %w", err)
+	}
+	return generation + 1, nil
+}
+
+// nextKeyNumberUnsafe takes the next number in the task key sequence. The
+// counter is saved before the task, so a failed create leaves a gap rather
+// than a number that could be given out twice. Must be called with the
+// exclusive lock held.
+func (fs *FileStorage) nextKeyNumberUnsafe() (uint64, error) {
+	path := filepath.Join(fs.dataDir, taskKeyFileName)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read task key counter: %w", err)
+	}
+
+	last, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		// A missing or damaged counter carries on from the keys in use
+		tasks, err := fs.listTasksUnsafe()
+		if err != nil {
+			return 0, err
+		}
+		last = lastKeyNumber(tasks)
+	}
+
+	if err := writeFileAtomic(path, []byte(strconv.FormatUint(last+1, 10))); err != nil {
+		return 0, fmt.Errorf("failed to write task key counter: %w", err)
+	}
+	return last + 1, nil
+}
+
+// assignKeysUnsafe gives keys to tasks created before tasks had keys, and
+// adds tasks missing from the key index to it. Must be called with the
+// exclusive lock held.
+func (fs *FileStorage) assignKeysUnsafe() error {
+	tasks, err := fs.listTasksUnsafe()
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := fs.indexKeyUnsafe(task); err != nil {
+			return err
+		}
+	}
+
+	keyless := keylessTasks(tasks)
+	if len(keyless) == 0 {
+		return nil
+	}
+
+	for _, task := range keyless {
+		number, err := fs.nextKeyNumberUnsafe()
+		if err != nil {
+			return err
+		}
+		task.Key = models.CurrentWorkflow().TaskKey(number)
+	}
+	if err := fs.commitUnsafe(keyless, nil); err != nil {
+		return fmt.Errorf("failed to assign task keys: %w", err)
+	}
+	return nil
+}
+
+// lockDir takes the cross-process advisory lock on the data directory and
+// returns a function that releases it. Exclusive holders first replay any
+// pending journal. Each call opens its own handle so
+// concurrent shared holders in this process don't release each other's lock.
+func (fs *FileStorage) lockDir(exclusive bool) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(fs.dataDir, lockFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock data directory: %w", err)
+	}
+
+	unlock := func() {
+		unlockFile(f)
+		f.Close()
+	}
+
+	// Writers finish any operation a crashed process left half done
+	if exclusive {
+		if err := fs.recoverJournalUnsafe(); err != nil {
+			unlock()
+			return nil, err
+		}
+	}
+
+	return unlock, nil
+}
+
+// Internal unsafe methods (must be called with mutex held)
+
+func (fs *FileStorage) getTaskUnsafe(id string) (*models.Task, error) {
+	filePath := filepath.Join(fs.dataDir, "tasks", id+".json")
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read task file: %w", err)
+	}
+
+	var task models.Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	return &task, nil
+}
+
+// keyPath returns the key index entry for key, which holds the ID of the
+// task with that key
+func (fs *FileStorage) keyPath(key string) string {
+	return filepath.Join(fs.dataDir, "keys", key)
+}
+
+// getTaskByKeyUnsafe looks key up in the key index. An entry is written
+// before its task and removed after it, so one whose task is gone or has
+// another key is left over from an interrupted write and ignored.
+func (fs *FileStorage) getTaskByKeyUnsafe(key string) (*models.Task, error) {
+	if parsed, _, ok := models.ParseTaskKey(key); !ok || parsed != key {
+		return nil, fmt.Errorf("task not found: %s", key)
+	}
+
+	id, err := os.ReadFile(fs.keyPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("task not found: %s", key)
+		}
+		return nil, fmt.Errorf("failed to read key index: %w", err)
+	}
+
+	task, err := fs.getTaskUnsafe(string(id))
+	if err != nil || task.Key != key {
+		return nil, fmt.Errorf("task not found: %s", key)
+	}
+	return task, nil
+}
+
+// indexKeyUnsafe adds the task's key to the key index. Keys never change,
+// so the entry is only written the first time the task is saved.
+func (fs *FileStorage) indexKeyUnsafe(task *models.Task) error {
+	if parsed, _, ok := models.ParseTaskKey(task.Key); !ok || parsed != task.Key {
+		return nil
+	}
+	path := fs.keyPath(task.Key)
+	if id, err := os.ReadFile(path); err == nil && string(id) == task.ID {
+		return nil
+	}
+	if err := writeFileAtomic(path, []byte(task.ID)); err != nil {
+		return fmt.Errorf("failed to write key index: %w", err)
+	}
+	return nil
+}
+
+// unindexKeyUnsafe removes the task's key from the key index unless the
+// key now belongs to another task
+func (fs *FileStorage) unindexKeyUnsafe(task *models.Task) error {
+	if parsed, _, ok := models.ParseTaskKey(task.Key); !ok || parsed != task.Key {
+		return nil
+	}
+	path := fs.keyPath(task.Key)
+	if id, err := os.ReadFile(path); err != nil || string(id) != task.ID {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove key index entry: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStorage) saveTaskUnsafe(task *models.Task) error {
+	filePath := filepath.Join(fs.dataDir, "tasks", task.ID+".json")
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	if err := fs.indexKeyUnsafe(task); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filePath, data); err != nil {
+		return fmt.Errorf("failed to write task file: %w", err)
+	}
+
+	return nil
+}
+
+func (fs *FileStorage) deleteTaskUnsafe(id string) error {
+	// Read the task first to find its key; a task already gone or unreadable
+	// leaves nothing in the index that lookups don't ignore
+	task, _ := fs.getTaskUnsafe(id)
+
+	filePath := filepath.Join(fs.dataDir, "tasks", id+".json")
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete task file: %w", err)
+	}
+
+	if task != nil {
+		return fs.unindexKeyUnsafe(task)
+	}
 	return nil
 }
 
@@ -278,3 +1550,352 @@ func (fs *FileStorage) taskExistsUnsafe(id string) bool {
 	_, err := os.Stat(filePath)
 	return err == nil
 }
+
+func (fs *FileStorage) userPath(id string) string {
+	return filepath.Join(fs.dataDir, "users", id+".json")
+}
+
+func (fs *FileStorage) getUserUnsafe(id string) (*models.User, error) {
+	data, err := os.ReadFile(fs.userPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read user file: %w", err)
+	}
+
+	var user models.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	return &user, nil
+}
+
+func (fs *FileStorage) saveUserUnsafe(user *models.User) error {
+	data, err := json.MarshalIndent(user, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.userPath(user.ID), data); err != nil {
+		return fmt.Errorf("failed to write user file: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStorage) userExistsUnsafe(id string) bool {
+	_, err := os.Stat(fs.userPath(id))
+	return err == nil
+}
+
+// labelPath returns the file a label is stored in. Callers check the name is
+// valid first, so it can't escape the labels directory.
+func (fs *FileStorage) labelPath(name string) string {
+	return filepath.Join(fs.dataDir, "labels", name+".json")
+}
+
+func (fs *FileStorage) getLabelUnsafe(name string) (*models.Label, error) {
+	if !models.IsValidLabelName(name) {
+		return nil, fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+	}
+	data, err := os.ReadFile(fs.labelPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read label file: %w", err)
+	}
+
+	var label models.Label
+	if err := json.Unmarshal(data, &label); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal label: %w", err)
+	}
+	return &label, nil
+}
+
+func (fs *FileStorage) saveLabelUnsafe(label *models.Label) error {
+	data, err := json.MarshalIndent(label, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal label: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.labelPath(label.Name), data); err != nil {
+		return fmt.Errorf("failed to write label file: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStorage) labelExistsUnsafe(name string) bool {
+	if !models.IsValidLabelName(name) {
+		return false
+	}
+	_, err := os.Stat(fs.labelPath(name))
+	return err == nil
+}
+
+// workLogPath returns the file a work log is stored in. Callers check the ID
+// is a UUID first, so it can't escape the worklogs directory.
+func (fs *FileStorage) workLogPath(id string) string {
+	return filepath.Join(fs.dataDir, "worklogs", id+".json")
+}
+
+func (fs *FileStorage) getWorkLogUnsafe(id string) (*models.WorkLog, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkLogNotFound, id)
+	}
+	data, err := os.ReadFile(fs.workLogPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrWorkLogNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read work log file: %w", err)
+	}
+
+	var log models.WorkLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal work log: %w", err)
+	}
+	return &log, nil
+}
+
+func (fs *FileStorage) saveWorkLogUnsafe(log *models.WorkLog) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal work log: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.workLogPath(log.ID), data); err != nil {
+		return fmt.Errorf("failed to write work log file: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStorage) listWorkLogsUnsafe() ([]*models.WorkLog, error) {
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "worklogs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read worklogs directory: %w", err)
+	}
+
+	logs := []*models.WorkLog{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			log, err := fs.getWorkLogUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				logs = append(logs, log)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// commentPath returns the file a comment is stored in. Callers check the ID
+// is a UUID first, so it can't escape the comments directory.
+func (fs *FileStorage) commentPath(id string) string {
+	return filepath.Join(fs.dataDir, "comments", id+".json")
+}
+
+func (fs *FileStorage) getCommentUnsafe(id string) (*models.Comment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	data, err := os.ReadFile(fs.commentPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read comment file: %w", err)
+	}
+
+	var comment models.Comment
+	if err := json.Unmarshal(data, &comment); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal comment: %w", err)
+	}
+	return &comment, nil
+}
+
+func (fs *FileStorage) saveCommentUnsafe(comment *models.Comment) error {
+	data, err := json.MarshalIndent(comment, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.commentPath(comment.ID), data); err != nil {
+		return fmt.Errorf("failed to write comment file: %w", err)
+	}
+	return nil
+}
+
+// listCommentsUnsafe returns the comments on a task in no particular order
+func (fs *FileStorage) listCommentsUnsafe(taskID string) ([]*models.Comment, error) {
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "comments"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments directory: %w", err)
+	}
+
+	comments := []*models.Comment{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			comment, err := fs.getCommentUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil && comment.TaskID == taskID {
+				comments = append(comments, comment)
+			}
+		}
+	}
+	return comments, nil
+}
+
+// sprintPath returns the file a sprint is stored in. Callers check the ID is
+// a UUID first, so it can't escape the sprints directory.
+func (fs *FileStorage) sprintPath(id string) string {
+	return filepath.Join(fs.dataDir, "sprints", id+".json")
+}
+
+func (fs *FileStorage) getSprintUnsafe(id string) (*models.Sprint, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSprintNotFound, id)
+	}
+	data, err := os.ReadFile(fs.sprintPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrSprintNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read sprint file: %w", err)
+	}
+
+	var sprint models.Sprint
+	if err := json.Unmarshal(data, &sprint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sprint: %w", err)
+	}
+	return &sprint, nil
+}
+
+func (fs *FileStorage) saveSprintUnsafe(sprint *models.Sprint) error {
+	data, err := json.MarshalIndent(sprint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sprint: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.sprintPath(sprint.ID), data); err != nil {
+		return fmt.Errorf("failed to write sprint file: %w", err)
+	}
+	return nil
+}
+
+// listSprintsUnsafe returns every sprint ordered by start date
+func (fs *FileStorage) listSprintsUnsafe() ([]*models.Sprint, error) {
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "sprints"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sprints directory: %w", err)
+	}
+
+	sprints := []*models.Sprint{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			sprint, err := fs.getSprintUnsafe(strings.TrimSuffix(entry.Name(), ".json"))
+			if err == nil {
+				sprints = append(sprints, sprint)
+			}
+		}
+	}
+
+	sortSprints(sprints)
+	return sprints, nil
+}
+
+// milestonePath returns the file a milestone is stored in. Callers check the
+// ID is a UUID first, so it can't escape the milestones directory.
+func (fs *FileStorage) milestonePath(id string) string {
+	return filepath.Join(fs.dataDir, "milestones", id+".json")
+}
+
+func (fs *FileStorage) getMilestoneUnsafe(id string) (*models.Milestone, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMilestoneNotFound, id)
+	}
+	data, err := os.ReadFile(fs.milestonePath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrMilestoneNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read milestone file: %w", err)
+	}
+
+	var milestone models.Milestone
+	if err := json.Unmarshal(data, &milestone); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal milestone: %w", err)
+	}
+	return &milestone, nil
+}
+
+func (fs *FileStorage) saveMilestoneUnsafe(milestone *models.Milestone) error {
+	data, err := json.MarshalIndent(milestone, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal milestone: %w", err)
+	}
+
+	if err := writeFileAtomic(fs.milestonePath(milestone.ID), data); err != nil {
+		return fmt.Errorf("failed to write milestone file: %w", err)
+	}
+	return nil
+}
+
+// historyPath returns the file a task's events are appended to, one JSON
+// document per line. Callers check the task ID is a UUID first, so it can't
+// escape the history directory.
+func (fs *FileStorage) historyPath(taskID string) string {
+	return filepath.Join(fs.dataDir, "history", taskID+".jsonl")
+}
+
+// readHistoryUnsafe returns a task's events in the order they were appended.
+// A line cut short by a crash while it was appended is skipped.
+func (fs *FileStorage) readHistoryUnsafe(taskID string) ([]*models.TaskEvent, error) {
+	data, err := os.ReadFile(fs.historyPath(taskID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var events []*models.TaskEvent
+	seen := make(map[string]bool)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var event models.TaskEvent
+		if len(line) == 0 || json.Unmarshal(line, &event) != nil {
+			continue
+		}
+		// A journal replayed after a crash may append an event again
+		if seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+// listEventsUnsafe returns the events of every task, each task's in the
+// order they were appended
+func (fs *FileStorage) listEventsUnsafe() ([]*models.TaskEvent, error) {
+	entries, err := os.ReadDir(filepath.Join(fs.dataDir, "history"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var events []*models.TaskEvent
+	for _, entry := range entries {
+		taskID := strings.TrimSuffix(entry.Name(), ".jsonl")
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
+			continue
+		}
+		if _, err := uuid.Parse(taskID); err != nil {
+			continue
+		}
+		history, err := fs.readHistoryUnsafe(taskID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, history...)
+	}
+	return events, nil
+}
@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"testing"
 
 	"github.com/aykay76/projectflow/internal/models"
@@ -221,13 +226,6 @@ func TestFileStorage_GetTaskHierarchy(t *testing.T) {
 		t.Fatalf("Setup failed: %v", err)
 	}
 
-	// Update parent to include child
-	parentTask.AddChild(childTask.ID)
-	err = storage.UpdateTask(parentTask)
-	if err != nil {
-		t.Fatalf("Setup failed: %v", err)
-	}
-
 	// Test hierarchy
 	hierarchy, err := storage.GetTaskHierarchy()
 	if err != nil {
@@ -255,3 +253,133 @@ func TestFileStorage_GetTaskHierarchy(t *testing.T) {
 		t.Errorf("GetTaskHierarchy() child task ID = %v, want %v", child.ID, childTask.ID)
 	}
 }
+
+// Environment variables used to run the test binary as a storage worker
+const (
+	workerDirEnv    = "PROJECTFLOW_TEST_WORKER_DIR"
+	workerParentEnv = "PROJECTFLOW_TEST_WORKER_PARENT"
+	workerCountEnv  = "PROJECTFLOW_TEST_WORKER_COUNT"
+)
+
+// TestFileStorage_MultiProcessWorker is not a real test. It is run in a child
+// process by TestFileStorage_MultiProcess to write to a shared data directory.
+func TestFileStorage_MultiProcessWorker(t *testing.T) {
+	dataDir := os.Getenv(workerDirEnv)
+	if dataDir == "" {
+		t.Skip("only runs as a child process")
+	}
+	parentID := os.Getenv(workerParentEnv)
+	count, _ := strconv.Atoi(os.Getenv(workerCountEnv))
+
+	storage, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	for i := 0; i < count; i++ {
+		child := models.NewTask(fmt.Sprintf("Child %d of %d", i, os.Getpid()), "")
+		child.Type = models.TypeSubtask
+		if i%2 == 0 {
+			// Let storage link the child
+			child.ParentID = parentID
+			if err := storage.CreateTask(child); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			continue
+		}
+
+		// Move an existing root task under the parent, then try to save a
+		// stale copy of the parent that predates the move
+		staleParent, err := storage.GetTask(parentID)
+		if err != nil {
+			t.Fatalf("GetTask() error = %v", err)
+		}
+		if err := storage.CreateTask(child); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+		child.ParentID = parentID
+		if err := storage.UpdateTask(child); err != nil {
+			t.Fatalf("UpdateTask() error = %v", err)
+		}
+		staleParent.Description = fmt.Sprintf("Last touched by %d", os.Getpid())
+		if err := storage.UpdateTask(staleParent); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("UpdateTask() with a stale parent error = %v, want ErrVersionConflict", err)
+		}
+	}
+}
+
+func TestFileStorage_MultiProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("advisory file locking is not supported on this platform")
+	}
+	if os.Getenv(workerDirEnv) != "" {
+		t.Skip("already running as a child process")
+	}
+
+	tempDir := t.TempDir()
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	parent := models.NewTask("Shared Parent", "")
+	if err := storage.CreateTask(parent); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	const workers = 3
+	const perWorker = 40
+
+	cmds := make([]*exec.Cmd, workers)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestFileStorage_MultiProcessWorker$")
+		cmd.Env = append(os.Environ(),
+			workerDirEnv+"="+tempDir,
+			workerParentEnv+"="+parent.ID,
+			workerCountEnv+"="+strconv.Itoa(perWorker),
+		)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("Failed to start worker: %v", err)
+		}
+		cmds[i] = cmd
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("Worker failed: %v", err)
+		}
+	}
+
+	updatedParent, err := storage.GetTask(parent.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if len(updatedParent.Children) != workers*perWorker {
+		t.Errorf("parent has %d children, want %d", len(updatedParent.Children), workers*perWorker)
+	}
+
+	children, err := storage.GetTaskChildren(parent.ID)
+	if err != nil {
+		t.Fatalf("GetTaskChildren() error = %v", err)
+	}
+	for _, child := range children {
+		if child.ParentID != parent.ID {
+			t.Errorf("child %s has ParentID %q, want %q", child.ID, child.ParentID, parent.ID)
+		}
+	}
+
+	// Every task got its own key from the shared sequence
+	tasks, err := storage.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	keys := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		if keys[task.Key] {
+			t.Errorf("key %s was given out twice", task.Key)
+		}
+		keys[task.Key] = true
+	}
+	if last := lastKeyNumber(tasks); last != uint64(len(tasks)) {
+		t.Errorf("last key number = %d, want %d", last, len(tasks))
+	}
+}
@@ -0,0 +1,552 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// quarantineDirName is the directory inside the data directory that receives
+// task data fsck could not parse
+const quarantineDirName = "quarantine"
+
+// FsckProblemKind identifies a class of integrity problem found by fsck
+type FsckProblemKind string
+
+const (
+	FsckUnparseable    FsckProblemKind = "unparseable_json"
+	FsckInvalidEnum    FsckProblemKind = "invalid_enum"
+	FsckMissingParent  FsckProblemKind = "missing_parent"
+	FsckCycle          FsckProblemKind = "cycle"
+	FsckDanglingChild  FsckProblemKind = "dangling_child"
+	FsckAsymmetricLink FsckProblemKind = "asymmetric_link"
+	FsckDanglingLink   FsckProblemKind = "dangling_link"
+	FsckPendingJournal FsckProblemKind = "pending_journal"
+)
+
+// FsckProblem describes one integrity problem and the repair that fixes it
+type FsckProblem struct {
+	Kind   FsckProblemKind `json:"kind"`
+	TaskID string          `json:"task_id"`
+	Detail string          `json:"detail"`
+	Repair string          `json:"repair"`
+}
+
+// FsckReport is the result of an integrity check. When Repaired is true every
+// problem's Repair has been applied; otherwise it describes what a repair run
+// would do.
+type FsckReport struct {
+	CheckedAt    time.Time     `json:"checked_at"`
+	TasksScanned int           `json:"tasks_scanned"`
+	Problems     []FsckProblem `json:"problems"`
+	Repaired     bool          `json:"repaired"`
+}
+
+// Checker is implemented by storage backends that can verify, and optionally
+// repair, the integrity of their own data
+type Checker interface {
+	Fsck(repair bool) (*FsckReport, error)
+}
+
+// CheckReadOnly checks the storage in dataDir without changing it. Unlike
+// opening it with NewStorage, a pending journal isn't replayed, temporary
+// files are left in place, tasks aren't given keys and the database schema
+// isn't migrated.
+func CheckReadOnly(backend, dataDir string) (*FsckReport, error) {
+	switch backend {
+	case "", BackendFile:
+		return checkFilesReadOnly(dataDir)
+	case BackendSQLite:
+		return checkSQLiteReadOnly(dataDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
+
+// checkFilesReadOnly is CheckReadOnly for the file backend
+func checkFilesReadOnly(dataDir string) (*FsckReport, error) {
+	fs := &FileStorage{fileState: &fileState{dataDir: dataDir}}
+
+	// Share the lock with other readers, unless no process has written here
+	// to create the lock file
+	f, err := os.Open(filepath.Join(dataDir, lockFileName))
+	if err == nil {
+		defer f.Close()
+		if err := lockFile(f, false); err != nil {
+			return nil, fmt.Errorf("failed to lock data directory: %w", err)
+		}
+		defer unlockFile(f)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	report, err := fs.fsckUnsafe(false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tasks were read as they are on disk, before the interrupted
+	// operation in the journal is finished
+	if _, err := os.Stat(filepath.Join(dataDir, journalFileName)); err == nil {
+		report.Problems = append(report.Problems, FsckProblem{
+			Kind:   FsckPendingJournal,
+			Detail: "an interrupted write has not been finished",
+			Repair: "replayed the journal",
+		})
+	}
+	return report, nil
+}
+
+// checkSQLiteReadOnly is CheckReadOnly for the SQLite backend
+func checkSQLiteReadOnly(dataDir string) (*FsckReport, error) {
+	path := filepath.Join(dataDir, sqliteFileName)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Opening a WAL database creates its -wal and -shm files when no other
+	// process has it open, even read-only. Without a -wal file everything is
+	// in the database file, so it can be read as immutable instead.
+	params := "mode=ro&_pragma=busy_timeout(5000)"
+	if _, err := os.Stat(path + "-wal"); os.IsNotExist(err) {
+		params = "mode=ro&immutable=1"
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ss := &SQLiteStorage{sqliteState: &sqliteState{dataDir: dataDir, db: db}}
+	return ss.Fsck(false)
+}
+
+// checkTasks finds integrity problems in tasks and fixes them in place. The
+// returned set holds the IDs of tasks that were modified. Problems are found
+// in a fixed order over sorted IDs so repairs are deterministic.
+func checkTasks(tasks map[string]*models.Task) ([]FsckProblem, map[string]bool) {
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var problems []FsckProblem
+	changed := make(map[string]bool)
+
+	// Invalid enum values are reset to the defaults used by NewTask
+	for _, id := range ids {
+		task := tasks[id]
+		if !models.IsValidStatus(string(task.Status)) {
+			problems = append(problems, FsckProblem{
+				Kind:   FsckInvalidEnum,
+				TaskID: id,
+				Detail: fmt.Sprintf("invalid status %q", task.Status),
+				Repair: fmt.Sprintf("set status to %q", models.CurrentWorkflow().Initial),
+			})
+			task.Status = models.CurrentWorkflow().Initial
+			changed[id] = true
+		}
+		if !models.IsValidPriority(string(task.Priority)) {
+			problems = append(problems, FsckProblem{
+				Kind:   FsckInvalidEnum,
+				TaskID: id,
+				Detail: fmt.Sprintf("invalid priority %q", task.Priority),
+				Repair: fmt.Sprintf("set priority to %q", models.PriorityMedium),
+			})
+			task.Priority = models.PriorityMedium
+			changed[id] = true
+		}
+		if !models.IsValidType(string(task.Type)) {
+			problems = append(problems, FsckProblem{
+				Kind:   FsckInvalidEnum,
+				TaskID: id,
+				Detail: fmt.Sprintf("invalid type %q", task.Type),
+				Repair: fmt.Sprintf("set type to %q", models.TypeTask),
+			})
+			task.Type = models.TypeTask
+			changed[id] = true
+		}
+	}
+
+	// Tasks whose parent no longer exists become root tasks
+	for _, id := range ids {
+		task := tasks[id]
+		if task.ParentID == "" {
+			continue
+		}
+		if _, exists := tasks[task.ParentID]; !exists {
+			problems = append(problems, FsckProblem{
+				Kind:   FsckMissingParent,
+				TaskID: id,
+				Detail: fmt.Sprintf("parent %s does not exist", task.ParentID),
+				Repair: "made it a root task",
+			})
+			task.ParentID = ""
+			changed[id] = true
+		}
+	}
+
+	// Break each ParentID cycle at its smallest ID, which becomes a root task
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(ids))
+	for _, id := range ids {
+		var path []string
+		current := id
+		for current != "" && state[current] == unvisited {
+			state[current] = visiting
+			path = append(path, current)
+			current = tasks[current].ParentID
+		}
+
+		if current != "" && state[current] == visiting {
+			// current is on this path, so the path from it onwards is a cycle
+			var cycle []string
+			for i, pathID := range path {
+				if pathID == current {
+					cycle = append([]string(nil), path[i:]...)
+					break
+				}
+			}
+			sort.Strings(cycle)
+			breakAt := tasks[cycle[0]]
+			problems = append(problems, FsckProblem{
+				Kind:   FsckCycle,
+				TaskID: breakAt.ID,
+				Detail: fmt.Sprintf("parent links form a cycle: %s", strings.Join(cycle, ", ")),
+				Repair: "made it a root task",
+			})
+			breakAt.ParentID = ""
+			changed[breakAt.ID] = true
+		}
+
+		for _, pathID := range path {
+			state[pathID] = visited
+		}
+	}
+
+	// Children lists must match the children's ParentID. ParentID wins, as it
+	// does when storage moves a task.
+	for _, id := range ids {
+		task := tasks[id]
+		listed := make(map[string]bool, len(task.Children))
+		children := make([]string, 0, len(task.Children))
+		for _, childID := range task.Children {
+			child, exists := tasks[childID]
+			switch {
+			case !exists:
+				problems = append(problems, FsckProblem{
+					Kind:   FsckDanglingChild,
+					TaskID: id,
+					Detail: fmt.Sprintf("lists child %s which does not exist", childID),
+					Repair: fmt.Sprintf("removed %s from children", childID),
+				})
+			case listed[childID]:
+				problems = append(problems, FsckProblem{
+					Kind:   FsckAsymmetricLink,
+					TaskID: id,
+					Detail: fmt.Sprintf("lists child %s more than once", childID),
+					Repair: "removed the duplicate entry",
+				})
+			case child.ParentID != id:
+				problems = append(problems, FsckProblem{
+					Kind:   FsckAsymmetricLink,
+					TaskID: id,
+					Detail: fmt.Sprintf("lists child %s whose parent is %q", childID, child.ParentID),
+					Repair: fmt.Sprintf("removed %s from children", childID),
+				})
+			default:
+				listed[childID] = true
+				children = append(children, childID)
+				continue
+			}
+			changed[id] = true
+		}
+		task.Children = children
+	}
+
+	for _, id := range ids {
+		task := tasks[id]
+		if task.ParentID == "" {
+			continue
+		}
+		parent := tasks[task.ParentID]
+		found := false
+		for _, childID := range parent.Children {
+			if childID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, FsckProblem{
+				Kind:   FsckAsymmetricLink,
+				TaskID: id,
+				Detail: fmt.Sprintf("parent %s does not list it as a child", parent.ID),
+				Repair: fmt.Sprintf("added it to the children of %s", parent.ID),
+			})
+			parent.Children = append(parent.Children, id)
+			changed[parent.ID] = true
+		}
+	}
+
+	// Links to missing tasks or of unknown types are dropped, and a link
+	// missing its inverse on the other task gets one
+	for _, id := range ids {
+		task := tasks[id]
+		links := make([]models.TaskLink, 0, len(task.Links))
+		for _, link := range task.Links {
+			_, exists := tasks[link.TaskID]
+			switch {
+			case !models.IsValidLinkType(string(link.Type)):
+				problems = append(problems, FsckProblem{
+					Kind:   FsckInvalidEnum,
+					TaskID: id,
+					Detail: fmt.Sprintf("link to %s has invalid type %q", link.TaskID, link.Type),
+					Repair: "removed the link",
+				})
+			case !exists || link.TaskID == id:
+				problems = append(problems, FsckProblem{
+					Kind:   FsckDanglingLink,
+					TaskID: id,
+					Detail: fmt.Sprintf("%s link to %s which does not exist", link.Type, link.TaskID),
+					Repair: "removed the link",
+				})
+			default:
+				links = append(links, link)
+				continue
+			}
+			changed[id] = true
+		}
+		if changed[id] {
+			task.Links = links
+		}
+	}
+
+	for _, id := range ids {
+		for _, link := range tasks[id].Links {
+			other := tasks[link.TaskID]
+			if other.AddLink(link.Type.Inverse(), id) {
+				problems = append(problems, FsckProblem{
+					Kind:   FsckAsymmetricLink,
+					TaskID: id,
+					Detail: fmt.Sprintf("%s link to %s has no %s link back", link.Type, link.TaskID, link.Type.Inverse()),
+					Repair: fmt.Sprintf("added the %s link to %s", link.Type.Inverse(), link.TaskID),
+				})
+				changed[other.ID] = true
+			}
+		}
+	}
+
+	return problems, changed
+}
+
+// sortedChanged returns the changed tasks ordered by ID
+func sortedChanged(tasks map[string]*models.Task, changed map[string]bool) []*models.Task {
+	ids := make([]string, 0, len(changed))
+	for id := range changed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	writes := make([]*models.Task, 0, len(ids))
+	for _, id := range ids {
+		writes = append(writes, tasks[id])
+	}
+	return writes
+}
+
+// Fsck checks the task files for integrity problems. With repair set the
+// problems are fixed: task changes are committed through the journal and
+// unparseable files are moved to the quarantine directory.
+func (fs *FileStorage) Fsck(repair bool) (*FsckReport, error) {
+	if repair {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+	} else {
+		fs.mu.RLock()
+		defer fs.mu.RUnlock()
+	}
+
+	unlock, err := fs.lockDir(repair)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return fs.fsckUnsafe(repair)
+}
+
+// fsckUnsafe is Fsck for a caller holding the locks
+func (fs *FileStorage) fsckUnsafe(repair bool) (*FsckReport, error) {
+	tasksDir := filepath.Join(fs.dataDir, "tasks")
+	entries, err := os.ReadDir(tasksDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks directory: %w", err)
+	}
+
+	report := &FsckReport{
+		CheckedAt: time.Now(),
+		Problems:  []FsckProblem{},
+		Repaired:  repair,
+	}
+	tasks := make(map[string]*models.Task)
+	var unparseable []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		report.TasksScanned++
+		taskID := strings.TrimSuffix(entry.Name(), ".json")
+
+		task, err := fs.getTaskUnsafe(taskID)
+		if err != nil {
+			report.Problems = append(report.Problems, FsckProblem{
+				Kind:   FsckUnparseable,
+				TaskID: taskID,
+				Detail: err.Error(),
+				Repair: fmt.Sprintf("moved file to %s/", quarantineDirName),
+			})
+			unparseable = append(unparseable, entry.Name())
+			continue
+		}
+		tasks[taskID] = task
+	}
+
+	problems, changed := checkTasks(tasks)
+	report.Problems = append(report.Problems, problems...)
+
+	if !repair {
+		return report, nil
+	}
+
+	if len(unparseable) > 0 {
+		quarantineDir := filepath.Join(fs.dataDir, quarantineDirName)
+		if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create quarantine directory: %w", err)
+		}
+		for _, name := range unparseable {
+			if err := os.Rename(filepath.Join(tasksDir, name), filepath.Join(quarantineDir, name)); err != nil {
+				return nil, fmt.Errorf("failed to quarantine %s: %w", name, err)
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		repairs := sortedChanged(tasks, changed)
+		bumpVersions(repairs)
+		if err := fs.commitUnsafe(repairs, nil); err != nil {
+			return nil, fmt.Errorf("failed to write repairs: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// Fsck checks the database for integrity problems. With repair set the
+// problems are fixed in one transaction; rows whose JSON cannot be parsed are
+// copied to the quarantine directory and deleted.
+func (ss *SQLiteStorage) Fsck(repair bool) (*FsckReport, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	report := &FsckReport{
+		CheckedAt: time.Now(),
+		Problems:  []FsckProblem{},
+		Repaired:  repair,
+	}
+
+	err := ss.withTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT id, data FROM tasks ORDER BY id`)
+		if err != nil {
+			return fmt.Errorf("failed to query tasks: %w", err)
+		}
+
+		tasks := make(map[string]*models.Task)
+		unparseable := make(map[string]string)
+		for rows.Next() {
+			var id, data string
+			if err := rows.Scan(&id, &data); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan task: %w", err)
+			}
+			report.TasksScanned++
+
+			var task models.Task
+			if err := json.Unmarshal([]byte(data), &task); err != nil {
+				report.Problems = append(report.Problems, FsckProblem{
+					Kind:   FsckUnparseable,
+					TaskID: id,
+					Detail: fmt.Sprintf("failed to unmarshal task: %v", err),
+					Repair: fmt.Sprintf("moved row to %s/%s.json", quarantineDirName, id),
+				})
+				unparseable[id] = data
+				continue
+			}
+			task.ID = id
+			tasks[id] = &task
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if err := loadChildrenTx(tx, task); err != nil {
+				return err
+			}
+		}
+
+		problems, changed := checkTasks(tasks)
+		report.Problems = append(report.Problems, problems...)
+
+		if !repair || len(unparseable)+len(changed) == 0 {
+			return nil
+		}
+
+		if _, err := bumpGenerationTx(tx); err != nil {
+			return err
+		}
+
+		if len(unparseable) > 0 {
+			quarantineDir := filepath.Join(ss.dataDir, quarantineDirName)
+			if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+				return fmt.Errorf("failed to create quarantine directory: %w", err)
+			}
+			for id, data := range unparseable {
+				if err := os.WriteFile(filepath.Join(quarantineDir, id+".json"), []byte(data), 0644); err != nil {
+					return fmt.Errorf("failed to quarantine %s: %w", id, err)
+				}
+				if err := deleteTaskTx(tx, id); err != nil {
+					return err
+				}
+			}
+		}
+
+		repairs := sortedChanged(tasks, changed)
+		bumpVersions(repairs)
+		for _, task := range repairs {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// writeRawTask writes a task file directly, bypassing the storage's checks
+func writeRawTask(t *testing.T, dataDir string, task *models.Task) {
+	t.Helper()
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("Failed to marshal task: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "tasks", task.ID+".json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write task: %v", err)
+	}
+}
+
+func rawTask(id, parentID string, children ...string) *models.Task {
+	task := models.NewTask(id, "")
+	task.ID = id
+	task.ParentID = parentID
+	task.Children = append([]string{}, children...)
+	return task
+}
+
+func countKinds(problems []FsckProblem) map[FsckProblemKind]int {
+	counts := make(map[FsckProblemKind]int)
+	for _, problem := range problems {
+		counts[problem.Kind]++
+	}
+	return counts
+}
+
+func TestFileStorage_Fsck(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	// epic lists a deleted child and a child that points elsewhere
+	writeRawTask(t, tempDir, rawTask("epic", "", "gone", "story-b"))
+	// story-a points at epic but epic doesn't list it, blocks a missing task
+	// and relates to bad-enum without a link back
+	storyA := rawTask("story-a", "epic")
+	storyA.AddLink(models.LinkBlocks, "gone")
+	storyA.AddLink(models.LinkRelatesTo, "bad-enum")
+	writeRawTask(t, tempDir, storyA)
+	// story-b is listed by epic but claims a missing parent
+	writeRawTask(t, tempDir, rawTask("story-b", "missing"))
+	// loop-1 and loop-2 are each other's parent
+	writeRawTask(t, tempDir, rawTask("loop-1", "loop-2", "loop-2"))
+	writeRawTask(t, tempDir, rawTask("loop-2", "loop-1", "loop-1"))
+	// bad-enum has an unknown status
+	badEnum := rawTask("bad-enum", "")
+	badEnum.Status = "finished"
+	writeRawTask(t, tempDir, badEnum)
+	// broken is a truncated file
+	if err := os.WriteFile(filepath.Join(tempDir, "tasks", "broken.json"), []byte(`{"id": "bro`), 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	report, err := storage.Fsck(false)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+
+	counts := countKinds(report.Problems)
+	want := map[FsckProblemKind]int{
+		FsckUnparseable:    1,
+		FsckInvalidEnum:    1,
+		FsckMissingParent:  1,
+		FsckCycle:          1,
+		FsckDanglingChild:  1,
+		FsckAsymmetricLink: 4,
+		FsckDanglingLink:   1,
+	}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("Fsck() found %d %s problems, want %d", counts[kind], kind, n)
+		}
+	}
+	if report.TasksScanned != 7 {
+		t.Errorf("Fsck() scanned %d tasks, want 7", report.TasksScanned)
+	}
+
+	// A check-only run must not change anything
+	if _, err := os.Stat(filepath.Join(tempDir, "tasks", "broken.json")); err != nil {
+		t.Error("Fsck(false) should leave unparseable files in place")
+	}
+
+	repaired, err := storage.Fsck(true)
+	if err != nil {
+		t.Fatalf("Fsck(true) error = %v", err)
+	}
+	if len(repaired.Problems) != len(report.Problems) {
+		t.Errorf("repair run found %d problems, check run found %d", len(repaired.Problems), len(report.Problems))
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, quarantineDirName, "broken.json")); err != nil {
+		t.Error("Fsck(true) should move unparseable files to quarantine")
+	}
+
+	epic, err := storage.GetTask("epic")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if !equalIDs(epic.Children, []string{"story-a"}) {
+		t.Errorf("epic children = %v, want [story-a]", epic.Children)
+	}
+
+	badEnumTask, err := storage.GetTask("bad-enum")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if !badEnumTask.HasLink(models.LinkRelatesTo, "story-a") {
+		t.Errorf("bad-enum links = %v, want the missing relates_to link added", badEnumTask.Links)
+	}
+
+	loop1, err := storage.GetTask("loop-1")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if loop1.ParentID != "" {
+		t.Errorf("loop-1 ParentID = %q, want the cycle broken at loop-1", loop1.ParentID)
+	}
+
+	clean, err := storage.Fsck(false)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+	if len(clean.Problems) != 0 {
+		t.Errorf("Fsck() after repair found %v, want none", clean.Problems)
+	}
+}
+
+func TestSQLiteStorage_Fsck(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	parent := models.NewTask("Parent", "")
+	if err := storage.CreateTask(parent); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	child := models.NewTask("Child", "")
+	child.Type = models.TypeSubtask
+	child.ParentID = parent.ID
+	if err := storage.CreateTask(child); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Break the child's back-link and corrupt a row behind the storage's back
+	if _, err := storage.db.Exec(`DELETE FROM task_edges WHERE child_id = ?`, child.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := storage.db.Exec(`INSERT INTO tasks (id, status, priority, type, created_at, updated_at, data)
+		VALUES ('broken', 'todo', 'medium', 'task', '', '', '{"id":')`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	report, err := storage.Fsck(true)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+	counts := countKinds(report.Problems)
+	if counts[FsckUnparseable] != 1 || counts[FsckAsymmetricLink] != 1 {
+		t.Errorf("Fsck() problems = %v, want one unparseable and one asymmetric", report.Problems)
+	}
+
+	repairedParent, err := storage.GetTask(parent.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if !equalIDs(repairedParent.Children, []string{child.ID}) {
+		t.Errorf("parent children = %v, want [%s]", repairedParent.Children, child.ID)
+	}
+	if storage.TaskExists("broken") {
+		t.Error("Fsck(true) should remove unparseable rows")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package storage
+
+import (
+	"os"
+)
+
+// lockFile is a no-op on platforms without flock; only the in-process
+// mutex protects the data directory there
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without flock
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// syncDir is a no-op on platforms that cannot fsync a directory
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// buildHierarchy organises a flat task list into root tasks (no parent) with
+// their children nested beneath them
+func buildHierarchy(allTasks []*models.Task) []*models.HierarchyTask {
+	// Create a map for quick lookup
+	taskMap := make(map[string]*models.Task)
+	for _, task := range allTasks {
+		taskMap[task.ID] = task
+	}
+
+	// Build the hierarchy by finding root tasks (no parent) and recursively building children
+	var rootTasks []*models.HierarchyTask
+	for _, task := range allTasks {
+		if task.ParentID == "" {
+			hierarchyTask := buildHierarchyTask(task, taskMap)
+			rootTasks = append(rootTasks, hierarchyTask)
+		}
+	}
+
+	return rootTasks
+}
+
+// buildHierarchyTask recursively builds a HierarchyTask with its children
+func buildHierarchyTask(task *models.Task, taskMap map[string]*models.Task) *models.HierarchyTask {
+	hierarchyTask := &models.HierarchyTask{
+		Task:       task,
+		ChildTasks: []*models.HierarchyTask{},
+	}
+
+	// Recursively build children
+	for _, childID := range task.Children {
+		if childTask, exists := taskMap[childID]; exists {
+			childHierarchyTask := buildHierarchyTask(childTask, taskMap)
+			hierarchyTask.ChildTasks = append(hierarchyTask.ChildTasks, childHierarchyTask)
+		}
+	}
+
+	// Children are rolled up first, so totals accumulate towards the root
+	hierarchyTask.RollUp()
+	return hierarchyTask
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestGetTaskHierarchy_RollsUpEstimates(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			estimate := func(id string, status models.TaskStatus, points float64, original time.Duration) {
+				task, err := store.GetTask(id)
+				if err != nil {
+					t.Fatalf("GetTask() error = %v", err)
+				}
+				task.Status = status
+				task.StoryPoints = points
+				task.OriginalEstimate = models.Estimate(original)
+				if err := store.UpdateTask(task); err != nil {
+					t.Fatalf("UpdateTask() error = %v", err)
+				}
+			}
+			estimate(f.grandchild.ID, models.StatusInProgress, 2, 3*time.Hour)
+			estimate(f.sibling.ID, models.StatusInProgress, 5, 8*time.Hour)
+			estimate(f.sibling.ID, models.StatusDone, 5, 8*time.Hour)
+
+			hierarchy, err := store.GetTaskHierarchy()
+			if err != nil {
+				t.Fatalf("GetTaskHierarchy() error = %v", err)
+			}
+			if len(hierarchy) != 1 {
+				t.Fatalf("GetTaskHierarchy() returned %d roots, want 1", len(hierarchy))
+			}
+
+			want := models.Rollup{
+				TotalPoints:       7,
+				CompletedPoints:   5,
+				RemainingPoints:   2,
+				OriginalEstimate:  models.Estimate(11 * time.Hour),
+				RemainingEstimate: models.Estimate(3 * time.Hour),
+			}
+			if got := hierarchy[0].Rollup; got != want {
+				t.Errorf("epic rollup = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
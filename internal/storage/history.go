@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/google/uuid"
+)
+
+// EventQuery selects task events. Empty fields match every event.
+type EventQuery struct {
+	TaskID  string
+	ActorID string
+	Source  models.EventSource
+	// Since selects events at or after a time
+	Since time.Time
+	// Limit caps the number of events returned, newest first. Zero returns
+	// every match.
+	Limit int
+}
+
+// ParseEventQuery builds an EventQuery from named string parameters, like
+// ParseTaskQuery: task_id, actor_id, source, since (RFC 3339 or YYYY-MM-DD)
+// and limit
+func ParseEventQuery(get func(name string) (string, bool)) (EventQuery, error) {
+	var query EventQuery
+	query.TaskID, _ = get("task_id")
+	query.ActorID, _ = get("actor_id")
+
+	if value, ok := get("source"); ok && value != "" {
+		query.Source = models.EventSource(value)
+		if !models.IsValidEventSource(query.Source) {
+			return query, fmt.Errorf("invalid source: %s", value)
+		}
+	}
+	since, err := parseTimeParam(get, "since")
+	if err != nil {
+		return query, err
+	}
+	if since != nil {
+		query.Since = *since
+	}
+	if value, ok := get("limit"); ok && value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return query, fmt.Errorf("invalid limit: %s", value)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+// matches reports whether event is selected by q
+func (q EventQuery) matches(event *models.TaskEvent) bool {
+	if q.TaskID != "" && event.TaskID != q.TaskID {
+		return false
+	}
+	if q.ActorID != "" && event.ActorID != q.ActorID {
+		return false
+	}
+	if q.Source != "" && event.Source != q.Source {
+		return false
+	}
+	if !q.Since.IsZero() && event.CreatedAt.Before(q.Since) {
+		return false
+	}
+	return true
+}
+
+// prepareEvents checks events before they are appended and assigns their IDs
+// and times
+func prepareEvents(events []*models.TaskEvent) error {
+	now := time.Now()
+	for _, event := range events {
+		if _, err := uuid.Parse(event.TaskID); err != nil {
+			return fmt.Errorf("%w: task ID %q", ErrInvalidEvent, event.TaskID)
+		}
+		if !models.IsValidEventSource(event.Source) {
+			return fmt.Errorf("%w: source %q", ErrInvalidEvent, event.Source)
+		}
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = now
+		}
+	}
+	return nil
+}
+
+// selectEvents returns the events matching query, newest first. events must
+// be in the order they were appended.
+func selectEvents(events []*models.TaskEvent, query EventQuery) []*models.TaskEvent {
+	matched := []*models.TaskEvent{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if query.matches(events[i]) {
+			matched = append(matched, events[i])
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+	return matched
+}
+
+// Actor identifies who is making changes and through which interface
+type Actor struct {
+	UserID string
+	Source models.EventSource
+}
+
+// ActorStorage is a Storage that records the changes made through it in the
+// task history. WithActor returns a view of the store whose changes are
+// recorded as made by actor; every task a change writes or deletes gets an
+// event, appended in the same commit as the change.
+type ActorStorage interface {
+	Storage
+	WithActor(actor Actor) Storage
+}
+
+// WithActor returns store with its changes recorded as made by actor. A view
+// returned earlier has its actor replaced, and a store that can't record
+// history is returned as it is.
+func WithActor(store Storage, actor Actor) Storage {
+	if recorder, ok := store.(ActorStorage); ok {
+		return recorder.WithActor(actor)
+	}
+	return store
+}
+
+// taskChange is a task as it was before a commit and as the commit left it.
+// before is nil for a created task and after for a deleted one.
+type taskChange struct {
+	before *models.Task
+	after  *models.Task
+}
+
+// events returns an event for each of changes that altered its task, made by
+// actor, with IDs and times assigned. A nil actor records nothing.
+func (actor *Actor) events(changes []taskChange) ([]*models.TaskEvent, error) {
+	if actor == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var events []*models.TaskEvent
+	for _, change := range changes {
+		if change.before == nil && change.after == nil {
+			continue
+		}
+		if event := models.NewTaskEvent(change.before, change.after, actor.UserID, actor.Source, now); event != nil {
+			events = append(events, event)
+		}
+	}
+	if err := prepareEvents(events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestTaskHistory(t *testing.T) {
+	for name, backend := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := WithActor(backend, Actor{UserID: "alice", Source: models.SourceAPI})
+
+			epic, story, other := models.NewTask("Epic", ""), models.NewTask("Story", ""), models.NewTask("Other", "")
+			epic.Type, story.Type = models.TypeEpic, models.TypeStory
+			for _, task := range []*models.Task{epic, story, other} {
+				if err := store.CreateTask(task); err != nil {
+					t.Fatalf("CreateTask() error = %v", err)
+				}
+			}
+
+			story.ParentID = epic.ID
+			story.Status = models.StatusInProgress
+			if err := store.UpdateTask(story); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			// Saving without a change records nothing
+			if err := store.UpdateTask(story); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+
+			// Changes through another actor are attributed to them
+			agent := WithActor(store, Actor{UserID: "bot", Source: models.SourceMCP})
+			if err := agent.LinkTasks(other.ID, models.LinkRelatesTo, story.ID); err != nil {
+				t.Fatalf("LinkTasks() error = %v", err)
+			}
+
+			history, err := store.ListEvents(EventQuery{TaskID: story.ID})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(history) != 3 {
+				t.Fatalf("ListEvents() = %d events, want link, update and create", len(history))
+			}
+			link, update, create := history[0], history[1], history[2]
+			if create.Action != models.ActionCreated || create.ActorID != "alice" || create.Source != models.SourceAPI {
+				t.Errorf("first event = %+v, want created by alice over the API", create)
+			}
+			if update.Action != models.ActionUpdated || len(update.Changes) != 2 ||
+				update.Changes[0].Field != "parent_id" || update.Changes[1].Field != "status" ||
+				string(update.Changes[1].Before) != `"todo"` || string(update.Changes[1].After) != `"in_progress"` {
+				t.Errorf("update event changes = %+v, want parent_id and status todo -> in_progress", update.Changes)
+			}
+			if link.ActorID != "bot" || link.Source != models.SourceMCP || link.Changes[0].Field != "links" {
+				t.Errorf("link event = %+v, want a links change by bot over MCP", link)
+			}
+
+			// Deleting the epic moves the story up and keeps the history
+			if _, err := store.DeleteTaskWithStrategy(epic.ID, DeleteOrphanToRoot, 0); err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+			history, err = store.ListEvents(EventQuery{TaskID: epic.ID})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(history) != 3 || history[0].Action != models.ActionDeleted || history[0].TaskTitle != "Epic" ||
+				history[1].Changes[0].Field != "children" {
+				t.Errorf("epic history = %+v, want its deletion after gaining the story and its creation", history)
+			}
+			moved, err := store.ListEvents(EventQuery{TaskID: story.ID, Limit: 1})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(moved) != 1 || moved[0].Changes[0].Field != "parent_id" || string(moved[0].Changes[0].After) != "null" {
+				t.Errorf("story's latest event = %+v, want it detached from the epic", moved[0].Changes)
+			}
+
+			feed, err := store.ListEvents(EventQuery{})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(feed) != 9 {
+				t.Errorf("ListEvents() feed = %d events, want 9", len(feed))
+			}
+			for i := 1; i < len(feed); i++ {
+				if feed[i].CreatedAt.After(feed[i-1].CreatedAt) {
+					t.Errorf("ListEvents() feed isn't newest first at %d", i)
+				}
+			}
+			byBot, err := store.ListEvents(EventQuery{ActorID: "bot"})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			bySource, err := store.ListEvents(EventQuery{Source: models.SourceMCP})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(byBot) != 2 || len(bySource) != 2 {
+				t.Errorf("ListEvents() by bot = %d, over MCP = %d, want the two link events", len(byBot), len(bySource))
+			}
+			since, err := store.ListEvents(EventQuery{Since: link.CreatedAt})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			if len(since) != 4 {
+				t.Errorf("ListEvents() since the link = %d events, want 4", len(since))
+			}
+
+			invalid := []*models.TaskEvent{{TaskID: "../tasks", Action: models.ActionUpdated, Source: models.SourceAPI}}
+			if err := store.AppendEvents(invalid); !errors.Is(err, ErrInvalidEvent) {
+				t.Errorf("AppendEvents() with a bad task ID error = %v, want ErrInvalidEvent", err)
+			}
+		})
+	}
+}
+
+func TestTaskHistory_EveryTaskWritten(t *testing.T) {
+	for name, backend := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := WithActor(backend, Actor{UserID: "alice", Source: models.SourceAPI})
+			oldParent, newParent := models.NewTask("Old parent", ""), models.NewTask("New parent", "")
+			child, grandchild := models.NewTask("Child", ""), models.NewTask("Grandchild", "")
+			oldParent.Type, newParent.Type, child.Type, grandchild.Type = models.TypeEpic, models.TypeEpic, models.TypeStory, models.TypeTask
+			for _, task := range []*models.Task{oldParent, newParent, child, grandchild} {
+				if err := store.CreateTask(task); err != nil {
+					t.Fatalf("CreateTask() error = %v", err)
+				}
+			}
+
+			// events returns how many events each task has
+			events := func() map[string]int {
+				t.Helper()
+				feed, err := store.ListEvents(EventQuery{})
+				if err != nil {
+					t.Fatalf("ListEvents() error = %v", err)
+				}
+				counts := make(map[string]int)
+				for _, event := range feed {
+					counts[event.TaskID]++
+				}
+				return counts
+			}
+
+			child.ParentID = oldParent.ID
+			if err := store.UpdateTask(child); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			grandchild.ParentID = child.ID
+			if err := store.UpdateTask(grandchild); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			// Creating under a parent changes the parent too
+			extra := models.NewTask("Extra", "")
+			extra.Type, extra.ParentID = models.TypeStory, newParent.ID
+			if err := store.CreateTask(extra); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			if got := events(); got[newParent.ID] != 2 || got[extra.ID] != 1 {
+				t.Errorf("events after creating a child = %v, want the new parent's change recorded", got)
+			}
+
+			// Reparenting changes both parents
+			child, err := store.GetTask(child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			child.ParentID = newParent.ID
+			if err := store.UpdateTask(child); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if got := events(); got[oldParent.ID] != 3 || got[newParent.ID] != 3 || got[child.ID] != 4 {
+				t.Errorf("events after reparenting = %v, want both parents' changes recorded", got)
+			}
+
+			// Deleting with the orphan strategy changes the parent and the
+			// children it detaches
+			if _, err := store.DeleteTaskWithStrategy(child.ID, DeleteOrphanToRoot, 0); err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+			if got := events(); got[child.ID] != 5 || got[newParent.ID] != 4 || got[grandchild.ID] != 3 {
+				t.Errorf("events after deleting = %v, want the parent and detached grandchild recorded", got)
+			}
+		})
+	}
+}
+
+func TestFileStorage_HistoryCutShort(t *testing.T) {
+	store, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	task := createTasks(t, store, "Task")[0]
+
+	// A crash left half a line behind
+	if err := os.WriteFile(store.historyPath(task.ID), []byte(`{"id":"cut`), 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	event := &models.TaskEvent{TaskID: task.ID, Action: models.ActionUpdated, Source: models.SourceWeb}
+	if err := store.AppendEvents([]*models.TaskEvent{event}); err != nil {
+		t.Fatalf("AppendEvents() error = %v", err)
+	}
+
+	events, err := store.ListEvents(EventQuery{TaskID: task.ID})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != event.ID {
+		t.Errorf("ListEvents() = %+v, want only the event appended after the cut line", events)
+	}
+}
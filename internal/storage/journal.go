@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// journalFileName is the write-ahead journal kept in the data directory while
+// a multi-file operation is in flight
+const journalFileName = "journal.json"
+
+// journal records every file change of a multi-file operation before any of
+// them is applied, along with the history events it records. Replaying it is
+// idempotent, so an operation interrupted by a crash is completed the next
+// time the data directory is locked for writing.
+type journal struct {
+	Writes  []*models.Task      `json:"writes,omitempty"`
+	Deletes []string            `json:"deletes,omitempty"`
+	Events  []*models.TaskEvent `json:"events,omitempty"`
+}
+
+// commitUnsafe writes and deletes a set of task files as one unit, recording
+// an event for each task it changes when made through a view with an actor.
+// A single change with no events is applied directly since each file write is
+// already atomic; anything more goes through the journal. An empty set
+// changes nothing, so caches are left alone. Must be called with the
+// exclusive lock held.
+func (fs *FileStorage) commitUnsafe(writes []*models.Task, deletes []string) error {
+	if len(writes) == 0 && len(deletes) == 0 {
+		return nil
+	}
+
+	events, err := fs.actor.events(fs.changesUnsafe(writes, deletes))
+	if err != nil {
+		return err
+	}
+
+	// Bump the generation first so a crash mid-commit still tells caches to reload
+	generation, err := fs.bumpGenerationUnsafe()
+	if err != nil {
+		return err
+	}
+
+	j := &journal{Writes: writes, Deletes: deletes, Events: events}
+	if len(writes)+len(deletes) == 1 && len(events) == 0 {
+		if err := fs.applyUnsafe(j); err != nil {
+			return err
+		}
+		fs.search.update(generation, writes, deletes)
+		return nil
+	}
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if err := writeFileAtomic(fs.journalPath(), data); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := fs.applyUnsafe(j); err != nil {
+		// Leave the journal in place so the operation is retried on recovery
+		return err
+	}
+	fs.search.update(generation, writes, deletes)
+
+	return fs.removeJournal()
+}
+
+// recoverJournalUnsafe replays a journal left behind by an interrupted
+// operation. Must be called with the exclusive lock held.
+func (fs *FileStorage) recoverJournalUnsafe() error {
+	data, err := os.ReadFile(fs.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		// The journal is renamed into place only once complete, so an
+		// unreadable one means nothing from it was applied yet
+		return fs.removeJournal()
+	}
+
+	if _, err := fs.bumpGenerationUnsafe(); err != nil {
+		return err
+	}
+	if err := fs.applyUnsafe(&j); err != nil {
+		return fmt.Errorf("failed to replay journal: %w", err)
+	}
+	return fs.removeJournal()
+}
+
+// changesUnsafe pairs the tasks a commit writes and deletes with their
+// stored copies. Must be called with the exclusive lock held.
+func (fs *FileStorage) changesUnsafe(writes []*models.Task, deletes []string) []taskChange {
+	if fs.actor == nil {
+		return nil
+	}
+
+	changes := make([]taskChange, 0, len(writes)+len(deletes))
+	for _, task := range writes {
+		// A task that isn't stored yet is being created
+		before, _ := fs.getTaskUnsafe(task.ID)
+		changes = append(changes, taskChange{before: before, after: task})
+	}
+	for _, id := range deletes {
+		before, _ := fs.getTaskUnsafe(id)
+		changes = append(changes, taskChange{before: before})
+	}
+	return changes
+}
+
+// applyUnsafe performs the file changes recorded in j
+func (fs *FileStorage) applyUnsafe(j *journal) error {
+	for _, task := range j.Writes {
+		if err := fs.saveTaskUnsafe(task); err != nil {
+			return err
+		}
+	}
+	for _, id := range j.Deletes {
+		if err := fs.deleteTaskUnsafe(id); err != nil {
+			return err
+		}
+	}
+	if len(j.Events) > 0 {
+		return fs.appendEventsUnsafe(j.Events)
+	}
+	return nil
+}
+
+func (fs *FileStorage) removeJournal() error {
+	if err := os.Remove(fs.journalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStorage) journalPath() string {
+	return filepath.Join(fs.dataDir, journalFileName)
+}
+
+// appendLines appends newline-terminated data to the file at path, creating
+// it if needed, and syncs it to disk. If an earlier append was cut short the
+// data starts on a new line, so only the cut line is lost.
+func appendLines(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if size := info.Size(); size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			f.Close()
+			return err
+		}
+		if last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileAtomic replaces path with data so that readers see either the old
+// or the new contents, never a partial file. The data is written to a
+// temporary file in the same directory, flushed to disk and renamed over path.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// removeTempFiles deletes temporary files abandoned in dir by writes that were
+// interrupted before their rename. Must be called with the exclusive lock held.
+func removeTempFiles(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp*"))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove temporary file: %w", err)
+		}
+	}
+	return nil
+}
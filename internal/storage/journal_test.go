@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestFileStorage_RecoverJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	parent := models.NewTask("Parent", "")
+	if err := storage.CreateTask(parent); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	doomed := models.NewTask("Doomed", "")
+	if err := storage.CreateTask(doomed); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Simulate a crash after the journal was written but before it was applied
+	child := models.NewTask("Child", "")
+	child.ID = "child-id"
+	child.ParentID = parent.ID
+	parent.AddChild(child.ID)
+	data, err := json.Marshal(&journal{
+		Writes:  []*models.Task{parent, child},
+		Deletes: []string{doomed.ID},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal journal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, journalFileName), data, 0644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	reopened, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, journalFileName)); !os.IsNotExist(err) {
+		t.Error("recovery should remove the journal")
+	}
+
+	recoveredParent, err := reopened.GetTask(parent.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if len(recoveredParent.Children) != 1 || recoveredParent.Children[0] != child.ID {
+		t.Errorf("recovered parent children = %v, want [%s]", recoveredParent.Children, child.ID)
+	}
+	if !reopened.TaskExists(child.ID) {
+		t.Error("recovery should write the child task")
+	}
+	if reopened.TaskExists(doomed.ID) {
+		t.Error("recovery should apply journalled deletes")
+	}
+}
+
+func TestFileStorage_RecoverTruncatedJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, journalFileName), []byte(`{"writes":[{"id"`), 0644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, journalFileName)); !os.IsNotExist(err) {
+		t.Error("an unreadable journal should be discarded")
+	}
+
+	tasks, err := storage.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("ListTasks() returned %d tasks, want 0", len(tasks))
+	}
+}
+
+func TestFileStorage_AtomicWrites(t *testing.T) {
+	tempDir := t.TempDir()
+	tasksDir := filepath.Join(tempDir, "tasks")
+
+	// A temporary file left by an interrupted write
+	if err := os.MkdirAll(tasksDir, 0755); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	stale := filepath.Join(tasksDir, ".abc.json.tmp123")
+	if err := os.WriteFile(stale, []byte(`{"id":`), 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("NewFileStorage() should remove abandoned temporary files")
+	}
+
+	parent := models.NewTask("Parent", "")
+	if err := storage.CreateTask(parent); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	child := models.NewTask("Child", "")
+	child.Type = models.TypeSubtask
+	child.ParentID = parent.ID
+	if err := storage.CreateTask(child); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(tasksDir)
+	if err != nil {
+		t.Fatalf("Failed to read tasks directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("tasks directory has %d entries, want 2", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, journalFileName)); !os.IsNotExist(err) {
+		t.Error("a completed operation should not leave a journal behind")
+	}
+}
+
+func TestFileStorage_EmptyCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	if err := storage.CreateTask(models.NewTask("Task", "")); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	before, err := storage.Generation()
+	if err != nil {
+		t.Fatalf("Generation() error = %v", err)
+	}
+
+	storage.mu.Lock()
+	err = storage.commitUnsafe(nil, nil)
+	storage.mu.Unlock()
+	if err != nil {
+		t.Fatalf("commitUnsafe() error = %v", err)
+	}
+
+	after, err := storage.Generation()
+	if err != nil {
+		t.Fatalf("Generation() error = %v", err)
+	}
+	if after != before {
+		t.Errorf("Generation() after an empty commit = %d, want %d", after, before)
+	}
+}
+
+func TestFileStorage_RecoverJournalEvents(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	task := createTasks(t, storage, "Task")[0]
+
+	// Simulate a crash after the event was appended but before the journal
+	// was removed
+	task.Title = "Renamed"
+	event := models.NewTaskEvent(&models.Task{ID: task.ID, Title: "Task"}, task, "alice", models.SourceAPI, task.UpdatedAt)
+	if err := storage.AppendEvents([]*models.TaskEvent{event}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	data, err := json.Marshal(&journal{Writes: []*models.Task{task}, Events: []*models.TaskEvent{event}})
+	if err != nil {
+		t.Fatalf("Failed to marshal journal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, journalFileName), data, 0644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	reopened, err := NewFileStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	events, err := reopened.ListEvents(EventQuery{TaskID: task.ID})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != event.ID {
+		t.Errorf("ListEvents() after replaying the journal = %+v, want the event once", events)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// GetTaskByRef retrieves the task whose key or ID is ref, so users can name
+// tasks by key wherever an ID is accepted
+func GetTaskByRef(store Storage, ref string) (*models.Task, error) {
+	if key, _, ok := models.ParseTaskKey(ref); ok {
+		return store.GetTaskByKey(key)
+	}
+	return store.GetTask(ref)
+}
+
+// ResolveTaskID returns the ID of the task whose key or ID is ref. An ID is
+// returned as it is without checking the task exists.
+func ResolveTaskID(store Storage, ref string) (string, error) {
+	key, _, ok := models.ParseTaskKey(ref)
+	if !ok {
+		return ref, nil
+	}
+	task, err := store.GetTaskByKey(key)
+	if err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// lastKeyNumber returns the highest number used in the keys of tasks, for
+// starting the sequence again when its counter has been lost
+func lastKeyNumber(tasks []*models.Task) uint64 {
+	var last uint64
+	for _, task := range tasks {
+		if _, number, ok := models.ParseTaskKey(task.Key); ok && number > last {
+			last = number
+		}
+	}
+	return last
+}
+
+// keylessTasks returns the tasks created before tasks had keys, oldest first,
+// so the keys they are given follow the order they were created in
+func keylessTasks(tasks []*models.Task) []*models.Task {
+	var keyless []*models.Task
+	for _, task := range tasks {
+		if task.Key == "" {
+			keyless = append(keyless, task)
+		}
+	}
+	sort.Slice(keyless, func(i, j int) bool {
+		a, b := keyless[i], keyless[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return keyless
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestTaskKeys(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { models.SetWorkflow(nil) })
+
+			tasks := createTasks(t, store, "Parser", "Docs", "Release")
+			for i, want := range []string{"PF-1", "PF-2", "PF-3"} {
+				if tasks[i].Key != want {
+					t.Errorf("CreateTask() key = %q, want %q", tasks[i].Key, want)
+				}
+			}
+
+			// A deleted task's number isn't given out again
+			if err := store.DeleteTask(tasks[2].ID); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			// Nor is one taken by a create that is rejected
+			orphan := models.NewTask("Orphan", "")
+			orphan.ParentID = "missing"
+			if err := store.CreateTask(orphan); err == nil {
+				t.Fatal("CreateTask() with a missing parent error = nil")
+			}
+			workflow := models.DefaultWorkflow()
+			workflow.KeyPrefix = "WEB"
+			models.SetWorkflow(workflow)
+			next := createTasks(t, store, "Login page")[0]
+			if next.Key != "WEB-4" {
+				t.Errorf("CreateTask() after a delete, a rejected create and a new prefix key = %q, want WEB-4", next.Key)
+			}
+
+			// The key can't be changed
+			update, err := store.GetTask(tasks[0].ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			update.Key = "PF-99"
+			update.Title = "Parser v2"
+			if err := store.UpdateTask(update); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if update.Key != "PF-1" {
+				t.Errorf("UpdateTask() key = %q, want PF-1", update.Key)
+			}
+
+			got, err := store.GetTaskByKey("PF-1")
+			if err != nil {
+				t.Fatalf("GetTaskByKey() error = %v", err)
+			}
+			if got.ID != tasks[0].ID || got.Title != "Parser v2" {
+				t.Errorf("GetTaskByKey(PF-1) = %+v, want the updated parser task", got)
+			}
+			if _, err := store.GetTaskByKey("PF-99"); err == nil {
+				t.Error("GetTaskByKey(PF-99) error = nil, want not found")
+			}
+
+			if got, err := GetTaskByRef(store, "web-4"); err != nil || got.ID != next.ID {
+				t.Errorf("GetTaskByRef(web-4) = %v, %v, want the login page task", got, err)
+			}
+			if got, err := GetTaskByRef(store, tasks[1].ID); err != nil || got.Key != "PF-2" {
+				t.Errorf("GetTaskByRef(ID) = %v, %v, want the docs task", got, err)
+			}
+			if id, err := ResolveTaskID(store, "PF-2"); err != nil || id != tasks[1].ID {
+				t.Errorf("ResolveTaskID(PF-2) = %q, %v, want %q", id, err, tasks[1].ID)
+			}
+			if _, err := ResolveTaskID(store, "PF-3"); err == nil {
+				t.Error("ResolveTaskID() of a deleted task's key error = nil, want not found")
+			}
+		})
+	}
+}
+
+func TestFileStorage_AssignsMissingKeys(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	tasks := createTasks(t, store, "Older", "Newer")
+
+	// Rewrite the tasks as they were stored before tasks had keys, the
+	// newer one first, and lose the counter
+	for i, task := range []*models.Task{tasks[1], tasks[0]} {
+		task.Key = ""
+		task.CreatedAt = time.Date(2026, 1, 2-i, 0, 0, 0, 0, time.UTC)
+		data, err := json.Marshal(task)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, "tasks", task.ID+".json"), data, 0644); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dataDir, taskKeyFileName)); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	reopened, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	for i, want := range []string{"PF-1", "PF-2"} {
+		got, err := reopened.GetTask(tasks[i].ID)
+		if err != nil {
+			t.Fatalf("GetTask() error = %v", err)
+		}
+		if got.Key != want {
+			t.Errorf("task %q key = %q, want %q", got.Title, got.Key, want)
+		}
+	}
+	if next := createTasks(t, reopened, "Next")[0]; next.Key != "PF-3" {
+		t.Errorf("CreateTask() key = %q, want PF-3", next.Key)
+	}
+}
+
+func TestFileStorage_KeyIndex(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	tasks := createTasks(t, store, "Kept", "Deleted")
+
+	if err := store.DeleteTask(tasks[1].ID); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "keys", "PF-2")); !os.IsNotExist(err) {
+		t.Error("DeleteTask() should remove the task's key index entry")
+	}
+
+	// An entry left by an interrupted write is ignored
+	if err := os.WriteFile(filepath.Join(dataDir, "keys", "PF-3"), []byte(tasks[0].ID), 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := store.GetTaskByKey("PF-3"); err == nil {
+		t.Error("GetTaskByKey() of a stale entry error = nil, want not found")
+	}
+
+	// A data directory from before the index had one is indexed on opening
+	if err := os.RemoveAll(filepath.Join(dataDir, "keys")); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	reopened, err := NewFileStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	if got, err := reopened.GetTaskByKey("PF-1"); err != nil || got.ID != tasks[0].ID {
+		t.Errorf("GetTaskByKey(PF-1) = %v, %v, want the kept task", got, err)
+	}
+}
+
+func TestSQLiteStorage_AssignsMissingKeys(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewSQLiteStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	epic := models.NewTask("Epic", "")
+	epic.Type = models.TypeEpic
+	if err := store.CreateTask(epic); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	story := models.NewTask("Story", "")
+	story.Type = models.TypeStory
+	story.ParentID = epic.ID
+	if err := store.CreateTask(story); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Strip the keys and the counter as in a database from before tasks had keys
+	if _, err := store.db.Exec(`UPDATE tasks SET data = json_remove(data, '$.key')`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := store.db.Exec(`DELETE FROM meta WHERE key = 'task_key'`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	store.Close()
+
+	reopened, err := NewSQLiteStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	got, err := reopened.GetTask(epic.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if got.Key == "" || len(got.Children) != 1 {
+		t.Errorf("GetTask() = %+v, want a key and the story still a child", got)
+	}
+	if next := createTasks(t, reopened, "Next")[0]; next.Key != "PF-3" {
+		t.Errorf("CreateTask() key = %q, want PF-3", next.Key)
+	}
+}
+
+func TestSQLiteStorage_KeyColumn(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewSQLiteStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	tasks := createTasks(t, store, "First", "Second")
+
+	// Keys are looked up through the indexed column, not the task data
+	var id, parent, unused int
+	var plan string
+	err = store.db.QueryRow(`EXPLAIN QUERY PLAN SELECT id FROM tasks WHERE key = ?`, "PF-1").
+		Scan(&id, &parent, &unused, &plan)
+	if err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN error = %v", err)
+	}
+	if !strings.Contains(plan, "idx_tasks_key") {
+		t.Errorf("query plan = %q, want idx_tasks_key", plan)
+	}
+
+	// Drop the column as in a database from before it was added
+	for _, stmt := range []string{`DROP INDEX idx_tasks_key`, `ALTER TABLE tasks DROP COLUMN key`} {
+		if _, err := store.db.Exec(stmt); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+	store.Close()
+
+	reopened, err := NewSQLiteStorage(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.GetTaskByKey("PF-2"); err != nil || got.ID != tasks[1].ID {
+		t.Errorf("GetTaskByKey(PF-2) = %v, %v, want the second task", got, err)
+	}
+	if next := createTasks(t, reopened, "Third")[0]; next.Key != "PF-3" {
+		t.Errorf("CreateTask() key = %q, want PF-3", next.Key)
+	}
+}
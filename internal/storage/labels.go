@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkLabel puts a label's name in canonical form and checks its fields
+func checkLabel(label *models.Label) error {
+	label.Name = models.NormalizeLabelName(label.Name)
+	if !models.IsValidLabelName(label.Name) {
+		return fmt.Errorf("%w: name %q must be lower case letters, digits, dots, dashes or underscores", ErrInvalidLabel, label.Name)
+	}
+	if !models.IsValidLabelColor(label.Color) {
+		return fmt.Errorf("%w: color %q must be a #rrggbb hex colour", ErrInvalidLabel, label.Color)
+	}
+	return nil
+}
+
+// checkLabels puts task's labels in canonical form and returns
+// ErrLabelNotFound when one of them isn't registered
+func checkLabels(labelExists func(name string) bool, task *models.Task) error {
+	labels, err := models.NormalizeLabels(task.Labels)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLabel, err)
+	}
+	for _, name := range labels {
+		if !labelExists(name) {
+			return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+	}
+	task.Labels = labels
+	return nil
+}
+
+// planLabels works out the task writes needed to add and remove labels on
+// taskIDs, reading tasks through get. It returns the writes and every task
+// named, in taskIDs order.
+func planLabels(get func(id string) (*models.Task, error), labelExists func(name string) bool, taskIDs []string, add, remove []string) ([]*models.Task, []*models.Task, error) {
+	add, err := models.NormalizeLabels(add)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidLabel, err)
+	}
+	remove, err = models.NormalizeLabels(remove)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidLabel, err)
+	}
+	for _, name := range add {
+		if !labelExists(name) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(taskIDs))
+	var writes, tasks []*models.Task
+	for _, id := range taskIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		task, err := get(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, task)
+
+		var labels []string
+		for _, names := range [][]string{task.Labels, add} {
+			for _, name := range names {
+				if !containsValue(remove, name) {
+					labels = append(labels, name)
+				}
+			}
+		}
+		labels, _ = models.NormalizeLabels(labels)
+		if !equalLabels(labels, task.Labels) {
+			task.Labels = labels
+			task.UpdatedAt = now
+			writes = append(writes, task)
+		}
+	}
+
+	bumpVersions(writes)
+	return writes, tasks, nil
+}
+
+// stripLabel removes label from every task in tasks that carries it and
+// returns the tasks to write
+func stripLabel(tasks []*models.Task, label string) []*models.Task {
+	now := time.Now()
+	var writes []*models.Task
+	for _, task := range tasks {
+		if !task.HasLabel(label) {
+			continue
+		}
+		labels := make([]string, 0, len(task.Labels)-1)
+		for _, name := range task.Labels {
+			if name != label {
+				labels = append(labels, name)
+			}
+		}
+		if len(labels) == 0 {
+			labels = nil
+		}
+		task.Labels = labels
+		task.UpdatedAt = now
+		writes = append(writes, task)
+	}
+
+	bumpVersions(writes)
+	return writes
+}
+
+func equalLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
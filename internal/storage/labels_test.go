@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestLabels(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, label := range []*models.Label{
+				{Name: " MCP ", Color: "#123456"},
+				{Name: "backend", Color: "#abcdef", Description: "Server side"},
+			} {
+				if err := store.CreateLabel(label); err != nil {
+					t.Fatalf("CreateLabel() error = %v", err)
+				}
+			}
+
+			labels, err := store.ListLabels()
+			if err != nil {
+				t.Fatalf("ListLabels() error = %v", err)
+			}
+			if len(labels) != 2 || labels[0].Name != "backend" || labels[1].Name != "mcp" {
+				t.Errorf("ListLabels() = %v, want backend then mcp", labels)
+			}
+
+			if err := store.CreateLabel(&models.Label{Name: "mcp", Color: "#000000"}); !errors.Is(err, ErrLabelExists) {
+				t.Errorf("CreateLabel(duplicate) error = %v, want ErrLabelExists", err)
+			}
+			for _, label := range []*models.Label{
+				{Name: "has space", Color: "#000000"},
+				{Name: "../escape", Color: "#000000"},
+				{Name: "colour", Color: "red"},
+			} {
+				if err := store.CreateLabel(label); !errors.Is(err, ErrInvalidLabel) {
+					t.Errorf("CreateLabel(%q, %q) error = %v, want ErrInvalidLabel", label.Name, label.Color, err)
+				}
+			}
+
+			if err := store.UpdateLabel(&models.Label{Name: "mcp", Color: "#654321", Description: "Agents"}); err != nil {
+				t.Fatalf("UpdateLabel() error = %v", err)
+			}
+			got, err := store.GetLabel("mcp")
+			if err != nil {
+				t.Fatalf("GetLabel() error = %v", err)
+			}
+			if got.Color != "#654321" || got.Description != "Agents" {
+				t.Errorf("GetLabel() = %+v, want the updated label", got)
+			}
+
+			if _, err := store.GetLabel("missing"); !errors.Is(err, ErrLabelNotFound) {
+				t.Errorf("GetLabel(missing) error = %v, want ErrLabelNotFound", err)
+			}
+			if err := store.UpdateLabel(&models.Label{Name: "missing", Color: "#000000"}); !errors.Is(err, ErrLabelNotFound) {
+				t.Errorf("UpdateLabel(missing) error = %v, want ErrLabelNotFound", err)
+			}
+			if err := store.DeleteLabel("missing"); !errors.Is(err, ErrLabelNotFound) {
+				t.Errorf("DeleteLabel(missing) error = %v, want ErrLabelNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTaskLabels(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, label := range []string{"backend", "mcp", "web-ui"} {
+				if err := store.CreateLabel(&models.Label{Name: label, Color: models.DefaultLabelColor}); err != nil {
+					t.Fatalf("CreateLabel() error = %v", err)
+				}
+			}
+
+			tagged := models.NewTask("Tagged", "")
+			tagged.Labels = []string{"MCP", "backend", "mcp"}
+			if err := store.CreateTask(tagged); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			if !equalLabels(tagged.Labels, []string{"backend", "mcp"}) {
+				t.Errorf("CreateTask() labels = %v, want [backend mcp]", tagged.Labels)
+			}
+			unknown := models.NewTask("Unknown", "")
+			unknown.Labels = []string{"missing"}
+			if err := store.CreateTask(unknown); !errors.Is(err, ErrLabelNotFound) {
+				t.Errorf("CreateTask() with unknown label error = %v, want ErrLabelNotFound", err)
+			}
+
+			tasks := createTasks(t, store, "First", "Second")
+			first, second := tasks[0], tasks[1]
+
+			// Bulk tagging writes only the tasks whose labels change
+			updated, err := store.LabelTasks([]string{first.ID, second.ID, tagged.ID, first.ID}, []string{"web-ui"}, []string{"backend"})
+			if err != nil {
+				t.Fatalf("LabelTasks() error = %v", err)
+			}
+			if len(updated) != 3 {
+				t.Fatalf("LabelTasks() returned %d tasks, want 3", len(updated))
+			}
+			for _, task := range updated {
+				if task.ID == tagged.ID {
+					if !equalLabels(task.Labels, []string{"mcp", "web-ui"}) {
+						t.Errorf("LabelTasks() labels of %q = %v, want [mcp web-ui]", task.Title, task.Labels)
+					}
+				} else if !equalLabels(task.Labels, []string{"web-ui"}) {
+					t.Errorf("LabelTasks() labels of %q = %v, want [web-ui]", task.Title, task.Labels)
+				}
+			}
+			current, err := store.GetTask(first.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if current.Version != first.Version+1 {
+				t.Errorf("LabelTasks() version = %d, want %d", current.Version, first.Version+1)
+			}
+
+			if _, err := store.LabelTasks([]string{first.ID}, []string{"missing"}, nil); !errors.Is(err, ErrLabelNotFound) {
+				t.Errorf("LabelTasks() with unknown label error = %v, want ErrLabelNotFound", err)
+			}
+			if _, err := store.LabelTasks([]string{"missing"}, []string{"mcp"}, nil); err == nil {
+				t.Error("LabelTasks() with unknown task expected error")
+			}
+
+			page, err := store.QueryTasks(TaskQuery{Labels: []string{"mcp"}})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 1 || page.Tasks[0].ID != tagged.ID {
+				t.Errorf("QueryTasks(mcp) = %d tasks, want only %q", page.Total, tagged.Title)
+			}
+			page, err = store.QueryTasks(TaskQuery{Labels: []string{"mcp", "web-ui"}})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 3 {
+				t.Errorf("QueryTasks(mcp or web-ui) = %d tasks, want 3", page.Total)
+			}
+
+			// Deleting a label removes it from every task
+			if err := store.DeleteLabel("web-ui"); err != nil {
+				t.Fatalf("DeleteLabel() error = %v", err)
+			}
+			page, err = store.QueryTasks(TaskQuery{Labels: []string{"web-ui"}})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 0 {
+				t.Errorf("QueryTasks(web-ui) after delete = %d tasks, want 0", page.Total)
+			}
+			current, err = store.GetTask(tagged.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalLabels(current.Labels, []string{"mcp"}) {
+				t.Errorf("GetTask() labels after delete = %v, want [mcp]", current.Labels)
+			}
+		})
+	}
+}
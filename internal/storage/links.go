@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// planLink works out the task writes needed to link fromID to toID, reading
+// tasks through get. Backends apply the writes in a single atomic unit.
+func planLink(get func(id string) (*models.Task, error), fromID string, linkType models.LinkType, toID string) ([]*models.Task, error) {
+	if !models.IsValidLinkType(string(linkType)) {
+		return nil, fmt.Errorf("%w: unknown link type %q", ErrInvalidLink, linkType)
+	}
+	if fromID == toID {
+		return nil, fmt.Errorf("%w: a task cannot be linked to itself", ErrInvalidLink)
+	}
+
+	from, err := get(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := get(toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.HasLink(linkType, toID) {
+		// Already linked; nothing to write
+		return nil, nil
+	}
+
+	// Check directed links from the side they point away from
+	source, target, forward := fromID, toID, linkType
+	if linkType == models.LinkBlockedBy || linkType == models.LinkDuplicatedBy {
+		source, target, forward = toID, fromID, linkType.Inverse()
+	}
+	if forward != models.LinkRelatesTo && reaches(get, target, source, forward) {
+		return nil, fmt.Errorf("%w: %s already %s %s", ErrDependencyCycle, target, describeLink(forward), source)
+	}
+
+	now := time.Now()
+	from.AddLink(linkType, toID)
+	from.UpdatedAt = now
+	to.AddLink(linkType.Inverse(), fromID)
+	to.UpdatedAt = now
+
+	writes := []*models.Task{from, to}
+	bumpVersions(writes)
+	return writes, nil
+}
+
+// planUnlink works out the task writes needed to remove a link and its inverse
+func planUnlink(get func(id string) (*models.Task, error), fromID string, linkType models.LinkType, toID string) ([]*models.Task, error) {
+	from, err := get(fromID)
+	if err != nil {
+		return nil, err
+	}
+	if !from.RemoveLink(linkType, toID) {
+		return nil, fmt.Errorf("%w: %s does not %s %s", ErrLinkNotFound, fromID, describeLink(linkType), toID)
+	}
+	from.UpdatedAt = time.Now()
+	writes := []*models.Task{from}
+
+	// The other end may already be gone
+	if to, err := get(toID); err == nil {
+		to.RemoveLink(linkType.Inverse(), fromID)
+		to.UpdatedAt = from.UpdatedAt
+		writes = append(writes, to)
+	}
+
+	bumpVersions(writes)
+	return writes, nil
+}
+
+// reaches reports whether following linkType links from startID leads to goalID
+func reaches(get func(id string) (*models.Task, error), startID, goalID string, linkType models.LinkType) bool {
+	visited := make(map[string]bool)
+	queue := []string{startID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == goalID {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		task, err := get(id)
+		if err != nil {
+			continue
+		}
+		queue = append(queue, task.LinkedTasks(linkType)...)
+	}
+	return false
+}
+
+// checkBlockers returns ErrTaskBlocked when task is moving into an in
+// progress status while a task that blocks it is not done
+func checkBlockers(get func(id string) (*models.Task, error), task, current *models.Task) error {
+	if models.CategoryOf(task.Status) != models.CategoryInProgress ||
+		models.CategoryOf(current.Status) == models.CategoryInProgress {
+		return nil
+	}
+
+	if open := openBlockers(get, current); len(open) > 0 {
+		return fmt.Errorf("%w: waiting on %s", ErrTaskBlocked, strings.Join(open, ", "))
+	}
+	return nil
+}
+
+// openBlockers returns the IDs of the tasks blocking task that are not done
+func openBlockers(get func(id string) (*models.Task, error), task *models.Task) []string {
+	var open []string
+	for _, id := range task.LinkedTasks(models.LinkBlockedBy) {
+		blocker, err := get(id)
+		if err == nil && !models.IsDoneStatus(blocker.Status) {
+			open = append(open, id)
+		}
+	}
+	return open
+}
+
+// unlinkDeleted removes the links that surviving tasks hold to the tasks the
+// plan deletes, adding those tasks to the plan's writes
+func unlinkDeleted(get func(id string) (*models.Task, error), plan *deletePlan) {
+	deleted := make(map[string]bool, len(plan.result.Deleted))
+	for _, id := range plan.result.Deleted {
+		deleted[id] = true
+	}
+	written := make(map[string]*models.Task, len(plan.writes))
+	for _, task := range plan.writes {
+		written[task.ID] = task
+	}
+
+	for _, id := range plan.result.Deleted {
+		task, err := get(id)
+		if err != nil {
+			continue
+		}
+		for _, link := range task.Links {
+			if deleted[link.TaskID] {
+				continue
+			}
+			other, ok := written[link.TaskID]
+			if !ok {
+				if other, err = get(link.TaskID); err != nil {
+					continue
+				}
+				written[other.ID] = other
+				plan.writes = append(plan.writes, other)
+			}
+			other.RemoveLink(link.Type.Inverse(), id)
+			other.UpdatedAt = time.Now()
+		}
+	}
+}
+
+// describeLink turns a link type into words for error messages
+func describeLink(linkType models.LinkType) string {
+	return strings.ReplaceAll(string(linkType), "_", " ")
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func createTasks(t *testing.T, store Storage, titles ...string) []*models.Task {
+	t.Helper()
+	tasks := make([]*models.Task, 0, len(titles))
+	for _, title := range titles {
+		task := models.NewTask(title, "")
+		if err := store.CreateTask(task); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+func TestLinkTasks(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			tasks := createTasks(t, store, "Schema", "API", "UI")
+			schema, api, ui := tasks[0], tasks[1], tasks[2]
+
+			if err := store.LinkTasks(schema.ID, models.LinkBlocks, api.ID); err != nil {
+				t.Fatalf("LinkTasks() error = %v", err)
+			}
+			// Stated from the blocked side
+			if err := store.LinkTasks(ui.ID, models.LinkBlockedBy, api.ID); err != nil {
+				t.Fatalf("LinkTasks() error = %v", err)
+			}
+
+			stored, _ := store.GetTask(api.ID)
+			if !stored.HasLink(models.LinkBlockedBy, schema.ID) || !stored.HasLink(models.LinkBlocks, ui.ID) {
+				t.Errorf("api links = %v, want blocked by schema and blocking ui", stored.Links)
+			}
+
+			tests := []struct {
+				name     string
+				from, to string
+				linkType models.LinkType
+				want     error
+			}{
+				{"direct cycle", api.ID, schema.ID, models.LinkBlocks, ErrDependencyCycle},
+				{"transitive cycle", ui.ID, schema.ID, models.LinkBlocks, ErrDependencyCycle},
+				{"cycle stated from the blocked side", schema.ID, ui.ID, models.LinkBlockedBy, ErrDependencyCycle},
+				{"self link", api.ID, api.ID, models.LinkRelatesTo, ErrInvalidLink},
+				{"unknown type", api.ID, ui.ID, models.LinkType("follows"), ErrInvalidLink},
+			}
+			for _, tt := range tests {
+				if err := store.LinkTasks(tt.from, tt.linkType, tt.to); !errors.Is(err, tt.want) {
+					t.Errorf("%s: LinkTasks() error = %v, want %v", tt.name, err, tt.want)
+				}
+			}
+
+			if err := store.LinkTasks(ui.ID, models.LinkRelatesTo, schema.ID); err != nil {
+				t.Errorf("LinkTasks() relates_to error = %v", err)
+			}
+
+			if err := store.UnlinkTasks(api.ID, models.LinkBlockedBy, schema.ID); err != nil {
+				t.Fatalf("UnlinkTasks() error = %v", err)
+			}
+			if stored, _ := store.GetTask(schema.ID); stored.HasLink(models.LinkBlocks, api.ID) {
+				t.Errorf("inverse link kept after UnlinkTasks()")
+			}
+			if err := store.UnlinkTasks(api.ID, models.LinkBlockedBy, schema.ID); !errors.Is(err, ErrLinkNotFound) {
+				t.Errorf("UnlinkTasks() of a missing link error = %v, want ErrLinkNotFound", err)
+			}
+		})
+	}
+}
+
+func TestLinkTasks_BlockedStatus(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			tasks := createTasks(t, store, "Blocker", "Blocked")
+			blocker, blocked := tasks[0], tasks[1]
+			if err := store.LinkTasks(blocker.ID, models.LinkBlocks, blocked.ID); err != nil {
+				t.Fatalf("LinkTasks() error = %v", err)
+			}
+
+			start := func() error {
+				task, _ := store.GetTask(blocked.ID)
+				task.Status = models.StatusInProgress
+				return store.UpdateTask(task)
+			}
+
+			if err := start(); !errors.Is(err, ErrTaskBlocked) {
+				t.Fatalf("UpdateTask() to in_progress error = %v, want ErrTaskBlocked", err)
+			}
+
+			done, _ := store.GetTask(blocker.ID)
+			done.Status = models.StatusDone
+			if err := store.UpdateTask(done); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if err := start(); err != nil {
+				t.Errorf("UpdateTask() once the blocker is done error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkTasks_DeleteRemovesLinks(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			tasks := createTasks(t, store, "Blocker", "Blocked")
+			blocker, blocked := tasks[0], tasks[1]
+			if err := store.LinkTasks(blocker.ID, models.LinkBlocks, blocked.ID); err != nil {
+				t.Fatalf("LinkTasks() error = %v", err)
+			}
+
+			if err := store.DeleteTask(blocker.ID); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			stored, _ := store.GetTask(blocked.ID)
+			if len(stored.Links) != 0 {
+				t.Errorf("links = %v after the linked task was deleted, want none", stored.Links)
+			}
+		})
+	}
+}
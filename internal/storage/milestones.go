@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkMilestone trims a milestone's name and task IDs and checks its fields
+func checkMilestone(milestone *models.Milestone) error {
+	milestone.Name = strings.TrimSpace(milestone.Name)
+	if milestone.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMilestone)
+	}
+	if milestone.TargetDate.IsZero() {
+		return fmt.Errorf("%w: target date is required", ErrInvalidMilestone)
+	}
+
+	seen := make(map[string]bool, len(milestone.TaskIDs))
+	taskIDs := []string{}
+	for _, id := range milestone.TaskIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			taskIDs = append(taskIDs, id)
+		}
+	}
+	milestone.TaskIDs = taskIDs
+	return nil
+}
+
+// checkMilestoneTasks checks that the tasks linked to milestone exist.
+// previous holds the tasks the stored milestone links, which aren't checked
+// again, so a deleted task doesn't stop the milestone being updated.
+func checkMilestoneTasks(get func(id string) (*models.Task, error), milestone *models.Milestone, previous []string) error {
+	linked := make(map[string]bool, len(previous))
+	for _, id := range previous {
+		linked[id] = true
+	}
+	for _, id := range milestone.TaskIDs {
+		if linked[id] {
+			continue
+		}
+		if _, err := get(id); err != nil {
+			return fmt.Errorf("%w: task %s not found", ErrInvalidMilestone, id)
+		}
+	}
+	return nil
+}
+
+// updateMilestone copies milestone's fields onto stored, keeping the time it
+// was created, and then stored back onto milestone
+func updateMilestone(stored, milestone *models.Milestone) {
+	stored.Name, stored.Description = milestone.Name, milestone.Description
+	stored.TargetDate = milestone.TargetDate
+	stored.TaskIDs = milestone.TaskIDs
+	stored.UpdatedAt = time.Now()
+	*milestone = *stored
+}
+
+// sortMilestones orders milestones by target date, then name and ID
+func sortMilestones(milestones []*models.Milestone) {
+	sort.Slice(milestones, func(i, j int) bool {
+		a, b := milestones[i], milestones[j]
+		if !a.TargetDate.Equal(b.TargetDate) {
+			return a.TargetDate.Before(b.TargetDate)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
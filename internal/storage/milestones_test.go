@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestMilestones(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			tasks := createTasks(t, store, "Parser", "Docs")
+			target := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+			release := models.NewMilestone(" 1.0 ", "First release", target)
+			release.TaskIDs = []string{tasks[0].ID, tasks[1].ID, tasks[0].ID}
+			if err := store.CreateMilestone(release); err != nil {
+				t.Fatalf("CreateMilestone() error = %v", err)
+			}
+			if release.Name != "1.0" || len(release.TaskIDs) != 2 {
+				t.Errorf("CreateMilestone() = %+v, want the name trimmed and each task once", release)
+			}
+			beta := models.NewMilestone("Beta", "", target.AddDate(0, -1, 0))
+			if err := store.CreateMilestone(beta); err != nil {
+				t.Fatalf("CreateMilestone() error = %v", err)
+			}
+
+			listed, err := store.ListMilestones()
+			if err != nil {
+				t.Fatalf("ListMilestones() error = %v", err)
+			}
+			if len(listed) != 2 || listed[0].ID != beta.ID || listed[1].ID != release.ID {
+				t.Errorf("ListMilestones() = %v, want beta then 1.0", listed)
+			}
+
+			for _, invalid := range []*models.Milestone{
+				models.NewMilestone(" ", "", target),
+				{Name: "Undated"},
+				{Name: "Unknown task", TargetDate: target, TaskIDs: []string{"missing"}},
+			} {
+				if err := store.CreateMilestone(invalid); !errors.Is(err, ErrInvalidMilestone) {
+					t.Errorf("CreateMilestone(%q) error = %v, want ErrInvalidMilestone", invalid.Name, err)
+				}
+			}
+
+			// A deleted task stays linked and doesn't stop updates
+			if err := store.DeleteTask(tasks[1].ID); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			update, err := store.GetMilestone(release.ID)
+			if err != nil {
+				t.Fatalf("GetMilestone() error = %v", err)
+			}
+			update.TargetDate = target.AddDate(0, 0, 7)
+			if err := store.UpdateMilestone(update); err != nil {
+				t.Fatalf("UpdateMilestone() error = %v", err)
+			}
+			update.TaskIDs = append(update.TaskIDs, "missing")
+			if err := store.UpdateMilestone(update); !errors.Is(err, ErrInvalidMilestone) {
+				t.Errorf("UpdateMilestone() linking a missing task error = %v, want ErrInvalidMilestone", err)
+			}
+			got, err := store.GetMilestone(release.ID)
+			if err != nil {
+				t.Fatalf("GetMilestone() error = %v", err)
+			}
+			if !got.TargetDate.Equal(target.AddDate(0, 0, 7)) || len(got.TaskIDs) != 2 || !got.CreatedAt.Equal(release.CreatedAt) {
+				t.Errorf("GetMilestone() = %+v, want the new target date and both tasks", got)
+			}
+
+			if err := store.DeleteMilestone(release.ID); err != nil {
+				t.Fatalf("DeleteMilestone() error = %v", err)
+			}
+			if err := store.DeleteMilestone(release.ID); !errors.Is(err, ErrMilestoneNotFound) {
+				t.Errorf("DeleteMilestone(deleted) error = %v, want ErrMilestoneNotFound", err)
+			}
+			if _, err := store.GetMilestone("../tasks"); !errors.Is(err, ErrMilestoneNotFound) {
+				t.Errorf("GetMilestone(../tasks) error = %v, want ErrMilestoneNotFound", err)
+			}
+			if _, err := store.GetTask(tasks[0].ID); err != nil {
+				t.Errorf("GetTask() after its milestone was deleted error = %v", err)
+			}
+		})
+	}
+}
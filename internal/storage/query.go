@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// TaskSortField names the field QueryTasks orders results by
+type TaskSortField string
+
+const (
+	SortByCreatedAt TaskSortField = "created_at"
+	SortByUpdatedAt TaskSortField = "updated_at"
+	SortByDueDate   TaskSortField = "due_date"
+	SortByPriority  TaskSortField = "priority"
+	SortByStatus    TaskSortField = "status"
+	SortByTitle     TaskSortField = "title"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded or
+// belongs to a query with a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskQuery selects, orders and pages tasks. Zero-valued fields don't filter.
+// Range bounds ending in After are inclusive and those ending in Before are
+// exclusive.
+type TaskQuery struct {
+	Status   []models.TaskStatus
+	Priority []models.TaskPriority
+	Type     []models.TaskType
+	// Labels selects tasks carrying any of the labels
+	Labels []string
+	// ParentID selects the children of a task; an empty string selects root tasks
+	ParentID *string
+	// AssigneeID and ReporterID select tasks by user; an empty string
+	// selects tasks without one
+	AssigneeID *string
+	ReporterID *string
+	// SprintID selects the tasks in a sprint; an empty string selects the
+	// backlog, tasks in no sprint
+	SprintID   *string
+	HasDueDate *bool
+	Overdue    *bool
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+
+	// Text matches case-insensitively against the title and description
+	Text string
+
+	SortBy     TaskSortField
+	Descending bool
+	// Limit caps the page size; zero returns every match
+	Limit  int
+	Cursor string
+}
+
+// TaskPage is one page of QueryTasks results
+type TaskPage struct {
+	Tasks []*models.Task `json:"tasks"`
+	// Total counts every match, not just this page
+	Total int `json:"total"`
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// queryCursor is the decoded form of TaskPage.NextCursor. It records where the
+// previous page ended so pages stay stable while tasks are added or removed.
+type queryCursor struct {
+	SortBy     TaskSortField `json:"s"`
+	Descending bool          `json:"d"`
+	Key        string        `json:"k"`
+	ID         string        `json:"id"`
+}
+
+// ApplyQuery filters, sorts and pages tasks in memory. Backends without a
+// native query path implement QueryTasks with it.
+func ApplyQuery(tasks []*models.Task, query TaskQuery) (*TaskPage, error) {
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = SortByCreatedAt
+	}
+
+	var after *queryCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != sortBy || cursor.Descending != query.Descending {
+			return nil, fmt.Errorf("%w: sort order changed", ErrInvalidCursor)
+		}
+		after = cursor
+	}
+
+	now := time.Now()
+	type keyedTask struct {
+		key  string
+		task *models.Task
+	}
+	var matches []keyedTask
+	for _, task := range tasks {
+		if query.matches(task, now) {
+			matches = append(matches, keyedTask{sortKey(task, sortBy), task})
+		}
+	}
+
+	less := func(aKey, aID, bKey, bID string) bool {
+		if aKey != bKey {
+			return (aKey < bKey) != query.Descending
+		}
+		return aID < bID
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return less(matches[i].key, matches[i].task.ID, matches[j].key, matches[j].task.ID)
+	})
+
+	page := &TaskPage{Tasks: []*models.Task{}, Total: len(matches)}
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return less(after.Key, after.ID, matches[i].key, matches[i].task.ID)
+		})
+	}
+
+	end := len(matches)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+
+	for _, match := range matches[start:end] {
+		page.Tasks = append(page.Tasks, match.task)
+	}
+
+	if end < len(matches) {
+		last := matches[end-1]
+		page.NextCursor = encodeCursor(&queryCursor{
+			SortBy:     sortBy,
+			Descending: query.Descending,
+			Key:        last.key,
+			ID:         last.task.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// matches reports whether task passes every filter in the query
+func (q TaskQuery) matches(task *models.Task, now time.Time) bool {
+	if len(q.Status) > 0 && !containsValue(q.Status, task.Status) {
+		return false
+	}
+	if len(q.Priority) > 0 && !containsValue(q.Priority, task.Priority) {
+		return false
+	}
+	if len(q.Type) > 0 && !containsValue(q.Type, task.Type) {
+		return false
+	}
+	if len(q.Labels) > 0 && !hasAnyLabel(task, q.Labels) {
+		return false
+	}
+	if q.ParentID != nil && task.ParentID != *q.ParentID {
+		return false
+	}
+	if q.AssigneeID != nil && task.AssigneeID != *q.AssigneeID {
+		return false
+	}
+	if q.ReporterID != nil && task.ReporterID != *q.ReporterID {
+		return false
+	}
+	if q.SprintID != nil && task.SprintID != *q.SprintID {
+		return false
+	}
+	if q.HasDueDate != nil && (task.DueDate != nil) != *q.HasDueDate {
+		return false
+	}
+	if q.Overdue != nil {
+		overdue := task.DueDate != nil && !models.IsDoneStatus(task.Status) && now.After(*task.DueDate)
+		if overdue != *q.Overdue {
+			return false
+		}
+	}
+
+	if !inRange(&task.CreatedAt, q.CreatedAfter, q.CreatedBefore) ||
+		!inRange(&task.UpdatedAt, q.UpdatedAfter, q.UpdatedBefore) ||
+		!inRange(task.DueDate, q.DueAfter, q.DueBefore) {
+		return false
+	}
+
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(task.Title), text) &&
+			!strings.Contains(strings.ToLower(task.Description), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasAnyLabel(task *models.Task, labels []string) bool {
+	for _, label := range labels {
+		if task.HasLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange reports whether t lies in [after, before). A nil t only matches
+// when neither bound is set.
+func inRange(t, after, before *time.Time) bool {
+	if after == nil && before == nil {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	if after != nil && t.Before(*after) {
+		return false
+	}
+	if before != nil && !t.Before(*before) {
+		return false
+	}
+	return true
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toStrings[T ~string](values []T) []string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = string(v)
+	}
+	return strs
+}
+
+// sortKeyTimeFormat is fixed width so formatted times sort as strings
+const sortKeyTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// sortKey returns a string that orders tasks by field when compared bytewise
+func sortKey(task *models.Task, field TaskSortField) string {
+	switch field {
+	case SortByUpdatedAt:
+		return task.UpdatedAt.UTC().Format(sortKeyTimeFormat)
+	case SortByDueDate:
+		if task.DueDate == nil {
+			// Tasks without a due date sort after every dated task
+			return "~"
+		}
+		return task.DueDate.UTC().Format(sortKeyTimeFormat)
+	case SortByPriority:
+		return strconv.Itoa(priorityRank(task.Priority))
+	case SortByStatus:
+		return strconv.Itoa(statusRank(task.Status))
+	case SortByTitle:
+		return strings.ToLower(task.Title)
+	default:
+		return task.CreatedAt.UTC().Format(sortKeyTimeFormat)
+	}
+}
+
+// priorityRank orders priorities from low to critical
+func priorityRank(priority models.TaskPriority) int {
+	switch priority {
+	case models.PriorityLow:
+		return 0
+	case models.PriorityMedium:
+		return 1
+	case models.PriorityHigh:
+		return 2
+	case models.PriorityCritical:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// statusRank orders statuses by their position in the workflow, unknown
+// statuses last
+func statusRank(status models.TaskStatus) int {
+	statuses := models.CurrentWorkflow().Statuses
+	for i, definition := range statuses {
+		if definition.ID == status {
+			return i
+		}
+	}
+	return len(statuses)
+}
+
+func encodeCursor(cursor *queryCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (*queryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor queryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// ParseTaskQuery builds a TaskQuery from named string parameters, as found in
+// a URL query string or tool arguments. get returns a parameter's value and
+// whether it was given. Lists are comma separated, booleans are "true" or
+// "false", times are RFC 3339 or YYYY-MM-DD, and sort names a TaskSortField
+// with an optional "-" prefix for descending order.
+func ParseTaskQuery(get func(name string) (string, bool)) (TaskQuery, error) {
+	var query TaskQuery
+
+	if value, ok := get("status"); ok && value != "" {
+		for _, status := range strings.Split(value, ",") {
+			if !models.IsValidStatus(status) {
+				return query, fmt.Errorf("invalid status: %s", status)
+			}
+			query.Status = append(query.Status, models.TaskStatus(status))
+		}
+	}
+	if value, ok := get("priority"); ok && value != "" {
+		for _, priority := range strings.Split(value, ",") {
+			if !models.IsValidPriority(priority) {
+				return query, fmt.Errorf("invalid priority: %s", priority)
+			}
+			query.Priority = append(query.Priority, models.TaskPriority(priority))
+		}
+	}
+	if value, ok := get("type"); ok && value != "" {
+		for _, taskType := range strings.Split(value, ",") {
+			if !models.IsValidType(taskType) {
+				return query, fmt.Errorf("invalid type: %s", taskType)
+			}
+			query.Type = append(query.Type, models.TaskType(taskType))
+		}
+	}
+	if value, ok := get("label"); ok && value != "" {
+		labels, err := models.NormalizeLabels(strings.Split(value, ","))
+		if err != nil {
+			return query, err
+		}
+		query.Labels = labels
+	}
+
+	if value, ok := get("parent_id"); ok {
+		query.ParentID = &value
+	}
+	if value, ok := get("assignee_id"); ok {
+		query.AssigneeID = &value
+	}
+	if value, ok := get("reporter_id"); ok {
+		query.ReporterID = &value
+	}
+	if value, ok := get("sprint_id"); ok {
+		query.SprintID = &value
+	}
+
+	var err error
+	if query.HasDueDate, err = parseBoolParam(get, "has_due_date"); err != nil {
+		return query, err
+	}
+	if query.Overdue, err = parseBoolParam(get, "overdue"); err != nil {
+		return query, err
+	}
+
+	times := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+		{"due_after", &query.DueAfter},
+		{"due_before", &query.DueBefore},
+	}
+	for _, param := range times {
+		if *param.target, err = parseTimeParam(get, param.name); err != nil {
+			return query, err
+		}
+	}
+
+	query.Text, _ = get("q")
+
+	if value, ok := get("sort"); ok && value != "" {
+		query.Descending = strings.HasPrefix(value, "-")
+		query.SortBy = TaskSortField(strings.TrimPrefix(value, "-"))
+		switch query.SortBy {
+		case SortByCreatedAt, SortByUpdatedAt, SortByDueDate, SortByPriority, SortByStatus, SortByTitle:
+		default:
+			return query, fmt.Errorf("invalid sort field: %s", query.SortBy)
+		}
+	}
+
+	if value, ok := get("limit"); ok && value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return query, fmt.Errorf("invalid limit: %s", value)
+		}
+		query.Limit = limit
+	}
+
+	query.Cursor, _ = get("cursor")
+
+	return query, nil
+}
+
+func parseBoolParam(get func(string) (string, bool), name string) (*bool, error) {
+	value, ok := get(name)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &parsed, nil
+}
+
+func parseTimeParam(get func(string) (string, bool), name string) (*time.Time, error) {
+	value, ok := get(name)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return &parsed, nil
+}
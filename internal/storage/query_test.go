@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// newQueryFixture creates an epic with three stories and returns their IDs
+// in creation order
+func newQueryFixture(t *testing.T, store Storage) []string {
+	t.Helper()
+
+	epic := models.NewTask("Checkout epic", "Everything about paying")
+	epic.Type = models.TypeEpic
+	epic.Priority = models.PriorityHigh
+	if err := store.CreateTask(epic); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	past := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().AddDate(1, 0, 0).UTC().Truncate(24 * time.Hour)
+	stories := []struct {
+		title    string
+		status   models.TaskStatus
+		priority models.TaskPriority
+		due      *time.Time
+	}{
+		{"Card payments", models.StatusInProgress, models.PriorityCritical, &past},
+		{"Refunds", models.StatusDone, models.PriorityLow, &past},
+		{"Receipts", models.StatusTodo, models.PriorityMedium, &future},
+	}
+
+	ids := []string{epic.ID}
+	for _, s := range stories {
+		// Keep creation times distinct so the default order is predictable
+		time.Sleep(time.Millisecond)
+		story := models.NewTask(s.title, "")
+		story.Type = models.TypeStory
+		story.ParentID = epic.ID
+		story.Status = s.status
+		story.Priority = s.priority
+		story.DueDate = s.due
+		if err := store.CreateTask(story); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		ids = append(ids, story.ID)
+	}
+	return ids
+}
+
+func TestQueryTasks(t *testing.T) {
+	yes, no := true, false
+	root := ""
+	cutoff := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ids := newQueryFixture(t, store)
+			epic, card, refunds, receipts := ids[0], ids[1], ids[2], ids[3]
+
+			tests := []struct {
+				name  string
+				query TaskQuery
+				want  []string
+			}{
+				{"everything in creation order", TaskQuery{}, []string{epic, card, refunds, receipts}},
+				{"status", TaskQuery{Status: []models.TaskStatus{models.StatusTodo, models.StatusDone}}, []string{epic, refunds, receipts}},
+				{"type", TaskQuery{Type: []models.TaskType{models.TypeEpic}}, []string{epic}},
+				{"priority", TaskQuery{Priority: []models.TaskPriority{models.PriorityCritical}}, []string{card}},
+				{"children", TaskQuery{ParentID: &epic}, []string{card, refunds, receipts}},
+				{"roots", TaskQuery{ParentID: &root}, []string{epic}},
+				{"has due date", TaskQuery{HasDueDate: &no}, []string{epic}},
+				{"overdue skips done tasks", TaskQuery{Overdue: &yes}, []string{card}},
+				{"due before", TaskQuery{DueBefore: &cutoff}, []string{card, refunds}},
+				{"due after", TaskQuery{DueAfter: &cutoff}, []string{receipts}},
+				{"text in title", TaskQuery{Text: "REFUND"}, []string{refunds}},
+				{"text in description", TaskQuery{Text: "paying"}, []string{epic}},
+				{"sort by priority descending", TaskQuery{SortBy: SortByPriority, Descending: true}, []string{card, epic, receipts, refunds}},
+				{"sort by title", TaskQuery{SortBy: SortByTitle}, []string{card, epic, receipts, refunds}},
+				{"sort by due date puts undated last", TaskQuery{SortBy: SortByDueDate, Status: []models.TaskStatus{models.StatusTodo}}, []string{receipts, epic}},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					page, err := store.QueryTasks(tt.query)
+					if err != nil {
+						t.Fatalf("QueryTasks() error = %v", err)
+					}
+					if got := taskIDs(page.Tasks); !sameOrder(got, tt.want) {
+						t.Errorf("QueryTasks() = %v, want %v", got, tt.want)
+					}
+					if page.Total != len(tt.want) {
+						t.Errorf("QueryTasks() total = %d, want %d", page.Total, len(tt.want))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestQueryTasks_Pagination(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ids := newQueryFixture(t, store)
+
+			var seen []string
+			query := TaskQuery{Limit: 3}
+			for pages := 0; ; pages++ {
+				if pages > len(ids) {
+					t.Fatal("pagination did not terminate")
+				}
+				page, err := store.QueryTasks(query)
+				if err != nil {
+					t.Fatalf("QueryTasks() error = %v", err)
+				}
+				if page.Total != len(ids) {
+					t.Errorf("QueryTasks() total = %d, want %d", page.Total, len(ids))
+				}
+				seen = append(seen, taskIDs(page.Tasks)...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+
+				// A task created mid-walk sorts after the cursor and still shows up
+				if pages == 0 {
+					time.Sleep(time.Millisecond)
+					late := models.NewTask("Late", "")
+					if err := store.CreateTask(late); err != nil {
+						t.Fatalf("CreateTask() error = %v", err)
+					}
+					ids = append(ids, late.ID)
+				}
+			}
+
+			if !sameOrder(seen, ids) {
+				t.Errorf("paged through %v, want %v", seen, ids)
+			}
+
+			_, err := store.QueryTasks(TaskQuery{Cursor: query.Cursor, SortBy: SortByTitle})
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("QueryTasks() with a cursor for another sort error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestParseTaskQuery(t *testing.T) {
+	params := map[string]string{
+		"status":        "todo,done",
+		"parent_id":     "",
+		"overdue":       "true",
+		"due_before":    "2025-07-01",
+		"created_after": "2025-01-02T03:04:05Z",
+		"sort":          "-priority",
+		"limit":         "10",
+		"label":         "web-ui, Backend",
+	}
+	get := func(name string) (string, bool) {
+		value, ok := params[name]
+		return value, ok
+	}
+
+	query, err := ParseTaskQuery(get)
+	if err != nil {
+		t.Fatalf("ParseTaskQuery() error = %v", err)
+	}
+	if len(query.Status) != 2 || query.Status[1] != models.StatusDone {
+		t.Errorf("Status = %v, want [todo done]", query.Status)
+	}
+	if query.ParentID == nil || *query.ParentID != "" {
+		t.Errorf("ParentID = %v, want root tasks", query.ParentID)
+	}
+	if query.Overdue == nil || !*query.Overdue {
+		t.Errorf("Overdue = %v, want true", query.Overdue)
+	}
+	if query.HasDueDate != nil {
+		t.Errorf("HasDueDate = %v, want unset", *query.HasDueDate)
+	}
+	if query.DueBefore == nil || !query.DueBefore.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("DueBefore = %v, want 2025-07-01", query.DueBefore)
+	}
+	if query.CreatedAfter == nil || query.CreatedAfter.Hour() != 3 {
+		t.Errorf("CreatedAfter = %v, want 2025-01-02T03:04:05Z", query.CreatedAfter)
+	}
+	if query.SortBy != SortByPriority || !query.Descending || query.Limit != 10 {
+		t.Errorf("sort = %v desc=%v limit=%d, want -priority limit 10", query.SortBy, query.Descending, query.Limit)
+	}
+	if !sameOrder(query.Labels, []string{"backend", "web-ui"}) {
+		t.Errorf("Labels = %v, want [backend web-ui]", query.Labels)
+	}
+
+	invalid := []map[string]string{
+		{"status": "finished"},
+		{"overdue": "maybe"},
+		{"due_after": "July"},
+		{"sort": "colour"},
+		{"limit": "-1"},
+		{"label": "front end"},
+	}
+	for _, p := range invalid {
+		params = p
+		if _, err := ParseTaskQuery(get); err == nil {
+			t.Errorf("ParseTaskQuery(%v) should fail", p)
+		}
+	}
+}
+
+func taskIDs(tasks []*models.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func sameOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// planUpdate works out the task writes needed to save task, reading the stored
+// tasks through get. The stored Children and Links are kept, a changed
+// ParentID is applied to both the old and the new parent, and every written
+// task moves to its next version. Backends apply the writes in a single
+// atomic unit.
+func planUpdate(get func(id string) (*models.Task, error), task *models.Task) ([]*models.Task, error) {
+	current, err := get(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(current, task.Version); err != nil {
+		return nil, err
+	}
+	task.Version = current.Version
+
+	// Children only change through the ParentID of the child, and links
+	// through LinkTasks and UnlinkTasks. The key never changes.
+	task.Children = current.Children
+	task.Links = current.Links
+	task.Key = current.Key
+	trackCompletion(current, task)
+
+	if task.Status != current.Status {
+		if err := checkTransition(get, current, task.Status); err != nil {
+			return nil, err
+		}
+		if err := checkBlockers(get, task, current); err != nil {
+			return nil, err
+		}
+	}
+
+	if task.Type != current.Type {
+		if err := checkChildTypes(get, task); err != nil {
+			return nil, err
+		}
+		if task.ParentID != "" && task.ParentID == current.ParentID {
+			if parent, err := get(task.ParentID); err == nil {
+				if err := checkParentType(task, parent); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	var writes []*models.Task
+	if task.ParentID != current.ParentID {
+		if task.ParentID != "" {
+			newParent, err := get(task.ParentID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrParentNotFound, task.ParentID)
+			}
+			if createsCycle(get, task.ID, task.ParentID) {
+				return nil, ErrCircularReference
+			}
+			if err := checkParentType(task, newParent); err != nil {
+				return nil, err
+			}
+			newParent.AddChild(task.ID)
+			writes = append(writes, newParent)
+		}
+
+		if current.ParentID != "" {
+			oldParent, err := get(current.ParentID)
+			if err == nil {
+				oldParent.RemoveChild(task.ID)
+				writes = append(writes, oldParent)
+			}
+		}
+	}
+
+	writes = append(writes, task)
+	bumpVersions(writes)
+	return writes, nil
+}
+
+// trackCompletion keeps task.CompletedAt in step with its status: the time is
+// recorded when the task moves into a done category, kept while it stays
+// there and cleared when it moves out. current is nil for a new task.
+func trackCompletion(current, task *models.Task) {
+	switch {
+	case !models.IsDoneStatus(task.Status):
+		task.CompletedAt = nil
+	case current != nil && models.IsDoneStatus(current.Status):
+		task.CompletedAt = current.CompletedAt
+		if task.CompletedAt == nil {
+			// Finished before completion times were recorded
+			completed := current.UpdatedAt
+			task.CompletedAt = &completed
+		}
+	case task.CompletedAt == nil:
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+}
+
+// createsCycle reports whether making parentID the parent of taskID would make
+// taskID its own ancestor. It walks up from parentID, so the cost is the depth
+// of the tree rather than the size of taskID's subtree.
+func createsCycle(get func(id string) (*models.Task, error), taskID, parentID string) bool {
+	visited := make(map[string]bool)
+	for id := parentID; id != ""; {
+		if id == taskID {
+			return true
+		}
+		if visited[id] {
+			// An existing cycle above parentID that doesn't involve taskID
+			return false
+		}
+		visited[id] = true
+
+		ancestor, err := get(id)
+		if err != nil {
+			return false
+		}
+		id = ancestor.ParentID
+	}
+	return false
+}
+
+// checkParentType returns ErrInvalidParentType when the workflow doesn't allow
+// child's type to be nested under parent's type
+func checkParentType(child, parent *models.Task) error {
+	if !models.CurrentWorkflow().AllowsParent(child.Type, parent.Type) {
+		return fmt.Errorf("%w: %s can't be a child of %s", ErrInvalidParentType, child.Type, parent.Type)
+	}
+	return nil
+}
+
+// checkChildTypes checks that every stored child of task may stay under it
+// once task has its new type
+func checkChildTypes(get func(id string) (*models.Task, error), task *models.Task) error {
+	for _, id := range task.Children {
+		child, err := get(id)
+		if err != nil {
+			continue
+		}
+		if err := checkParentType(child, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
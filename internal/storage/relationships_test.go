@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestUpdateTask_MovesBetweenParents(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			// Move child from parent to sibling
+			child, err := store.GetTask(f.child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			child.ParentID = f.sibling.ID
+			if err := store.UpdateTask(child); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+
+			oldParent, err := store.GetTask(f.parent.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if len(oldParent.Children) != 0 {
+				t.Errorf("old parent children = %v, want none", oldParent.Children)
+			}
+
+			newParent, err := store.GetTask(f.sibling.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalIDs(newParent.Children, []string{f.child.ID}) {
+				t.Errorf("new parent children = %v, want [%s]", newParent.Children, f.child.ID)
+			}
+
+			// The moved task keeps its own children
+			moved, err := store.GetTask(f.child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalIDs(moved.Children, []string{f.grandchild.ID}) {
+				t.Errorf("moved task children = %v, want [%s]", moved.Children, f.grandchild.ID)
+			}
+
+			// Detach it completely
+			moved.ParentID = ""
+			if err := store.UpdateTask(moved); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			newParent, err = store.GetTask(f.sibling.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if len(newParent.Children) != 0 {
+				t.Errorf("parent children after detach = %v, want none", newParent.Children)
+			}
+		})
+	}
+}
+
+func TestUpdateTask_RejectsCycles(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			tests := []struct {
+				name     string
+				taskID   string
+				parentID string
+			}{
+				{name: "self", taskID: f.parent.ID, parentID: f.parent.ID},
+				{name: "child", taskID: f.parent.ID, parentID: f.child.ID},
+				{name: "grandchild", taskID: f.root.ID, parentID: f.grandchild.ID},
+			}
+
+			for _, tt := range tests {
+				task, err := store.GetTask(tt.taskID)
+				if err != nil {
+					t.Fatalf("GetTask() error = %v", err)
+				}
+				task.ParentID = tt.parentID
+				if err := store.UpdateTask(task); !errors.Is(err, ErrCircularReference) {
+					t.Errorf("%s: UpdateTask() error = %v, want %v", tt.name, err, ErrCircularReference)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTask_RejectsMissingParent(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			task, err := store.GetTask(f.child.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			task.ParentID = "nonexistent-id"
+			if err := store.UpdateTask(task); !errors.Is(err, ErrParentNotFound) {
+				t.Errorf("UpdateTask() error = %v, want %v", err, ErrParentNotFound)
+			}
+		})
+	}
+}
+
+func TestUpdateTask_IgnoresChildrenEdits(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			// Claiming the grandchild through the Children slice must not
+			// create a one-sided relationship
+			root, err := store.GetTask(f.root.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			root.AddChild(f.grandchild.ID)
+			if err := store.UpdateTask(root); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+
+			root, err = store.GetTask(f.root.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if !equalIDs(root.Children, []string{f.parent.ID, f.sibling.ID}) {
+				t.Errorf("root children = %v, want parent and sibling", root.Children)
+			}
+		})
+	}
+}
+
+// setParentTypes switches to the default workflow with parentTypes as its
+// nesting rules for the rest of the test
+func setParentTypes(t *testing.T, parentTypes map[models.TaskType][]models.TaskType) {
+	t.Helper()
+	workflow := models.DefaultWorkflow()
+	workflow.ParentTypes = parentTypes
+	models.SetWorkflow(workflow)
+	t.Cleanup(func() { models.SetWorkflow(nil) })
+}
+
+func TestParentTypes(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+
+			// An epic can't be created under a subtask
+			epic := models.NewTask("Nested epic", "")
+			epic.Type = models.TypeEpic
+			epic.ParentID = f.grandchild.ID
+			if err := store.CreateTask(epic); !errors.Is(err, ErrInvalidParentType) {
+				t.Errorf("CreateTask() error = %v, want %v", err, ErrInvalidParentType)
+			}
+			if children, _ := store.GetTaskChildren(f.grandchild.ID); len(children) != 0 {
+				t.Errorf("subtask children = %d, want none", len(children))
+			}
+
+			tests := []struct {
+				name     string
+				taskID   string
+				parentID string
+				taskType models.TaskType
+			}{
+				{name: "story under story", taskID: f.parent.ID, parentID: f.sibling.ID},
+				{name: "task under epic", taskID: f.child.ID, parentID: f.root.ID},
+				{name: "subtask under story", taskID: f.grandchild.ID, parentID: f.sibling.ID},
+				{name: "type change under parent", taskID: f.child.ID, parentID: f.parent.ID, taskType: models.TypeEpic},
+				{name: "type change over children", taskID: f.child.ID, parentID: f.parent.ID, taskType: models.TypeStory},
+			}
+			for _, tt := range tests {
+				task, err := store.GetTask(tt.taskID)
+				if err != nil {
+					t.Fatalf("GetTask() error = %v", err)
+				}
+				task.ParentID = tt.parentID
+				if tt.taskType != "" {
+					task.Type = tt.taskType
+				}
+				if err := store.UpdateTask(task); !errors.Is(err, ErrInvalidParentType) {
+					t.Errorf("%s: UpdateTask() error = %v, want %v", tt.name, err, ErrInvalidParentType)
+				}
+			}
+
+			// Moving a task between stories is fine
+			child, _ := store.GetTask(f.child.ID)
+			child.ParentID = f.sibling.ID
+			if err := store.UpdateTask(child); err != nil {
+				t.Errorf("UpdateTask() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/aykay76/projectflow/internal/search"
+)
+
+// SearchResult is a task matched by SearchTasks together with the byte ranges
+// of the matched words in its title and description
+type SearchResult struct {
+	Task               *models.Task
+	Score              float64
+	TitleMatches       []search.Span
+	DescriptionMatches []search.Span
+}
+
+// searchIndex is the search index a backend keeps over its own tasks. The
+// backend hands it every change it commits along with the generation the
+// change moved the store to. A change made by another process moves the
+// generation on without it, so the index is rebuilt on the next search.
+type searchIndex struct {
+	mu         sync.Mutex
+	index      *search.Index
+	generation uint64
+}
+
+// search ranks the tasks matching query in a store at generation, first
+// rebuilding the index from load if it isn't up to date. The tasks of the
+// hits are read with get; any that can't be read are left out.
+func (si *searchIndex) search(generation uint64, load func() ([]*models.Task, error), get func(id string) (*models.Task, error), query string, limit int) ([]*SearchResult, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	if si.index == nil || si.generation != generation {
+		tasks, err := load()
+		if err != nil {
+			return nil, err
+		}
+		si.index = search.NewIndex()
+		for _, task := range tasks {
+			si.index.Add(task.ID, task.Title, task.Description)
+		}
+		si.generation = generation
+	}
+
+	hits := si.index.Search(query, limit)
+	tasks := make(map[string]*models.Task, len(hits))
+	for _, hit := range hits {
+		if task, err := get(hit.ID); err == nil {
+			tasks[hit.ID] = task
+		}
+	}
+	return searchResults(hits, tasks), nil
+}
+
+// current reports whether the index is up to date with generation, and so
+// wants the changes of the commit that follows it
+func (si *searchIndex) current(generation uint64) bool {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.index != nil && si.generation == generation
+}
+
+// update applies a commit that moved the store to generation. An index that
+// wasn't up to date with the generation before it has missed a change, so
+// it is dropped and rebuilt on the next search.
+func (si *searchIndex) update(generation uint64, changed []*models.Task, removed []string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	if si.index == nil {
+		return
+	}
+	if si.generation != generation-1 {
+		si.index = nil
+		return
+	}
+	for _, id := range removed {
+		si.index.Remove(id)
+	}
+	for _, task := range changed {
+		si.index.Add(task.ID, task.Title, task.Description)
+	}
+	si.generation = generation
+}
+
+// searchFilterParams are the ParseTaskQuery parameters that can narrow search
+// results
+var searchFilterParams = []string{"label", "assignee_id", "reporter_id"}
+
+// ParseSearchFilter reads the search filters from named parameters the way
+// ParseTaskQuery does. given is false when none of them was passed.
+func ParseSearchFilter(get func(name string) (string, bool)) (query TaskQuery, given bool, err error) {
+	query, err = ParseTaskQuery(func(name string) (string, bool) {
+		if !containsValue(searchFilterParams, name) {
+			return "", false
+		}
+		value, ok := get(name)
+		given = given || ok
+		return value, ok
+	})
+	return query, given, err
+}
+
+// FilterSearchResults keeps the results whose task passes the filters in
+// query, up to limit. Sorting and paging fields of query are ignored; a limit
+// of zero keeps every match.
+func FilterSearchResults(results []*SearchResult, query TaskQuery, limit int) []*SearchResult {
+	now := time.Now()
+	filtered := make([]*SearchResult, 0, len(results))
+	for _, result := range results {
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+		if query.matches(result.Task, now) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// searchResults pairs index hits with their tasks
+func searchResults(hits []search.Hit, tasks map[string]*models.Task) []*SearchResult {
+	results := make([]*SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		task, ok := tasks[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, &SearchResult{
+			Task:               task,
+			Score:              hit.Score,
+			TitleMatches:       hit.TitleMatches,
+			DescriptionMatches: hit.DescriptionMatches,
+		})
+	}
+	return results
+}
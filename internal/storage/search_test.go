@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestSearchTasks_FollowsWrites(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			payments := models.NewTask("Card payments", "Accept cards at checkout")
+			if err := store.CreateTask(payments); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			login := models.NewTask("Login page", "Password sign in")
+			if err := store.CreateTask(login); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+
+			results, err := store.SearchTasks("payment checkout", 0)
+			if err != nil {
+				t.Fatalf("SearchTasks() error = %v", err)
+			}
+			if len(results) != 1 || results[0].Task.ID != payments.ID {
+				t.Fatalf("SearchTasks() = %v, want the payments task", results)
+			}
+			if len(results[0].TitleMatches) != 1 || len(results[0].DescriptionMatches) != 1 {
+				t.Errorf("SearchTasks() matches = %v/%v, want one in each field",
+					results[0].TitleMatches, results[0].DescriptionMatches)
+			}
+
+			login.Description = "Password sign in, then redirect to checkout"
+			if err := store.UpdateTask(login); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			results, err = store.SearchTasks("checkout ", 0)
+			if err != nil {
+				t.Fatalf("SearchTasks() error = %v", err)
+			}
+			if len(results) != 2 {
+				t.Errorf("SearchTasks() after update found %d tasks, want 2", len(results))
+			}
+
+			if err := store.DeleteTask(payments.ID); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			results, err = store.SearchTasks("checkout ", 0)
+			if err != nil {
+				t.Fatalf("SearchTasks() error = %v", err)
+			}
+			if len(results) != 1 || results[0].Task.ID != login.ID {
+				t.Errorf("SearchTasks() after delete = %v, want only the login task", results)
+			}
+		})
+	}
+}
+
+func TestSearchTasks_KeepsIndex(t *testing.T) {
+	fileDir := t.TempDir()
+	sqliteDir := t.TempDir()
+	backends := map[string]func(t *testing.T) (Storage, *searchIndex){
+		BackendFile: func(t *testing.T) (Storage, *searchIndex) {
+			store, err := NewFileStorage(fileDir)
+			if err != nil {
+				t.Fatalf("Failed to create storage: %v", err)
+			}
+			return store, &store.search
+		},
+		BackendSQLite: func(t *testing.T) (Storage, *searchIndex) {
+			store, err := NewSQLiteStorage(sqliteDir)
+			if err != nil {
+				t.Fatalf("Failed to create storage: %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store, &store.search
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			// Two stores over the same data stand in for two processes
+			store, index := open(t)
+			other, _ := open(t)
+
+			createTasks(t, store, "Card payments")
+			if results, err := store.SearchTasks("payments", 0); err != nil || len(results) != 1 {
+				t.Fatalf("SearchTasks() = %v, %v, want the payments task", results, err)
+			}
+			built := index.index
+
+			// The store's own writes update the index in place
+			refunds := createTasks(t, store, "Card refunds")[0]
+			refunds.Title = "Card refunds and payments"
+			if err := store.UpdateTask(refunds); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if results, err := store.SearchTasks("payments", 0); err != nil || len(results) != 2 {
+				t.Errorf("SearchTasks() after an update = %v, %v, want both tasks", results, err)
+			}
+			if index.index != built {
+				t.Error("SearchTasks() rebuilt the index after the store's own writes")
+			}
+
+			// Another process's write has the index rebuilt
+			createTasks(t, other, "Payments report")
+			if results, err := store.SearchTasks("payments", 0); err != nil || len(results) != 3 {
+				t.Errorf("SearchTasks() after another process wrote = %v, %v, want all three tasks", results, err)
+			}
+		})
+	}
+}
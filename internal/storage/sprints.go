@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkSprint trims a sprint's name and checks its fields
+func checkSprint(sprint *models.Sprint) error {
+	sprint.Name = strings.TrimSpace(sprint.Name)
+	if sprint.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSprint)
+	}
+	if sprint.StartDate.IsZero() || sprint.EndDate.IsZero() {
+		return fmt.Errorf("%w: start and end dates are required", ErrInvalidSprint)
+	}
+	if sprint.EndDate.Before(sprint.StartDate) {
+		return fmt.Errorf("%w: end date is before the start date", ErrInvalidSprint)
+	}
+	return nil
+}
+
+// checkTaskSprint returns ErrSprintNotFound when task is in a sprint that
+// doesn't exist and ErrSprintState when it moves into a closed one.
+// previousID is the sprint the stored task is in, empty for a new task.
+func checkTaskSprint(getSprint func(id string) (*models.Sprint, error), task *models.Task, previousID string) error {
+	if task.SprintID == "" || task.SprintID == previousID {
+		return nil
+	}
+	sprint, err := getSprint(task.SprintID)
+	if err != nil {
+		return err
+	}
+	if sprint.State == models.SprintClosed {
+		return fmt.Errorf("%w: sprint %q is closed", ErrSprintState, sprint.Name)
+	}
+	return nil
+}
+
+// storedSprintID returns the sprint the stored copy of a task is in, or an
+// empty string when it can't be read
+func storedSprintID(get func(id string) (*models.Task, error), id string) string {
+	if stored, err := get(id); err == nil {
+		return stored.SprintID
+	}
+	return ""
+}
+
+// updateSprint copies the name, goal and dates of sprint onto stored and
+// then stored back onto sprint, so the state and outcome the storage keeps
+// can't be changed by an update
+func updateSprint(stored, sprint *models.Sprint) {
+	stored.Name, stored.Goal = sprint.Name, sprint.Goal
+	stored.StartDate, stored.EndDate = sprint.StartDate, sprint.EndDate
+	stored.UpdatedAt = time.Now()
+	*sprint = *stored
+}
+
+// startSprint starts a planned sprint with tasks, the tasks in it. sprints
+// holds every sprint, to check no other is active.
+func startSprint(sprint *models.Sprint, sprints []*models.Sprint, tasks []*models.Task) error {
+	if sprint.State != models.SprintPlanned {
+		return fmt.Errorf("%w: sprint %q is %s", ErrSprintState, sprint.Name, sprint.State)
+	}
+	for _, other := range sprints {
+		if other.State == models.SprintActive && other.ID != sprint.ID {
+			return fmt.Errorf("%w: %s", ErrSprintActive, other.Name)
+		}
+	}
+	sprint.Start(tasks, time.Now())
+	return nil
+}
+
+// closeSprint closes the active sprint, carrying the unfinished tasks among
+// tasks, the tasks in it, over to the sprint picked by nextSprint. It
+// returns the task writes.
+func closeSprint(sprint *models.Sprint, nextID string, sprints []*models.Sprint, tasks []*models.Task) ([]*models.Task, error) {
+	if sprint.State != models.SprintActive {
+		return nil, fmt.Errorf("%w: sprint %q is %s", ErrSprintState, sprint.Name, sprint.State)
+	}
+	nextID, err := nextSprint(nextID, sprints)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := sprint.Close(tasks, nextID, time.Now())
+	bumpVersions(writes)
+	return writes, nil
+}
+
+// nextSprint picks the sprint unfinished tasks are carried over to: nextID
+// when given, which must be planned, or else the planned sprint that starts
+// first. It returns an empty ID, the backlog, when there is none.
+func nextSprint(nextID string, sprints []*models.Sprint) (string, error) {
+	if nextID != "" {
+		for _, sprint := range sprints {
+			if sprint.ID != nextID {
+				continue
+			}
+			if sprint.State != models.SprintPlanned {
+				return "", fmt.Errorf("%w: can't carry tasks over to sprint %q, which is %s", ErrSprintState, sprint.Name, sprint.State)
+			}
+			return nextID, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrSprintNotFound, nextID)
+	}
+
+	sortSprints(sprints)
+	for _, sprint := range sprints {
+		if sprint.State == models.SprintPlanned {
+			return sprint.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// leaveSprint moves every task in tasks that is in sprintID to the backlog
+// and returns the tasks to write
+func leaveSprint(tasks []*models.Task, sprintID string) []*models.Task {
+	now := time.Now()
+	var writes []*models.Task
+	for _, task := range tasks {
+		if task.SprintID == sprintID {
+			task.SprintID = ""
+			task.UpdatedAt = now
+			writes = append(writes, task)
+		}
+	}
+
+	bumpVersions(writes)
+	return writes
+}
+
+// tasksInSprint returns the tasks in sprintID
+func tasksInSprint(tasks []*models.Task, sprintID string) []*models.Task {
+	var in []*models.Task
+	for _, task := range tasks {
+		if task.SprintID == sprintID {
+			in = append(in, task)
+		}
+	}
+	return in
+}
+
+// sortSprints orders sprints by start date, then name and ID
+func sortSprints(sprints []*models.Sprint) {
+	sort.Slice(sprints, func(i, j int) bool {
+		a, b := sprints[i], sprints[j]
+		if !a.StartDate.Equal(b.StartDate) {
+			return a.StartDate.Before(b.StartDate)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
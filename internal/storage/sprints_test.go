@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func createSprints(t *testing.T, store Storage, names ...string) []*models.Sprint {
+	t.Helper()
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	sprints := make([]*models.Sprint, 0, len(names))
+	for i, name := range names {
+		begin := start.AddDate(0, 0, 14*i)
+		sprint := models.NewSprint(name, "", begin, begin.AddDate(0, 0, 13))
+		if err := store.CreateSprint(sprint); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		sprints = append(sprints, sprint)
+	}
+	return sprints
+}
+
+func TestSprints(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			// Created out of order, listed by start date
+			sprints := createSprints(t, store, "Sprint 1", "Sprint 2")
+			early := models.NewSprint("Sprint 0", "Set up", sprints[0].StartDate.AddDate(0, 0, -14), sprints[0].StartDate.AddDate(0, 0, -1))
+			if err := store.CreateSprint(early); err != nil {
+				t.Fatalf("CreateSprint() error = %v", err)
+			}
+			listed, err := store.ListSprints()
+			if err != nil {
+				t.Fatalf("ListSprints() error = %v", err)
+			}
+			if len(listed) != 3 || listed[0].Name != "Sprint 0" || listed[2].Name != "Sprint 2" {
+				t.Errorf("ListSprints() = %v, want sprints 0, 1 and 2", listed)
+			}
+
+			for _, invalid := range []*models.Sprint{
+				models.NewSprint(" ", "", early.StartDate, early.EndDate),
+				models.NewSprint("Backwards", "", early.EndDate, early.StartDate),
+				{Name: "Undated"},
+			} {
+				if err := store.CreateSprint(invalid); !errors.Is(err, ErrInvalidSprint) {
+					t.Errorf("CreateSprint(%q) error = %v, want ErrInvalidSprint", invalid.Name, err)
+				}
+			}
+
+			// Updates can't change the state
+			update := early.Clone()
+			update.Goal = "Set up the repo"
+			update.State = models.SprintClosed
+			if err := store.UpdateSprint(update); err != nil {
+				t.Fatalf("UpdateSprint() error = %v", err)
+			}
+			got, err := store.GetSprint(early.ID)
+			if err != nil {
+				t.Fatalf("GetSprint() error = %v", err)
+			}
+			if got.Goal != "Set up the repo" || got.State != models.SprintPlanned {
+				t.Errorf("GetSprint() = %+v, want the new goal and still planned", got)
+			}
+
+			task := models.NewTask("Planned", "")
+			task.SprintID = early.ID
+			if err := store.CreateTask(task); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			if err := store.DeleteSprint(early.ID); err != nil {
+				t.Fatalf("DeleteSprint() error = %v", err)
+			}
+			if _, err := store.GetSprint(early.ID); !errors.Is(err, ErrSprintNotFound) {
+				t.Errorf("GetSprint(deleted) error = %v, want ErrSprintNotFound", err)
+			}
+			task, err = store.GetTask(task.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			if task.SprintID != "" {
+				t.Errorf("task sprint = %q after its sprint was deleted, want the backlog", task.SprintID)
+			}
+
+			task.SprintID = "missing"
+			if err := store.UpdateTask(task); !errors.Is(err, ErrSprintNotFound) {
+				t.Errorf("UpdateTask() into a missing sprint error = %v, want ErrSprintNotFound", err)
+			}
+			if _, err := store.GetSprint("../tasks"); !errors.Is(err, ErrSprintNotFound) {
+				t.Errorf("GetSprint(../tasks) error = %v, want ErrSprintNotFound", err)
+			}
+		})
+	}
+}
+
+func TestSprintLifecycle(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			sprints := createSprints(t, store, "Sprint 1", "Sprint 2", "Sprint 3")
+			first, second, third := sprints[0], sprints[1], sprints[2]
+
+			var tasks []*models.Task
+			for i, title := range []string{"Done", "Started", "Untouched"} {
+				task := models.NewTask(title, "")
+				task.SprintID = first.ID
+				task.StoryPoints = float64(i + 1)
+				if err := store.CreateTask(task); err != nil {
+					t.Fatalf("CreateTask() error = %v", err)
+				}
+				tasks = append(tasks, task)
+			}
+			backlog := createTasks(t, store, "Backlog")[0]
+
+			started, err := store.StartSprint(first.ID)
+			if err != nil {
+				t.Fatalf("StartSprint() error = %v", err)
+			}
+			if started.State != models.SprintActive || len(started.Committed) != 3 || started.CommittedPoints != 6 {
+				t.Errorf("StartSprint() = %+v, want active with 3 tasks and 6 points committed", started)
+			}
+			if _, err := store.StartSprint(second.ID); !errors.Is(err, ErrSprintActive) {
+				t.Errorf("StartSprint() while another is active error = %v, want ErrSprintActive", err)
+			}
+			if _, err := store.StartSprint(first.ID); !errors.Is(err, ErrSprintState) {
+				t.Errorf("StartSprint(active) error = %v, want ErrSprintState", err)
+			}
+			if err := store.DeleteSprint(first.ID); !errors.Is(err, ErrSprintState) {
+				t.Errorf("DeleteSprint(active) error = %v, want ErrSprintState", err)
+			}
+			if _, err := store.CloseSprint(second.ID, ""); !errors.Is(err, ErrSprintState) {
+				t.Errorf("CloseSprint(planned) error = %v, want ErrSprintState", err)
+			}
+
+			done, inProgress := tasks[0], tasks[1]
+			done.Status = models.StatusDone
+			inProgress.Status = models.StatusInProgress
+			for _, task := range []*models.Task{done, inProgress} {
+				if err := store.UpdateTask(task); err != nil {
+					t.Fatalf("UpdateTask() error = %v", err)
+				}
+			}
+
+			// Without a next sprint named, the earliest planned one is used
+			closed, err := store.CloseSprint(first.ID, "")
+			if err != nil {
+				t.Fatalf("CloseSprint() error = %v", err)
+			}
+			if closed.State != models.SprintClosed || closed.ClosedAt == nil ||
+				len(closed.Completed) != 1 || closed.CompletedPoints != 1 ||
+				len(closed.CarriedOver) != 2 || closed.CarriedOverTo != second.ID {
+				t.Errorf("CloseSprint() = %+v, want 1 task completed and 2 carried over to sprint 2", closed)
+			}
+
+			page, err := store.QueryTasks(TaskQuery{SprintID: &second.ID})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 2 {
+				t.Errorf("QueryTasks(sprint 2) = %d tasks, want the 2 carried over", page.Total)
+			}
+			wantVersions := map[string]int64{"Started": 3, "Untouched": 2}
+			for _, task := range page.Tasks {
+				if task.Version != wantVersions[task.Title] {
+					t.Errorf("carried over task %q is at version %d, want %d", task.Title, task.Version, wantVersions[task.Title])
+				}
+			}
+			page, err = store.QueryTasks(TaskQuery{SprintID: &first.ID})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 1 || page.Tasks[0].ID != done.ID {
+				t.Errorf("QueryTasks(sprint 1) = %v, want only the done task", page.Tasks)
+			}
+			empty := ""
+			page, err = store.QueryTasks(TaskQuery{SprintID: &empty})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 1 || page.Tasks[0].ID != backlog.ID {
+				t.Errorf("QueryTasks(backlog) = %v, want only the backlog task", page.Tasks)
+			}
+
+			// Tasks can't be moved into a closed sprint
+			backlog.SprintID = first.ID
+			if err := store.UpdateTask(backlog); !errors.Is(err, ErrSprintState) {
+				t.Errorf("UpdateTask() into a closed sprint error = %v, want ErrSprintState", err)
+			}
+
+			// A named next sprint must be planned
+			if _, err := store.StartSprint(second.ID); err != nil {
+				t.Fatalf("StartSprint() error = %v", err)
+			}
+			if _, err := store.CloseSprint(second.ID, first.ID); !errors.Is(err, ErrSprintState) {
+				t.Errorf("CloseSprint() into a closed sprint error = %v, want ErrSprintState", err)
+			}
+			if _, err := store.CloseSprint(second.ID, third.ID); err != nil {
+				t.Fatalf("CloseSprint() error = %v", err)
+			}
+			page, err = store.QueryTasks(TaskQuery{SprintID: &third.ID})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 2 {
+				t.Errorf("QueryTasks(sprint 3) = %d tasks, want the 2 carried over again", page.Total)
+			}
+
+			// With no planned sprint left, unfinished tasks go to the backlog
+			if _, err := store.StartSprint(third.ID); err != nil {
+				t.Fatalf("StartSprint() error = %v", err)
+			}
+			closed, err = store.CloseSprint(third.ID, "")
+			if err != nil {
+				t.Fatalf("CloseSprint() error = %v", err)
+			}
+			if closed.CarriedOverTo != "" || len(closed.CarriedOver) != 2 {
+				t.Errorf("CloseSprint() = %+v, want 2 tasks carried over to the backlog", closed)
+			}
+			page, err = store.QueryTasks(TaskQuery{SprintID: &empty})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 3 {
+				t.Errorf("QueryTasks(backlog) = %d tasks, want 3", page.Total)
+			}
+		})
+	}
+}
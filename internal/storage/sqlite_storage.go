@@ -0,0 +1,2027 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used by SQLiteStorage. Each task row keeps
+// the full JSON document alongside the columns that are queried directly,
+// while parent/child edges live in their own table so children can be read
+// without loading every task.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id         TEXT PRIMARY KEY,
+	key        TEXT,
+	parent_id  TEXT NOT NULL DEFAULT '',
+	status     TEXT NOT NULL,
+	priority   TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+
+CREATE TABLE IF NOT EXISTS task_edges (
+	parent_id TEXT NOT NULL,
+	child_id  TEXT NOT NULL,
+	position  INTEGER NOT NULL,
+	PRIMARY KEY (parent_id, child_id)
+);
+CREATE INDEX IF NOT EXISTS idx_task_edges_child_id ON task_edges(child_id);
+
+CREATE TABLE IF NOT EXISTS users (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS labels (
+	name TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS worklogs (
+	id      TEXT PRIMARY KEY,
+	task_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	running INTEGER NOT NULL,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_worklogs_task_id ON worklogs(task_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_worklogs_running_timer ON worklogs(user_id) WHERE running = 1;
+
+CREATE TABLE IF NOT EXISTS comments (
+	id        TEXT PRIMARY KEY,
+	task_id   TEXT NOT NULL,
+	parent_id TEXT NOT NULL DEFAULT '',
+	data      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_comments_task_id ON comments(task_id);
+
+CREATE TABLE IF NOT EXISTS sprints (
+	id    TEXT PRIMARY KEY,
+	state TEXT NOT NULL,
+	data  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS milestones (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_events (
+	seq        INTEGER PRIMARY KEY,
+	id         TEXT NOT NULL UNIQUE,
+	task_id    TEXT NOT NULL,
+	actor_id   TEXT NOT NULL DEFAULT '',
+	source     TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_events_created_at ON task_events(created_at);
+
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value INTEGER NOT NULL
+);
+
+-- task_changes collects the tasks a write transaction touches, with each
+-- one's data before the write, so they can be passed on to the search index
+-- and recorded in the history. The transaction empties it before committing.
+CREATE TABLE IF NOT EXISTS task_changes (
+	id     TEXT NOT NULL,
+	before TEXT
+);
+CREATE TRIGGER IF NOT EXISTS task_changes_insert AFTER INSERT ON tasks BEGIN
+	INSERT INTO task_changes (id, before) VALUES (NEW.id, NULL);
+END;
+CREATE TRIGGER IF NOT EXISTS task_changes_update AFTER UPDATE ON tasks BEGIN
+	INSERT INTO task_changes (id, before) VALUES (NEW.id, OLD.data);
+END;
+CREATE TRIGGER IF NOT EXISTS task_changes_delete AFTER DELETE ON tasks BEGIN
+	INSERT INTO task_changes (id, before) VALUES (OLD.id, OLD.data);
+END;
+`
+
+// sqliteFileName is the database file created inside the data directory
+const sqliteFileName = "projectflow.db"
+
+// SQLiteStorage implements the Storage interface using a SQLite database
+type SQLiteStorage struct {
+	*sqliteState
+	// actor is who changes made through this view are recorded as made by;
+	// nil records no history
+	actor *Actor
+}
+
+// sqliteState is shared by a SQLiteStorage and its views from WithActor
+type sqliteState struct {
+	dataDir string
+	db      *sql.DB
+	mu      sync.RWMutex
+	search  searchIndex
+}
+
+// NewSQLiteStorage creates a new SQLite-backed storage instance in dataDir
+func NewSQLiteStorage(dataDir string) (*SQLiteStorage, error) {
+	// Create data directory if it doesn't exist
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	// busy_timeout lets the web server and MCP server share one database file
+	dsn := "file:" + filepath.Join(dataDir, sqliteFileName) +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	ss := &SQLiteStorage{
+		sqliteState: &sqliteState{dataDir: dataDir, db: db},
+	}
+	// Taking the write lock first keeps a second process opening the
+	// database at the same time from racing the migration or the counter
+	err = ss.withWriteTx(func(tx *sql.Tx) error {
+		if err := migrateTaskKeysTx(tx); err != nil {
+			return err
+		}
+		return assignKeysTx(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return ss, nil
+}
+
+// WithActor returns a view of the storage that records its changes in the
+// task history as made by actor
+func (ss *SQLiteStorage) WithActor(actor Actor) Storage {
+	return &SQLiteStorage{sqliteState: ss.sqliteState, actor: &actor}
+}
+
+// CreateTask creates a new task and assigns it an ID
+func (ss *SQLiteStorage) CreateTask(task *models.Task) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	// Generate UUID for new task
+	task.ID = uuid.New().String()
+	task.Version = 1
+	trackCompletion(nil, task)
+
+	return ss.withWriteTx(func(tx *sql.Tx) error {
+		userExists := func(id string) bool { return userExistsTx(tx, id) }
+		if err := checkUsers(userExists, task); err != nil {
+			return err
+		}
+		labelExists := func(name string) bool { return labelExistsTx(tx, name) }
+		if err := checkLabels(labelExists, task); err != nil {
+			return err
+		}
+		getSprint := func(id string) (*models.Sprint, error) { return getSprintTx(tx, id) }
+		if err := checkTaskSprint(getSprint, task, ""); err != nil {
+			return err
+		}
+
+		// If this task has a parent, add it to parent's children
+		if task.ParentID != "" {
+			parent, err := getTaskTx(tx, task.ParentID)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrParentNotFound, task.ParentID)
+			}
+			if err := checkParentType(task, parent); err != nil {
+				return err
+			}
+			parent.AddChild(task.ID)
+			parent.Version++
+			if err := saveTaskTx(tx, parent); err != nil {
+				return fmt.Errorf("failed to update parent task: %w", err)
+			}
+		}
+
+		number, err := nextKeyNumberTx(tx)
+		if err != nil {
+			return err
+		}
+		task.Key = models.CurrentWorkflow().TaskKey(number)
+		return saveTaskTx(tx, task)
+	})
+}
+
+// GetTask retrieves a task by ID
+func (ss *SQLiteStorage) GetTask(id string) (*models.Task, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var task *models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		task, err = getTaskTx(tx, id)
+		return err
+	})
+	return task, err
+}
+
+// GetTaskByKey retrieves a task by key
+func (ss *SQLiteStorage) GetTaskByKey(key string) (*models.Task, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var task *models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var id string
+		err := tx.QueryRow(`SELECT id FROM tasks WHERE key = ?`, key).Scan(&id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("task not found: %s", key)
+			}
+			return fmt.Errorf("failed to read task: %w", err)
+		}
+		task, err = getTaskTx(tx, id)
+		return err
+	})
+	return task, err
+}
+
+// UpdateTask updates an existing task
+func (ss *SQLiteStorage) UpdateTask(task *models.Task) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withWriteTx(func(tx *sql.Tx) error {
+		userExists := func(id string) bool { return userExistsTx(tx, id) }
+		if err := checkUsers(userExists, task); err != nil {
+			return err
+		}
+		labelExists := func(name string) bool { return labelExistsTx(tx, name) }
+		if err := checkLabels(labelExists, task); err != nil {
+			return err
+		}
+
+		get := func(id string) (*models.Task, error) {
+			return getTaskTx(tx, id)
+		}
+		getSprint := func(id string) (*models.Sprint, error) { return getSprintTx(tx, id) }
+		if err := checkTaskSprint(getSprint, task, storedSprintID(get, task.ID)); err != nil {
+			return err
+		}
+		writes, err := planUpdate(get, task)
+		if err != nil {
+			return err
+		}
+
+		for _, write := range writes {
+			if err := saveTaskTx(tx, write); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTask deletes a task together with its entire subtree
+func (ss *SQLiteStorage) DeleteTask(id string) error {
+	_, err := ss.DeleteTaskWithStrategy(id, DeleteCascade, 0)
+	return err
+}
+
+// DeleteTaskWithStrategy deletes a task, removes it from its parent's children
+// and deletes or moves its children according to strategy
+func (ss *SQLiteStorage) DeleteTaskWithStrategy(id string, strategy DeleteStrategy, expectedVersion int64) (*DeleteResult, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var result *DeleteResult
+	err := ss.withWriteTx(func(tx *sql.Tx) error {
+		get := func(id string) (*models.Task, error) {
+			return getTaskTx(tx, id)
+		}
+		plan, err := planDelete(get, id, strategy, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range plan.writes {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+		for _, deletedID := range plan.result.Deleted {
+			if err := deleteTaskTx(tx, deletedID); err != nil {
+				return err
+			}
+		}
+
+		result = plan.result
+		return nil
+	})
+	return result, err
+}
+
+// LinkTasks links fromID to toID, storing the inverse link on toID
+func (ss *SQLiteStorage) LinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	return ss.writeLinks(func(get func(id string) (*models.Task, error)) ([]*models.Task, error) {
+		return planLink(get, fromID, linkType, toID)
+	})
+}
+
+// UnlinkTasks removes a link and its inverse
+func (ss *SQLiteStorage) UnlinkTasks(fromID string, linkType models.LinkType, toID string) error {
+	return ss.writeLinks(func(get func(id string) (*models.Task, error)) ([]*models.Task, error) {
+		return planUnlink(get, fromID, linkType, toID)
+	})
+}
+
+// writeLinks saves the tasks planned by plan in one transaction
+func (ss *SQLiteStorage) writeLinks(plan func(get func(id string) (*models.Task, error)) ([]*models.Task, error)) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withWriteTx(func(tx *sql.Tx) error {
+		writes, err := plan(func(id string) (*models.Task, error) {
+			return getTaskTx(tx, id)
+		})
+		if err != nil {
+			return err
+		}
+		for _, task := range writes {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListTasks returns all tasks
+func (ss *SQLiteStorage) ListTasks() ([]*models.Task, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var tasks []*models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		tasks, err = listTasksTx(tx)
+		return err
+	})
+	return tasks, err
+}
+
+// QueryTasks returns the page of tasks selected by query. The indexed columns
+// narrow the rows read from the database before the rest of the query is
+// applied in memory.
+func (ss *SQLiteStorage) QueryTasks(query TaskQuery) (*TaskPage, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	addIn := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+		conditions = append(conditions, column+" IN ("+placeholders+")")
+		for _, value := range values {
+			args = append(args, value)
+		}
+	}
+	addIn("status", toStrings(query.Status))
+	addIn("priority", toStrings(query.Priority))
+	addIn("type", toStrings(query.Type))
+	if len(query.Labels) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(query.Labels)), ",")
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(tasks.data, '$.labels') WHERE value IN ("+placeholders+"))")
+		for _, label := range query.Labels {
+			args = append(args, label)
+		}
+	}
+	if query.ParentID != nil {
+		conditions = append(conditions, "parent_id = ?")
+		args = append(args, *query.ParentID)
+	}
+	if query.SprintID != nil {
+		conditions = append(conditions, sprintCondition)
+		args = append(args, *query.SprintID)
+	}
+
+	var tasks []*models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		tasks, err = selectTasksTx(tx, strings.Join(conditions, " AND "), args)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ApplyQuery(tasks, query)
+}
+
+// SearchTasks ranks tasks by how well they match query
+func (ss *SQLiteStorage) SearchTasks(query string, limit int) ([]*SearchResult, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var results []*SearchResult
+	err := ss.withTx(func(tx *sql.Tx) error {
+		generation, err := generationTx(tx)
+		if err != nil {
+			return err
+		}
+		load := func() ([]*models.Task, error) { return listTasksTx(tx) }
+		get := func(id string) (*models.Task, error) { return getTaskTx(tx, id) }
+		results, err = ss.search.search(generation, load, get, query, limit)
+		return err
+	})
+	return results, err
+}
+
+// CreateUser creates a new user and assigns it an ID. Users aren't cached, so
+// user writes leave the generation alone.
+func (ss *SQLiteStorage) CreateUser(user *models.User) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user.ID = uuid.New().String()
+	return ss.withTx(func(tx *sql.Tx) error {
+		return saveUserTx(tx, user)
+	})
+}
+
+// GetUser retrieves a user by ID
+func (ss *SQLiteStorage) GetUser(id string) (*models.User, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var data string
+	err := ss.db.QueryRow(`SELECT data FROM users WHERE id = ?`, id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+
+	var user models.User
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	return &user, nil
+}
+
+// UpdateUser updates an existing user
+func (ss *SQLiteStorage) UpdateUser(user *models.User) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		if !userExistsTx(tx, user.ID) {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, user.ID)
+		}
+		return saveUserTx(tx, user)
+	})
+}
+
+// DeleteUser deletes a user that no task refers to
+func (ss *SQLiteStorage) DeleteUser(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		if !userExistsTx(tx, id) {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, id)
+		}
+
+		// Tasks can also refer to the user in the custom fields of type user
+		where := `json_extract(data, '$.assignee_id') = ? OR json_extract(data, '$.reporter_id') = ?`
+		args := []interface{}{id, id}
+		for taskType, fields := range models.CurrentWorkflow().CustomFields {
+			for _, field := range fields {
+				if field.Type == models.FieldUser {
+					where += ` OR (json_extract(data, '$.type') = ? AND json_extract(data, ?) = ?)`
+					args = append(args, string(taskType), "$.custom_fields."+field.Name, id)
+				}
+			}
+		}
+
+		var inUse int
+		err := tx.QueryRow(`SELECT 1 FROM tasks WHERE `+where+` LIMIT 1`, args...).Scan(&inUse)
+		if err == nil {
+			return fmt.Errorf("%w: %s", ErrUserInUse, id)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to check user tasks: %w", err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListUsers returns every user ordered by name
+func (ss *SQLiteStorage) ListUsers() ([]*models.User, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	rows, err := ss.db.Query(`SELECT data FROM users`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		var user models.User
+		if err := json.Unmarshal([]byte(data), &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortUsers(users)
+	return users, nil
+}
+
+// CreateLabel registers a new label. Like users, labels aren't cached.
+func (ss *SQLiteStorage) CreateLabel(label *models.Label) error {
+	if err := checkLabel(label); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		if labelExistsTx(tx, label.Name) {
+			return fmt.Errorf("%w: %s", ErrLabelExists, label.Name)
+		}
+		return saveLabelTx(tx, label)
+	})
+}
+
+// GetLabel retrieves a label by name
+func (ss *SQLiteStorage) GetLabel(name string) (*models.Label, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var data string
+	err := ss.db.QueryRow(`SELECT data FROM labels WHERE name = ?`, name).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read label: %w", err)
+	}
+
+	var label models.Label
+	if err := json.Unmarshal([]byte(data), &label); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal label: %w", err)
+	}
+	return &label, nil
+}
+
+// UpdateLabel updates an existing label's colour and description
+func (ss *SQLiteStorage) UpdateLabel(label *models.Label) error {
+	if err := checkLabel(label); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		if !labelExistsTx(tx, label.Name) {
+			return fmt.Errorf("%w: %s", ErrLabelNotFound, label.Name)
+		}
+		return saveLabelTx(tx, label)
+	})
+}
+
+// DeleteLabel removes a label from every task that carries it, then deletes it
+func (ss *SQLiteStorage) DeleteLabel(name string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withWriteTx(func(tx *sql.Tx) error {
+		if !labelExistsTx(tx, name) {
+			return fmt.Errorf("%w: %s", ErrLabelNotFound, name)
+		}
+
+		tasks, err := selectTasksTx(tx, "EXISTS (SELECT 1 FROM json_each(tasks.data, '$.labels') WHERE value = ?)", []interface{}{name})
+		if err != nil {
+			return err
+		}
+		for _, task := range stripLabel(tasks, name) {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`DELETE FROM labels WHERE name = ?`, name); err != nil {
+			return fmt.Errorf("failed to delete label: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListLabels returns every label ordered by name
+func (ss *SQLiteStorage) ListLabels() ([]*models.Label, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	rows, err := ss.db.Query(`SELECT data FROM labels ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []*models.Label{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		var label models.Label
+		if err := json.Unmarshal([]byte(data), &label); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal label: %w", err)
+		}
+		labels = append(labels, &label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// LabelTasks adds and removes labels on several tasks in one transaction
+func (ss *SQLiteStorage) LabelTasks(taskIDs []string, add, remove []string) ([]*models.Task, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var tasks []*models.Task
+	err := ss.withWriteTx(func(tx *sql.Tx) error {
+		get := func(id string) (*models.Task, error) {
+			return getTaskTx(tx, id)
+		}
+		labelExists := func(name string) bool { return labelExistsTx(tx, name) }
+
+		writes, planned, err := planLabels(get, labelExists, taskIDs, add, remove)
+		if err != nil {
+			return err
+		}
+		for _, task := range writes {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+		tasks = planned
+		return nil
+	})
+	return tasks, err
+}
+
+// CreateWorkLog logs work or starts a timer and assigns it an ID. Like users,
+// work logs aren't cached.
+func (ss *SQLiteStorage) CreateWorkLog(log *models.WorkLog) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		taskExists := func(id string) bool { return taskExistsTx(tx, id) }
+		userExists := func(id string) bool { return userExistsTx(tx, id) }
+		if err := checkWorkLog(taskExists, userExists, log); err != nil {
+			return err
+		}
+		if log.IsRunning() {
+			running, err := selectWorkLogsTx(tx, `user_id = ? AND running = 1`, []interface{}{log.UserID})
+			if err != nil {
+				return err
+			}
+			if len(running) > 0 {
+				return fmt.Errorf("%w: on task %s", ErrTimerRunning, running[0].TaskID)
+			}
+		}
+
+		log.ID = uuid.New().String()
+		return saveWorkLogTx(tx, log)
+	})
+}
+
+// GetWorkLog retrieves a work log by ID
+func (ss *SQLiteStorage) GetWorkLog(id string) (*models.WorkLog, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var log *models.WorkLog
+	err := ss.withTx(func(tx *sql.Tx) error {
+		logs, err := selectWorkLogsTx(tx, `id = ?`, []interface{}{id})
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return fmt.Errorf("%w: %s", ErrWorkLogNotFound, id)
+		}
+		log = logs[0]
+		return nil
+	})
+	return log, err
+}
+
+// DeleteWorkLog deletes a work log
+func (ss *SQLiteStorage) DeleteWorkLog(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM worklogs WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete work log: %w", err)
+		}
+		if deleted, _ := result.RowsAffected(); deleted == 0 {
+			return fmt.Errorf("%w: %s", ErrWorkLogNotFound, id)
+		}
+		return nil
+	})
+}
+
+// ListWorkLogs returns the work logs matching query ordered by start
+func (ss *SQLiteStorage) ListWorkLogs(query WorkLogQuery) ([]*models.WorkLog, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	if query.TaskID != "" {
+		conditions = append(conditions, `task_id = ?`)
+		args = append(args, query.TaskID)
+	}
+	if query.UserID != "" {
+		conditions = append(conditions, `user_id = ?`)
+		args = append(args, query.UserID)
+	}
+	if query.Running != nil {
+		conditions = append(conditions, `running = ?`)
+		args = append(args, *query.Running)
+	}
+	where := "1 = 1"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+
+	var logs []*models.WorkLog
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		logs, err = selectWorkLogsTx(tx, where, args)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortWorkLogs(logs)
+	return logs, nil
+}
+
+// StopTimer stops the user's running timer at end
+func (ss *SQLiteStorage) StopTimer(userID string, end time.Time) (*models.WorkLog, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var log *models.WorkLog
+	err := ss.withTx(func(tx *sql.Tx) error {
+		running, err := selectWorkLogsTx(tx, `user_id = ? AND running = 1`, []interface{}{userID})
+		if err != nil {
+			return err
+		}
+		if len(running) == 0 {
+			return fmt.Errorf("%w: %s", ErrNoTimerRunning, userID)
+		}
+
+		log = running[0]
+		log.Stop(end)
+		return saveWorkLogTx(tx, log)
+	})
+	return log, err
+}
+
+// CreateComment adds a comment and assigns it an ID. Like users, comments
+// aren't cached.
+func (ss *SQLiteStorage) CreateComment(comment *models.Comment) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		taskExists := func(id string) bool { return taskExistsTx(tx, id) }
+		userExists := func(id string) bool { return userExistsTx(tx, id) }
+		getComment := func(id string) (*models.Comment, error) { return getCommentTx(tx, id) }
+		if err := checkComment(taskExists, userExists, getComment, comment); err != nil {
+			return err
+		}
+
+		comment.ID = uuid.New().String()
+		return saveCommentTx(tx, comment)
+	})
+}
+
+// GetComment retrieves a comment by ID
+func (ss *SQLiteStorage) GetComment(id string) (*models.Comment, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var comment *models.Comment
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		comment, err = getCommentTx(tx, id)
+		return err
+	})
+	return comment, err
+}
+
+// UpdateComment saves a comment's body and edit time
+func (ss *SQLiteStorage) UpdateComment(comment *models.Comment) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		stored, err := getCommentTx(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkCommentBody(comment.Body); err != nil {
+			return err
+		}
+
+		stored.Body = comment.Body
+		stored.EditedAt = comment.EditedAt
+		if err := saveCommentTx(tx, stored); err != nil {
+			return err
+		}
+		*comment = *stored
+		return nil
+	})
+}
+
+// DeleteComment deletes a comment and its replies
+func (ss *SQLiteStorage) DeleteComment(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		comment, err := getCommentTx(tx, id)
+		if err != nil {
+			return err
+		}
+		comments, err := selectCommentsTx(tx, `task_id = ?`, []interface{}{comment.TaskID})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range append([]string{id}, commentReplies(comments, id)...) {
+			if _, err := tx.Exec(`DELETE FROM comments WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete comment: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListComments returns the comments on a task, oldest first
+func (ss *SQLiteStorage) ListComments(taskID string) ([]*models.Comment, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var comments []*models.Comment
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		comments, err = selectCommentsTx(tx, `task_id = ?`, []interface{}{taskID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortComments(comments)
+	return comments, nil
+}
+
+// CreateSprint plans a new sprint and assigns it an ID. Like users, sprints
+// aren't cached.
+func (ss *SQLiteStorage) CreateSprint(sprint *models.Sprint) error {
+	if err := checkSprint(sprint); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	sprint.ID = uuid.New().String()
+	sprint.State = models.SprintPlanned
+	return ss.withTx(func(tx *sql.Tx) error {
+		return saveSprintTx(tx, sprint)
+	})
+}
+
+// GetSprint retrieves a sprint by ID
+func (ss *SQLiteStorage) GetSprint(id string) (*models.Sprint, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var sprint *models.Sprint
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		sprint, err = getSprintTx(tx, id)
+		return err
+	})
+	return sprint, err
+}
+
+// UpdateSprint updates a sprint's name, goal and dates
+func (ss *SQLiteStorage) UpdateSprint(sprint *models.Sprint) error {
+	if err := checkSprint(sprint); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		stored, err := getSprintTx(tx, sprint.ID)
+		if err != nil {
+			return err
+		}
+		updateSprint(stored, sprint)
+		return saveSprintTx(tx, sprint)
+	})
+}
+
+// DeleteSprint moves a sprint's tasks to the backlog and deletes it in one
+// transaction
+func (ss *SQLiteStorage) DeleteSprint(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withWriteTx(func(tx *sql.Tx) error {
+		sprint, err := getSprintTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if sprint.State == models.SprintActive {
+			return fmt.Errorf("%w: sprint %q is active", ErrSprintState, sprint.Name)
+		}
+
+		tasks, err := selectTasksTx(tx, sprintCondition, []interface{}{id})
+		if err != nil {
+			return err
+		}
+		for _, task := range leaveSprint(tasks, id) {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`DELETE FROM sprints WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete sprint: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListSprints returns every sprint ordered by start date
+func (ss *SQLiteStorage) ListSprints() ([]*models.Sprint, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var sprints []*models.Sprint
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		sprints, err = selectSprintsTx(tx, `1 = 1`, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortSprints(sprints)
+	return sprints, nil
+}
+
+// StartSprint makes a planned sprint active and records its commitment
+func (ss *SQLiteStorage) StartSprint(id string) (*models.Sprint, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var sprint *models.Sprint
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		sprint, err = getSprintTx(tx, id)
+		if err != nil {
+			return err
+		}
+		sprints, err := selectSprintsTx(tx, `state = ?`, []interface{}{string(models.SprintActive)})
+		if err != nil {
+			return err
+		}
+		tasks, err := selectTasksTx(tx, sprintCondition, []interface{}{id})
+		if err != nil {
+			return err
+		}
+
+		if err := startSprint(sprint, sprints, tasks); err != nil {
+			return err
+		}
+		return saveSprintTx(tx, sprint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// CloseSprint closes the active sprint and carries its unfinished tasks
+// over in one transaction
+func (ss *SQLiteStorage) CloseSprint(id, nextID string) (*models.Sprint, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var sprint *models.Sprint
+	err := ss.withWriteTx(func(tx *sql.Tx) error {
+		var err error
+		sprint, err = getSprintTx(tx, id)
+		if err != nil {
+			return err
+		}
+		sprints, err := selectSprintsTx(tx, `1 = 1`, nil)
+		if err != nil {
+			return err
+		}
+		tasks, err := selectTasksTx(tx, sprintCondition, []interface{}{id})
+		if err != nil {
+			return err
+		}
+
+		writes, err := closeSprint(sprint, nextID, sprints, tasks)
+		if err != nil {
+			return err
+		}
+		for _, task := range writes {
+			if err := saveTaskTx(tx, task); err != nil {
+				return err
+			}
+		}
+		return saveSprintTx(tx, sprint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sprint, nil
+}
+
+// CreateMilestone creates a milestone and assigns it an ID. Like users,
+// milestones aren't cached.
+func (ss *SQLiteStorage) CreateMilestone(milestone *models.Milestone) error {
+	if err := checkMilestone(milestone); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	milestone.ID = uuid.New().String()
+	return ss.withTx(func(tx *sql.Tx) error {
+		get := func(id string) (*models.Task, error) { return getTaskTx(tx, id) }
+		if err := checkMilestoneTasks(get, milestone, nil); err != nil {
+			return err
+		}
+		return saveMilestoneTx(tx, milestone)
+	})
+}
+
+// GetMilestone retrieves a milestone by ID
+func (ss *SQLiteStorage) GetMilestone(id string) (*models.Milestone, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var milestone *models.Milestone
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		milestone, err = getMilestoneTx(tx, id)
+		return err
+	})
+	return milestone, err
+}
+
+// UpdateMilestone updates an existing milestone
+func (ss *SQLiteStorage) UpdateMilestone(milestone *models.Milestone) error {
+	if err := checkMilestone(milestone); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		stored, err := getMilestoneTx(tx, milestone.ID)
+		if err != nil {
+			return err
+		}
+		get := func(id string) (*models.Task, error) { return getTaskTx(tx, id) }
+		if err := checkMilestoneTasks(get, milestone, stored.TaskIDs); err != nil {
+			return err
+		}
+		updateMilestone(stored, milestone)
+		return saveMilestoneTx(tx, milestone)
+	})
+}
+
+// DeleteMilestone deletes a milestone, leaving its tasks alone
+func (ss *SQLiteStorage) DeleteMilestone(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM milestones WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete milestone: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("%w: %s", ErrMilestoneNotFound, id)
+		}
+		return nil
+	})
+}
+
+// ListMilestones returns every milestone ordered by target date
+func (ss *SQLiteStorage) ListMilestones() ([]*models.Milestone, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var milestones []*models.Milestone
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		milestones, err = selectMilestonesTx(tx, `1 = 1`, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortMilestones(milestones)
+	return milestones, nil
+}
+
+// AppendEvents appends task events in one transaction
+func (ss *SQLiteStorage) AppendEvents(events []*models.TaskEvent) error {
+	if err := prepareEvents(events); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		return appendEventsTx(tx, events)
+	})
+}
+
+// ListEvents returns the task events matching query, newest first
+func (ss *SQLiteStorage) ListEvents(query EventQuery) ([]*models.TaskEvent, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var conditions []string
+	var args []interface{}
+	if query.TaskID != "" {
+		conditions = append(conditions, `task_id = ?`)
+		args = append(args, query.TaskID)
+	}
+	if query.ActorID != "" {
+		conditions = append(conditions, `actor_id = ?`)
+		args = append(args, query.ActorID)
+	}
+	if query.Source != "" {
+		conditions = append(conditions, `source = ?`)
+		args = append(args, string(query.Source))
+	}
+	if !query.Since.IsZero() {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, query.Since.UnixNano())
+	}
+	statement := `SELECT data FROM task_events`
+	if len(conditions) > 0 {
+		statement += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	statement += ` ORDER BY created_at DESC, seq DESC`
+	if query.Limit > 0 {
+		statement += ` LIMIT ?`
+		args = append(args, query.Limit)
+	}
+
+	events := []*models.TaskEvent{}
+	err := ss.withTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(statement, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query task events: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				return fmt.Errorf("failed to scan task event: %w", err)
+			}
+			var event models.TaskEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return fmt.Errorf("failed to unmarshal task event: %w", err)
+			}
+			events = append(events, &event)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetTaskChildren returns all direct children of a task
+func (ss *SQLiteStorage) GetTaskChildren(parentID string) ([]*models.Task, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var children []*models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		if !taskExistsTx(tx, parentID) {
+			return fmt.Errorf("task not found: %s", parentID)
+		}
+
+		rows, err := tx.Query(`SELECT t.data FROM task_edges e
+			JOIN tasks t ON t.id = e.child_id
+			WHERE e.parent_id = ? ORDER BY e.position`, parentID)
+		if err != nil {
+			return fmt.Errorf("failed to query children: %w", err)
+		}
+		decoded, err := scanTasks(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range decoded {
+			if err := loadChildrenTx(tx, child); err != nil {
+				return err
+			}
+		}
+		children = decoded
+		return nil
+	})
+	return children, err
+}
+
+// GetTaskParent returns the parent task of a given task
+func (ss *SQLiteStorage) GetTaskParent(childID string) (*models.Task, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var parent *models.Task
+	err := ss.withTx(func(tx *sql.Tx) error {
+		child, err := getTaskTx(tx, childID)
+		if err != nil {
+			return err
+		}
+
+		if child.ParentID == "" {
+			return fmt.Errorf("task has no parent")
+		}
+
+		parent, err = getTaskTx(tx, child.ParentID)
+		return err
+	})
+	return parent, err
+}
+
+// GetTaskHierarchy returns all tasks organized in hierarchical structure
+// Returns only top-level tasks (epics without parents) with their nested children
+func (ss *SQLiteStorage) GetTaskHierarchy() ([]*models.HierarchyTask, error) {
+	tasks, err := ss.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	return buildHierarchy(tasks), nil
+}
+
+// TaskExists checks if a task exists
+func (ss *SQLiteStorage) TaskExists(id string) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	exists := false
+	ss.withTx(func(tx *sql.Tx) error {
+		exists = taskExistsTx(tx, id)
+		return nil
+	})
+	return exists
+}
+
+// Close closes the underlying database
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
+
+// Generation returns the number of writes made to the database by any process
+func (ss *SQLiteStorage) Generation() (uint64, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var generation uint64
+	err := ss.db.QueryRow(`SELECT value FROM meta WHERE key = 'generation'`).Scan(&generation)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read generation: %w", err)
+	}
+	return generation, nil
+}
+
+// withTx runs fn inside a transaction, committing on success
+func (ss *SQLiteStorage) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withWriteTx runs fn inside a transaction that also bumps the generation.
+// The tasks fn changed are recorded in the history, when made through a view
+// with an actor, and passed on to the search index.
+func (ss *SQLiteStorage) withWriteTx(fn func(tx *sql.Tx) error) error {
+	var generation uint64
+	var changed []*models.Task
+	var removed []string
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		generation, err = bumpGenerationTx(tx)
+		if err != nil {
+			return err
+		}
+		// Forget changes made outside a write transaction, such as by fsck
+		if _, err := tx.Exec(`DELETE FROM task_changes`); err != nil {
+			return fmt.Errorf("failed to clear task changes: %w", err)
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		load := ss.actor != nil || ss.search.current(generation-1)
+		changes, err := takeTaskChangesTx(tx, load)
+		if err != nil {
+			return err
+		}
+		events, err := ss.actor.events(changes)
+		if err != nil {
+			return err
+		}
+		if err := appendEventsTx(tx, events); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.after != nil {
+				changed = append(changed, change.after)
+			} else {
+				removed = append(removed, change.before.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ss.search.update(generation, changed, removed)
+	return nil
+}
+
+// Internal transaction helpers
+
+func getTaskTx(tx *sql.Tx, id string) (*models.Task, error) {
+	var data string
+	err := tx.QueryRow(`SELECT data FROM tasks WHERE id = ?`, id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read task: %w", err)
+	}
+
+	var task models.Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	if err := loadChildrenTx(tx, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// loadChildrenTx fills task.Children from the edges table
+func loadChildrenTx(tx *sql.Tx, task *models.Task) error {
+	rows, err := tx.Query(`SELECT child_id FROM task_edges
+		WHERE parent_id = ? ORDER BY position`, task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query children: %w", err)
+	}
+	defer rows.Close()
+
+	task.Children = []string{}
+	for rows.Next() {
+		var childID string
+		if err := rows.Scan(&childID); err != nil {
+			return fmt.Errorf("failed to scan child: %w", err)
+		}
+		task.Children = append(task.Children, childID)
+	}
+	return rows.Err()
+}
+
+func listTasksTx(tx *sql.Tx) ([]*models.Task, error) {
+	return selectTasksTx(tx, "", nil)
+}
+
+// selectTasksTx returns the tasks matching the SQL condition where, or every
+// task when where is empty, with their children loaded
+func selectTasksTx(tx *sql.Tx, where string, args []interface{}) ([]*models.Task, error) {
+	statement := `SELECT data FROM tasks`
+	if where != "" {
+		statement += ` WHERE ` + where
+	}
+	rows, err := tx.Query(statement+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load every edge in one query rather than one per task
+	edges, err := tx.Query(`SELECT parent_id, child_id FROM task_edges
+		ORDER BY parent_id, position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query children: %w", err)
+	}
+	defer edges.Close()
+
+	children := make(map[string][]string)
+	for edges.Next() {
+		var parentID, childID string
+		if err := edges.Scan(&parentID, &childID); err != nil {
+			return nil, fmt.Errorf("failed to scan child: %w", err)
+		}
+		children[parentID] = append(children[parentID], childID)
+	}
+	if err := edges.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		task.Children = children[task.ID]
+		if task.Children == nil {
+			task.Children = []string{}
+		}
+	}
+	return tasks, nil
+}
+
+// scanTasks decodes the JSON documents returned by rows and closes it
+func scanTasks(rows *sql.Rows) ([]*models.Task, error) {
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		var task models.Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, rows.Err()
+}
+
+func saveTaskTx(tx *sql.Tx, task *models.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO tasks
+		(id, key, parent_id, status, priority, type, created_at, updated_at, data)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			key = excluded.key,
+			parent_id = excluded.parent_id,
+			status = excluded.status,
+			priority = excluded.priority,
+			type = excluded.type,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at,
+			data = excluded.data`,
+		task.ID, task.Key, task.ParentID, string(task.Status), string(task.Priority),
+		string(task.Type), task.CreatedAt.Format(time.RFC3339Nano),
+		task.UpdatedAt.Format(time.RFC3339Nano), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write task: %w", err)
+	}
+
+	// The task's Children slice is authoritative for its outgoing edges
+	if _, err := tx.Exec(`DELETE FROM task_edges WHERE parent_id = ?`, task.ID); err != nil {
+		return fmt.Errorf("failed to clear children: %w", err)
+	}
+	for i, childID := range task.Children {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO task_edges (parent_id, child_id, position)
+			VALUES (?, ?, ?)`, task.ID, childID, i)
+		if err != nil {
+			return fmt.Errorf("failed to write child: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteTaskTx(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM task_edges WHERE parent_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task children: %w", err)
+	}
+	return nil
+}
+
+// bumpGenerationTx increments the write counter returned by Generation and
+// returns its new value
+func bumpGenerationTx(tx *sql.Tx) (uint64, error) {
+	var generation uint64
+	err := tx.QueryRow(`INSERT INTO meta (key, value) VALUES ('generation', 1)
+		ON CONFLICT(key) DO UPDATE SET value = value + 1
+		RETURNING value`).Scan(&generation)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update generation: %w", err)
+	}
+	return generation, nil
+}
+
+// generationTx reads the write counter returned by Generation
+func generationTx(tx *sql.Tx) (uint64, error) {
+	var generation uint64
+	err := tx.QueryRow(`SELECT value FROM meta WHERE key = 'generation'`).Scan(&generation)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to read generation: %w", err)
+	}
+	return generation, nil
+}
+
+// takeTaskChangesTx empties the task_changes table filled by the triggers on
+// tasks. With load set it returns each task changed, as it was before the
+// transaction and as it is now. A row that can't be parsed counts as
+// missing, and one that was created and deleted again is left out.
+func takeTaskChangesTx(tx *sql.Tx, load bool) ([]taskChange, error) {
+	var changes []taskChange
+	if load {
+		rows, err := tx.Query(`SELECT id, before FROM task_changes ORDER BY rowid`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query task changes: %w", err)
+		}
+		defer rows.Close()
+
+		// The first row for a task holds its data from before the transaction
+		seen := make(map[string]bool)
+		var ids []string
+		before := make(map[string]*models.Task)
+		for rows.Next() {
+			var id string
+			var data sql.NullString
+			if err := rows.Scan(&id, &data); err != nil {
+				return nil, fmt.Errorf("failed to scan task change: %w", err)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+			before[id] = parseTaskData(data.String)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to query task changes: %w", err)
+		}
+		rows.Close()
+
+		for _, id := range ids {
+			var data string
+			err := tx.QueryRow(`SELECT data FROM tasks WHERE id = ?`, id).Scan(&data)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("failed to read task: %w", err)
+			}
+			after := parseTaskData(data)
+			if before[id] == nil && after == nil {
+				continue
+			}
+			changes = append(changes, taskChange{before: before[id], after: after})
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM task_changes`); err != nil {
+		return nil, fmt.Errorf("failed to clear task changes: %w", err)
+	}
+	return changes, nil
+}
+
+// parseTaskData parses a task row's JSON, returning nil for no data or data
+// that can't be parsed
+func parseTaskData(data string) *models.Task {
+	if data == "" {
+		return nil
+	}
+	var task models.Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		return nil
+	}
+	return &task
+}
+
+// appendEventsTx appends events to the task_events table
+func appendEventsTx(tx *sql.Tx, events []*models.TaskEvent) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal task event: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO task_events (id, task_id, actor_id, source, created_at, data) VALUES (?, ?, ?, ?, ?, ?)`,
+			event.ID, event.TaskID, event.ActorID, string(event.Source), event.CreatedAt.UnixNano(), string(data))
+		if err != nil {
+			return fmt.Errorf("failed to write task event: %w", err)
+		}
+	}
+	return nil
+}
+
+// nextKeyNumberTx takes the next number in the task key sequence
+func nextKeyNumberTx(tx *sql.Tx) (uint64, error) {
+	var number uint64
+	err := tx.QueryRow(`INSERT INTO meta (key, value) VALUES ('task_key', 1)
+		ON CONFLICT(key) DO UPDATE SET value = value + 1
+		RETURNING value`).Scan(&number)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update task key counter: %w", err)
+	}
+	return number, nil
+}
+
+// assignKeysTx starts the task key sequence after the keys in use when it
+// has no counter yet, and gives keys to tasks created before tasks had keys.
+// Rows that can't be read are left for Fsck to repair.
+// migrateTaskKeysTx adds the key column to a tasks table created before it
+// existed and fills it in from the task data, which is kept authoritative
+func migrateTaskKeysTx(tx *sql.Tx) error {
+	var hasKey bool
+	err := tx.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('tasks') WHERE name = 'key'`).Scan(&hasKey)
+	if err != nil {
+		return fmt.Errorf("failed to read tasks table: %w", err)
+	}
+	if !hasKey {
+		if _, err := tx.Exec(`ALTER TABLE tasks ADD COLUMN key TEXT`); err != nil {
+			return fmt.Errorf("failed to add key column: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE tasks SET key = NULLIF(json_extract(data, '$.key'), '')
+		WHERE json_valid(data) AND key IS NOT NULLIF(json_extract(data, '$.key'), '')`)
+	if err != nil {
+		return fmt.Errorf("failed to fill in task keys: %w", err)
+	}
+	if _, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_key ON tasks(key)`); err != nil {
+		return fmt.Errorf("failed to index task keys: %w", err)
+	}
+	return nil
+}
+
+func assignKeysTx(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT data FROM tasks WHERE json_valid(data) ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to query tasks: %w", err)
+	}
+	var tasks []*models.Task
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan task: %w", err)
+		}
+		var task models.Task
+		if json.Unmarshal([]byte(data), &task) == nil {
+			tasks = append(tasks, &task)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO meta (key, value) VALUES ('task_key', ?)
+		ON CONFLICT(key) DO NOTHING`, lastKeyNumber(tasks))
+	if err != nil {
+		return fmt.Errorf("failed to start task key counter: %w", err)
+	}
+
+	for _, task := range keylessTasks(tasks) {
+		number, err := nextKeyNumberTx(tx)
+		if err != nil {
+			return err
+		}
+		task.Key = models.CurrentWorkflow().TaskKey(number)
+		// Children live in the edges table, which saving rewrites
+		if err := loadChildrenTx(tx, task); err != nil {
+			return err
+		}
+		if err := saveTaskTx(tx, task); err != nil {
+			return fmt.Errorf("failed to assign task keys: %w", err)
+		}
+	}
+	return nil
+}
+
+func taskExistsTx(tx *sql.Tx, id string) bool {
+	var exists int
+	err := tx.QueryRow(`SELECT 1 FROM tasks WHERE id = ?`, id).Scan(&exists)
+	return err == nil
+}
+
+func saveUserTx(tx *sql.Tx, user *models.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO users (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, user.ID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write user: %w", err)
+	}
+	return nil
+}
+
+func userExistsTx(tx *sql.Tx, id string) bool {
+	var exists int
+	err := tx.QueryRow(`SELECT 1 FROM users WHERE id = ?`, id).Scan(&exists)
+	return err == nil
+}
+
+func saveLabelTx(tx *sql.Tx, label *models.Label) error {
+	data, err := json.Marshal(label)
+	if err != nil {
+		return fmt.Errorf("failed to marshal label: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO labels (name, data) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET data = excluded.data`, label.Name, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write label: %w", err)
+	}
+	return nil
+}
+
+func labelExistsTx(tx *sql.Tx, name string) bool {
+	var exists int
+	err := tx.QueryRow(`SELECT 1 FROM labels WHERE name = ?`, name).Scan(&exists)
+	return err == nil
+}
+
+func saveWorkLogTx(tx *sql.Tx, log *models.WorkLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal work log: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO worklogs (id, task_id, user_id, running, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET running = excluded.running, data = excluded.data`,
+		log.ID, log.TaskID, log.UserID, log.IsRunning(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write work log: %w", err)
+	}
+	return nil
+}
+
+// selectWorkLogsTx returns the work logs matching the SQL condition where
+func selectWorkLogsTx(tx *sql.Tx, where string, args []interface{}) ([]*models.WorkLog, error) {
+	rows, err := tx.Query(`SELECT data FROM worklogs WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []*models.WorkLog{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan work log: %w", err)
+		}
+		var log models.WorkLog
+		if err := json.Unmarshal([]byte(data), &log); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal work log: %w", err)
+		}
+		logs = append(logs, &log)
+	}
+	return logs, rows.Err()
+}
+
+func getCommentTx(tx *sql.Tx, id string) (*models.Comment, error) {
+	comments, err := selectCommentsTx(tx, `id = ?`, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCommentNotFound, id)
+	}
+	return comments[0], nil
+}
+
+func saveCommentTx(tx *sql.Tx, comment *models.Comment) error {
+	data, err := json.Marshal(comment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO comments (id, task_id, parent_id, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`,
+		comment.ID, comment.TaskID, comment.ParentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write comment: %w", err)
+	}
+	return nil
+}
+
+// selectCommentsTx returns the comments matching the SQL condition where
+func selectCommentsTx(tx *sql.Tx, where string, args []interface{}) ([]*models.Comment, error) {
+	rows, err := tx.Query(`SELECT data FROM comments WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		var comment models.Comment
+		if err := json.Unmarshal([]byte(data), &comment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal comment: %w", err)
+		}
+		comments = append(comments, &comment)
+	}
+	return comments, rows.Err()
+}
+
+// sprintCondition selects the tasks in the sprint given as its argument; an
+// empty ID selects the backlog
+const sprintCondition = `COALESCE(json_extract(tasks.data, '$.sprint_id'), '') = ?`
+
+func getSprintTx(tx *sql.Tx, id string) (*models.Sprint, error) {
+	sprints, err := selectSprintsTx(tx, `id = ?`, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(sprints) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSprintNotFound, id)
+	}
+	return sprints[0], nil
+}
+
+func saveSprintTx(tx *sql.Tx, sprint *models.Sprint) error {
+	data, err := json.Marshal(sprint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sprint: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO sprints (id, state, data) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET state = excluded.state, data = excluded.data`,
+		sprint.ID, string(sprint.State), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write sprint: %w", err)
+	}
+	return nil
+}
+
+// selectSprintsTx returns the sprints matching the SQL condition where
+func selectSprintsTx(tx *sql.Tx, where string, args []interface{}) ([]*models.Sprint, error) {
+	rows, err := tx.Query(`SELECT data FROM sprints WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sprints: %w", err)
+	}
+	defer rows.Close()
+
+	sprints := []*models.Sprint{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan sprint: %w", err)
+		}
+		var sprint models.Sprint
+		if err := json.Unmarshal([]byte(data), &sprint); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sprint: %w", err)
+		}
+		sprints = append(sprints, &sprint)
+	}
+	return sprints, rows.Err()
+}
+
+func getMilestoneTx(tx *sql.Tx, id string) (*models.Milestone, error) {
+	milestones, err := selectMilestonesTx(tx, `id = ?`, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(milestones) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMilestoneNotFound, id)
+	}
+	return milestones[0], nil
+}
+
+func saveMilestoneTx(tx *sql.Tx, milestone *models.Milestone) error {
+	data, err := json.Marshal(milestone)
+	if err != nil {
+		return fmt.Errorf("failed to marshal milestone: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO milestones (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`,
+		milestone.ID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write milestone: %w", err)
+	}
+	return nil
+}
+
+// selectMilestonesTx returns the milestones matching the SQL condition where
+func selectMilestonesTx(tx *sql.Tx, where string, args []interface{}) ([]*models.Milestone, error) {
+	rows, err := tx.Query(`SELECT data FROM milestones WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query milestones: %w", err)
+	}
+	defer rows.Close()
+
+	milestones := []*models.Milestone{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan milestone: %w", err)
+		}
+		var milestone models.Milestone
+		if err := json.Unmarshal([]byte(data), &milestone); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal milestone: %w", err)
+		}
+		milestones = append(milestones, &milestone)
+	}
+	return milestones, rows.Err()
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func newTestSQLiteStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	storage, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestSQLiteStorage_CreateTask(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	task := models.NewTask("Test Task", "Test Description")
+	if err := storage.CreateTask(task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	if task.ID == "" {
+		t.Error("CreateTask() should set task ID")
+	}
+
+	// Verify database file was created
+	if _, err := os.Stat(filepath.Join(tempDir, sqliteFileName)); os.IsNotExist(err) {
+		t.Error("NewSQLiteStorage() should create database file on disk")
+	}
+}
+
+func TestSQLiteStorage_GetTask(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	originalTask := models.NewTask("Test Task", "Test Description")
+	originalTask.Priority = models.PriorityHigh
+	if err := storage.CreateTask(originalTask); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	retrievedTask, err := storage.GetTask(originalTask.ID)
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+
+	if retrievedTask.Title != originalTask.Title {
+		t.Errorf("GetTask() title = %v, want %v", retrievedTask.Title, originalTask.Title)
+	}
+	if retrievedTask.Priority != models.PriorityHigh {
+		t.Errorf("GetTask() priority = %v, want %v", retrievedTask.Priority, models.PriorityHigh)
+	}
+	if retrievedTask.Children == nil {
+		t.Error("GetTask() should return a non-nil Children slice")
+	}
+}
+
+func TestSQLiteStorage_GetTask_NotFound(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	if _, err := storage.GetTask("nonexistent-id"); err == nil {
+		t.Error("GetTask() with nonexistent ID should return error")
+	}
+}
+
+func TestSQLiteStorage_UpdateTask(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	task := models.NewTask("Original Title", "Original Description")
+	if err := storage.CreateTask(task); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	task.Title = "Updated Title"
+	task.Status = models.StatusDone
+	if err := storage.UpdateTask(task); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	retrievedTask, err := storage.GetTask(task.ID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve updated task: %v", err)
+	}
+
+	if retrievedTask.Title != "Updated Title" {
+		t.Errorf("UpdateTask() title = %v, want %v", retrievedTask.Title, "Updated Title")
+	}
+	if retrievedTask.Status != models.StatusDone {
+		t.Errorf("UpdateTask() status = %v, want %v", retrievedTask.Status, models.StatusDone)
+	}
+
+	missing := models.NewTask("Missing", "")
+	missing.ID = "nonexistent-id"
+	if err := storage.UpdateTask(missing); err == nil {
+		t.Error("UpdateTask() with nonexistent ID should return error")
+	}
+}
+
+func TestSQLiteStorage_DeleteTask(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	parent := models.NewTask("Parent", "")
+	if err := storage.CreateTask(parent); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	child := models.NewTask("Child", "")
+	child.Type = models.TypeSubtask
+	child.ParentID = parent.ID
+	if err := storage.CreateTask(child); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := storage.DeleteTask(child.ID); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	if storage.TaskExists(child.ID) {
+		t.Error("DeleteTask() should make task no longer retrievable")
+	}
+
+	updatedParent, err := storage.GetTask(parent.ID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve parent: %v", err)
+	}
+	if len(updatedParent.Children) != 0 {
+		t.Errorf("DeleteTask() parent has %d children, want 0", len(updatedParent.Children))
+	}
+}
+
+func TestSQLiteStorage_ListTasks(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	task1 := models.NewTask("Task 1", "Description 1")
+	task2 := models.NewTask("Task 2", "Description 2")
+	if err := storage.CreateTask(task1); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := storage.CreateTask(task2); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	tasks, err := storage.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+
+	if len(tasks) != 2 {
+		t.Errorf("ListTasks() returned %d tasks, want 2", len(tasks))
+	}
+}
+
+func TestSQLiteStorage_Hierarchy(t *testing.T) {
+	storage := newTestSQLiteStorage(t)
+
+	parentTask := models.NewTask("Parent Task", "Parent Description")
+	parentTask.Type = models.TypeEpic
+	if err := storage.CreateTask(parentTask); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	childTask := models.NewTask("Child Task", "Child Description")
+	childTask.Type = models.TypeStory
+	childTask.ParentID = parentTask.ID
+	if err := storage.CreateTask(childTask); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	children, err := storage.GetTaskChildren(parentTask.ID)
+	if err != nil {
+		t.Fatalf("GetTaskChildren() error = %v", err)
+	}
+	if len(children) != 1 || children[0].ID != childTask.ID {
+		t.Errorf("GetTaskChildren() = %v, want [%s]", children, childTask.ID)
+	}
+
+	parent, err := storage.GetTaskParent(childTask.ID)
+	if err != nil {
+		t.Fatalf("GetTaskParent() error = %v", err)
+	}
+	if parent.ID != parentTask.ID {
+		t.Errorf("GetTaskParent() ID = %v, want %v", parent.ID, parentTask.ID)
+	}
+
+	hierarchy, err := storage.GetTaskHierarchy()
+	if err != nil {
+		t.Fatalf("GetTaskHierarchy() error = %v", err)
+	}
+
+	if len(hierarchy) != 1 {
+		t.Fatalf("GetTaskHierarchy() returned %d top-level items, want 1", len(hierarchy))
+	}
+	if len(hierarchy[0].ChildTasks) != 1 {
+		t.Fatalf("GetTaskHierarchy() parent has %d children, want 1", len(hierarchy[0].ChildTasks))
+	}
+	if hierarchy[0].ChildTasks[0].ID != childTask.ID {
+		t.Errorf("GetTaskHierarchy() child task ID = %v, want %v", hierarchy[0].ChildTasks[0].ID, childTask.ID)
+	}
+}
+
+func TestSQLiteStorage_Reopen(t *testing.T) {
+	tempDir := t.TempDir()
+	storage, err := NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	task := models.NewTask("Persistent Task", "")
+	if err := storage.CreateTask(task); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	storage.Close()
+
+	reopened, err := NewSQLiteStorage(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	if !reopened.TaskExists(task.ID) {
+		t.Error("task should survive closing and reopening the database")
+	}
+}
+
+func TestNewStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		wantErr bool
+	}{
+		{name: "default", backend: "", wantErr: false},
+		{name: "file", backend: BackendFile, wantErr: false},
+		{name: "sqlite", backend: BackendSQLite, wantErr: false},
+		{name: "unknown", backend: "postgres", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStorage(tt.backend, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if store != nil {
+				store.Close()
+			}
+		})
+	}
+}
@@ -1,17 +1,250 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/aykay76/projectflow/internal/models"
 )
 
+// Storage backend names accepted by NewStorage
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// Errors returned when a change would break the task hierarchy
+var (
+	ErrParentNotFound    = errors.New("parent task not found")
+	ErrCircularReference = errors.New("operation would create circular reference")
+	ErrInvalidParentType = errors.New("task type not allowed under parent")
+)
+
+// Errors returned when a change to task links is refused
+var (
+	ErrInvalidLink     = errors.New("invalid link")
+	ErrLinkNotFound    = errors.New("link not found")
+	ErrDependencyCycle = errors.New("link would create a dependency cycle")
+	ErrTaskBlocked     = errors.New("task is blocked")
+)
+
+// Errors returned when the workflow refuses a status change
+var (
+	ErrTransitionNotAllowed = errors.New("status transition not allowed")
+	ErrGuardFailed          = errors.New("transition guard failed")
+)
+
+// Errors returned by user operations
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserInUse    = errors.New("user is assigned to or reported tasks")
+)
+
+// Errors returned by label operations
+var (
+	ErrInvalidLabel  = errors.New("invalid label")
+	ErrLabelNotFound = errors.New("label not found")
+	ErrLabelExists   = errors.New("label already exists")
+)
+
+// Errors returned by work log operations
+var (
+	ErrInvalidWorkLog  = errors.New("invalid work log")
+	ErrWorkLogNotFound = errors.New("work log not found")
+	ErrTimerRunning    = errors.New("user already has a running timer")
+	ErrNoTimerRunning  = errors.New("user has no running timer")
+)
+
+// Errors returned by comment operations
+var (
+	ErrInvalidComment  = errors.New("invalid comment")
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// Errors returned by attachment operations
+var (
+	ErrInvalidAttachment  = errors.New("invalid attachment")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+)
+
+// Errors returned by sprint operations
+var (
+	ErrInvalidSprint  = errors.New("invalid sprint")
+	ErrSprintNotFound = errors.New("sprint not found")
+	ErrSprintState    = errors.New("not allowed in the sprint's state")
+	ErrSprintActive   = errors.New("another sprint is already active")
+)
+
+// Errors returned by milestone operations
+var (
+	ErrInvalidMilestone  = errors.New("invalid milestone")
+	ErrMilestoneNotFound = errors.New("milestone not found")
+)
+
+// ErrInvalidEvent is returned when a task event can't be appended to the
+// history
+var ErrInvalidEvent = errors.New("invalid task event")
+
+// ErrVersionConflict is returned when a write names a task version that is no
+// longer the stored one because someone else changed the task first
+var ErrVersionConflict = errors.New("version conflict")
+
+// DeleteStrategy controls what happens to a task's children when it is deleted
+type DeleteStrategy string
+
+const (
+	// DeleteCascade deletes the task and its entire subtree
+	DeleteCascade DeleteStrategy = "cascade"
+	// DeleteReparentToGrandparent moves the task's children up to its parent
+	DeleteReparentToGrandparent DeleteStrategy = "reparent-to-grandparent"
+	// DeleteOrphanToRoot detaches the task's children, making them root tasks
+	DeleteOrphanToRoot DeleteStrategy = "orphan-to-root"
+)
+
+// ParseDeleteStrategy validates a delete strategy name. An empty name selects
+// DeleteCascade.
+func ParseDeleteStrategy(name string) (DeleteStrategy, error) {
+	switch DeleteStrategy(name) {
+	case "":
+		return DeleteCascade, nil
+	case DeleteCascade, DeleteReparentToGrandparent, DeleteOrphanToRoot:
+		return DeleteStrategy(name), nil
+	default:
+		return "", fmt.Errorf("invalid delete strategy: %s", name)
+	}
+}
+
+// DeleteResult reports the tasks affected by a delete
+type DeleteResult struct {
+	Deleted []string `json:"deleted"`
+	Moved   []string `json:"moved"`
+}
+
 // Storage defines the interface for task storage operations
 type Storage interface {
-	// Task operations
+	// Task operations. CreateTask gives the task an ID and the next key in
+	// the project's sequence, and the key never changes afterwards.
 	CreateTask(task *models.Task) error
 	GetTask(id string) (*models.Task, error)
+	// GetTaskByKey retrieves a task by its key, as returned by
+	// models.ParseTaskKey
+	GetTaskByKey(key string) (*models.Task, error)
+	// UpdateTask saves task. Children is maintained by the storage and is
+	// ignored; changing ParentID moves the task, updating the old and new
+	// parents in the same operation and rejecting cycles. A non-zero Version
+	// must match the stored one or ErrVersionConflict is returned; on success
+	// task.Version is set to the new version. Links are also maintained by
+	// the storage. A status change must be allowed by the current workflow
+	// (ErrTransitionNotAllowed, ErrGuardFailed), and a task can't start work
+	// while a task that blocks it is not done (ErrTaskBlocked).
 	UpdateTask(task *models.Task) error
 	DeleteTask(id string) error
+	// DeleteTaskWithStrategy fails with ErrVersionConflict when
+	// expectedVersion is non-zero and doesn't match the stored task
+	DeleteTaskWithStrategy(id string, strategy DeleteStrategy, expectedVersion int64) (*DeleteResult, error)
 	ListTasks() ([]*models.Task, error)
+	QueryTasks(query TaskQuery) (*TaskPage, error)
+	// SearchTasks ranks tasks by how well their title and description match
+	// query. A limit of zero returns every match.
+	SearchTasks(query string, limit int) ([]*SearchResult, error)
+
+	// Link operations. LinkTasks records linkType on fromID and its inverse on
+	// toID in one operation; blocks and duplicates links that would form a
+	// cycle are rejected with ErrDependencyCycle. UnlinkTasks removes both ends.
+	LinkTasks(fromID string, linkType models.LinkType, toID string) error
+	UnlinkTasks(fromID string, linkType models.LinkType, toID string) error
+
+	// User operations. Tasks refer to users by AssigneeID, ReporterID and
+	// user custom fields: CreateTask and UpdateTask return ErrUserNotFound
+	// for an unknown user, and DeleteUser returns ErrUserInUse while a task
+	// still refers to the user.
+	CreateUser(user *models.User) error
+	GetUser(id string) (*models.User, error)
+	UpdateUser(user *models.User) error
+	DeleteUser(id string) error
+	// ListUsers returns every user ordered by name
+	ListUsers() ([]*models.User, error)
+
+	// Label operations. Task labels must be registered first: CreateTask,
+	// UpdateTask and LabelTasks store label names in canonical form and
+	// return ErrLabelNotFound for an unknown one. DeleteLabel also removes
+	// the label from every task that carries it.
+	CreateLabel(label *models.Label) error
+	GetLabel(name string) (*models.Label, error)
+	UpdateLabel(label *models.Label) error
+	DeleteLabel(name string) error
+	// ListLabels returns every label ordered by name
+	ListLabels() ([]*models.Label, error)
+	// LabelTasks adds and removes labels on several tasks in one operation,
+	// returning the tasks in taskIDs order. Only tasks whose labels change
+	// are written.
+	LabelTasks(taskIDs []string, add, remove []string) ([]*models.Task, error)
+
+	// Work log operations. A work log must name an existing task and user
+	// (ErrUserNotFound). CreateWorkLog works out the end from a duration
+	// and the duration from an end; with neither, the work log is a running
+	// timer and ErrTimerRunning is returned if the user already has one.
+	// Work logs are history, so deleting their task or user keeps them.
+	CreateWorkLog(log *models.WorkLog) error
+	GetWorkLog(id string) (*models.WorkLog, error)
+	DeleteWorkLog(id string) error
+	// ListWorkLogs returns the work logs matching query ordered by start
+	ListWorkLogs(query WorkLogQuery) ([]*models.WorkLog, error)
+	// StopTimer stops the user's running timer at end and returns it, or
+	// returns ErrNoTimerRunning
+	StopTimer(userID string, end time.Time) (*models.WorkLog, error)
+
+	// Comment operations. A comment must name an existing task and author
+	// (ErrUserNotFound), and a reply's parent must be a comment on the same
+	// task. UpdateComment only changes the body and edit time. DeleteComment
+	// also deletes the replies to the comment. Like work logs, comments are
+	// kept when their task or author is deleted.
+	CreateComment(comment *models.Comment) error
+	GetComment(id string) (*models.Comment, error)
+	UpdateComment(comment *models.Comment) error
+	DeleteComment(id string) error
+	// ListComments returns the comments on a task, oldest first
+	ListComments(taskID string) ([]*models.Comment, error)
+
+	// Sprint operations. A task's SprintID must name an existing sprint
+	// (ErrSprintNotFound), and CreateTask and UpdateTask refuse to move a
+	// task into a closed sprint (ErrSprintState). UpdateSprint only changes
+	// the name, goal and dates; the state changes through StartSprint and
+	// CloseSprint. DeleteSprint moves the sprint's tasks to the backlog and
+	// refuses to delete the active sprint.
+	CreateSprint(sprint *models.Sprint) error
+	GetSprint(id string) (*models.Sprint, error)
+	UpdateSprint(sprint *models.Sprint) error
+	DeleteSprint(id string) error
+	// ListSprints returns every sprint ordered by start date
+	ListSprints() ([]*models.Sprint, error)
+	// StartSprint makes a planned sprint active and records the tasks in it
+	// as its commitment. Only one sprint can be active (ErrSprintActive).
+	StartSprint(id string) (*models.Sprint, error)
+	// CloseSprint closes the active sprint and carries its unfinished tasks
+	// over to nextID, or when that is empty to the earliest planned sprint,
+	// or to the backlog when there is none
+	CloseSprint(id, nextID string) (*models.Sprint, error)
+
+	// Milestone operations. Tasks linked to a milestone must exist when they
+	// are linked (ErrInvalidMilestone); deleting a task leaves its ID behind,
+	// and progress skips it.
+	CreateMilestone(milestone *models.Milestone) error
+	GetMilestone(id string) (*models.Milestone, error)
+	UpdateMilestone(milestone *models.Milestone) error
+	DeleteMilestone(id string) error
+	// ListMilestones returns every milestone ordered by target date
+	ListMilestones() ([]*models.Milestone, error)
+
+	// History operations. Events are only ever appended, and are kept when
+	// their task is deleted. Changes are recorded through the view returned
+	// by WithActor. AppendEvents assigns each event an ID and, if it has
+	// none, a time.
+	AppendEvents(events []*models.TaskEvent) error
+	// ListEvents returns the events matching query, newest first
+	ListEvents(query EventQuery) ([]*models.TaskEvent, error)
 
 	// Hierarchy operations
 	GetTaskChildren(parentID string) ([]*models.Task, error)
@@ -22,3 +255,24 @@ type Storage interface {
 	TaskExists(id string) bool
 	Close() error
 }
+
+// ChangeTracker is implemented by backends that can report when their task
+// data has been changed, including by another process
+type ChangeTracker interface {
+	// Generation returns a counter that every committed task write
+	// increments by one
+	Generation() (uint64, error)
+}
+
+// NewStorage creates the storage backend named by backend, rooted at dataDir.
+// An empty backend selects file storage.
+func NewStorage(backend, dataDir string) (Storage, error) {
+	switch backend {
+	case "", BackendFile:
+		return NewFileStorage(dataDir)
+	case BackendSQLite:
+		return NewSQLiteStorage(dataDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
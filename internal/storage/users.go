@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkUsers returns ErrUserNotFound when task is assigned to or reported by
+// a user that doesn't exist, or names one in a user custom field
+func checkUsers(userExists func(id string) bool, task *models.Task) error {
+	ids := append([]string{task.AssigneeID, task.ReporterID}, models.CurrentWorkflow().CustomFieldUsers(task)...)
+	for _, id := range ids {
+		if id != "" && !userExists(id) {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, id)
+		}
+	}
+	return nil
+}
+
+// refersToUser reports whether any of tasks is assigned to or reported by
+// userID, or names them in a user custom field
+func refersToUser(tasks []*models.Task, userID string) bool {
+	workflow := models.CurrentWorkflow()
+	for _, task := range tasks {
+		if task.AssigneeID == userID || task.ReporterID == userID {
+			return true
+		}
+		for _, id := range workflow.CustomFieldUsers(task) {
+			if id == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortUsers orders users by name, then ID
+func sortUsers(users []*models.User) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Name != users[j].Name {
+			return users[i].Name < users[j].Name
+		}
+		return users[i].ID < users[j].ID
+	})
+}
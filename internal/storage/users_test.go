@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestUsers(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := models.NewUser("Alice", models.UserHuman)
+			bot := models.NewUser("Bot", models.UserAgent)
+			for _, user := range []*models.User{bot, alice} {
+				if err := store.CreateUser(user); err != nil {
+					t.Fatalf("CreateUser() error = %v", err)
+				}
+			}
+
+			users, err := store.ListUsers()
+			if err != nil {
+				t.Fatalf("ListUsers() error = %v", err)
+			}
+			if len(users) != 2 || users[0].ID != alice.ID || users[1].ID != bot.ID {
+				t.Errorf("ListUsers() = %v, want Alice then Bot", users)
+			}
+
+			bot.Email = "bot@example.com"
+			if err := store.UpdateUser(bot); err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			got, err := store.GetUser(bot.ID)
+			if err != nil {
+				t.Fatalf("GetUser() error = %v", err)
+			}
+			if got.Email != bot.Email || got.Kind != models.UserAgent {
+				t.Errorf("GetUser() = %+v, want the updated agent", got)
+			}
+
+			if _, err := store.GetUser("missing"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetUser(missing) error = %v, want ErrUserNotFound", err)
+			}
+			if err := store.UpdateUser(&models.User{ID: "missing", Name: "Nobody"}); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("UpdateUser(missing) error = %v, want ErrUserNotFound", err)
+			}
+			if err := store.DeleteUser("missing"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("DeleteUser(missing) error = %v, want ErrUserNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTaskUsers(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := models.NewUser("Alice", models.UserHuman)
+			bot := models.NewUser("Bot", models.UserAgent)
+			for _, user := range []*models.User{alice, bot} {
+				if err := store.CreateUser(user); err != nil {
+					t.Fatalf("CreateUser() error = %v", err)
+				}
+			}
+
+			reported := models.NewTask("Reported", "")
+			reported.ReporterID = alice.ID
+			reported.AssigneeID = bot.ID
+			if err := store.CreateTask(reported); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			unknown := models.NewTask("Unknown", "")
+			unknown.AssigneeID = "missing"
+			if err := store.CreateTask(unknown); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("CreateTask() with unknown assignee error = %v, want ErrUserNotFound", err)
+			}
+			tasks := createTasks(t, store, "Unassigned")
+			unassigned := tasks[0]
+
+			unassigned.AssigneeID = "missing"
+			if err := store.UpdateTask(unassigned); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("UpdateTask() with unknown assignee error = %v, want ErrUserNotFound", err)
+			}
+
+			assignee := bot.ID
+			page, err := store.QueryTasks(TaskQuery{AssigneeID: &assignee})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 1 || page.Tasks[0].ID != reported.ID {
+				t.Errorf("QueryTasks(assignee) = %d tasks, want only %q", page.Total, reported.Title)
+			}
+			nobody := ""
+			page, err = store.QueryTasks(TaskQuery{AssigneeID: &nobody})
+			if err != nil {
+				t.Fatalf("QueryTasks() error = %v", err)
+			}
+			if page.Total != 1 || page.Tasks[0].ID != unassigned.ID {
+				t.Errorf("QueryTasks(unassigned) = %d tasks, want only %q", page.Total, unassigned.Title)
+			}
+
+			// Users can't be deleted while tasks refer to them
+			for _, user := range []*models.User{alice, bot} {
+				if err := store.DeleteUser(user.ID); !errors.Is(err, ErrUserInUse) {
+					t.Errorf("DeleteUser(%s) error = %v, want ErrUserInUse", user.Name, err)
+				}
+			}
+
+			current, err := store.GetTask(reported.ID)
+			if err != nil {
+				t.Fatalf("GetTask() error = %v", err)
+			}
+			current.AssigneeID = ""
+			if err := store.UpdateTask(current); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if err := store.DeleteUser(bot.ID); err != nil {
+				t.Errorf("DeleteUser() after unassigning error = %v", err)
+			}
+			if _, err := store.GetUser(bot.ID); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetUser() after delete error = %v, want ErrUserNotFound", err)
+			}
+		})
+	}
+}
+
+func TestCustomFieldUsers(t *testing.T) {
+	workflow := models.DefaultWorkflow()
+	workflow.CustomFields = map[models.TaskType][]models.CustomField{
+		models.TypeTask: {{Name: "reviewer", Type: models.FieldUser}},
+	}
+	models.SetWorkflow(workflow)
+	t.Cleanup(func() { models.SetWorkflow(nil) })
+
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			reviewer := models.NewUser("Reviewer", models.UserHuman)
+			if err := store.CreateUser(reviewer); err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+
+			task := models.NewTask("Reviewed", "")
+			task.CustomFields = models.FieldValues{"reviewer": "missing"}
+			if err := store.CreateTask(task); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("CreateTask() with unknown reviewer error = %v, want ErrUserNotFound", err)
+			}
+			task.CustomFields = models.FieldValues{"reviewer": reviewer.ID}
+			if err := store.CreateTask(task); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+
+			if err := store.DeleteUser(reviewer.ID); !errors.Is(err, ErrUserInUse) {
+				t.Errorf("DeleteUser(reviewer) error = %v, want ErrUserInUse", err)
+			}
+			task.CustomFields = nil
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if err := store.DeleteUser(reviewer.ID); err != nil {
+				t.Errorf("DeleteUser() after the field was cleared error = %v", err)
+			}
+		})
+	}
+}
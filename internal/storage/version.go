@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkVersion returns ErrVersionConflict when expected is set and doesn't
+// match the stored task's version. An expected version of zero always passes.
+func checkVersion(current *models.Task, expected int64) error {
+	if expected != 0 && expected != current.Version {
+		return fmt.Errorf("%w: task %s is at version %d, not %d", ErrVersionConflict, current.ID, current.Version, expected)
+	}
+	return nil
+}
+
+// bumpVersions advances the version of every task about to be written. The
+// tasks must carry the stored version when called.
+func bumpVersions(tasks []*models.Task) {
+	for _, task := range tasks {
+		task.Version++
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestVersion_UpdateAndDelete(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			parent := models.NewTask("Parent", "")
+			if err := store.CreateTask(parent); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			child := models.NewTask("Child", "")
+			child.Type = models.TypeSubtask
+			child.ParentID = parent.ID
+			if err := store.CreateTask(child); err != nil {
+				t.Fatalf("CreateTask() error = %v", err)
+			}
+			if child.Version != 1 {
+				t.Errorf("new task Version = %d, want 1", child.Version)
+			}
+
+			// Adding the child wrote the parent too
+			stored, _ := store.GetTask(parent.ID)
+			if stored.Version != 2 {
+				t.Errorf("parent Version = %d, want 2", stored.Version)
+			}
+
+			edit, _ := store.GetTask(child.ID)
+			stale, _ := store.GetTask(child.ID)
+			edit.Title = "First edit"
+			if err := store.UpdateTask(edit); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if edit.Version != 2 {
+				t.Errorf("updated Version = %d, want 2", edit.Version)
+			}
+
+			stale.Title = "Lost edit"
+			if err := store.UpdateTask(stale); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("UpdateTask() with a stale version error = %v, want ErrVersionConflict", err)
+			}
+			if got, _ := store.GetTask(child.ID); got.Title != "First edit" {
+				t.Errorf("Title = %q after a conflicting update, want %q", got.Title, "First edit")
+			}
+
+			if _, err := store.DeleteTaskWithStrategy(child.ID, DeleteCascade, 1); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("DeleteTaskWithStrategy() with a stale version error = %v, want ErrVersionConflict", err)
+			}
+			if _, err := store.DeleteTaskWithStrategy(child.ID, DeleteCascade, 2); err != nil {
+				t.Fatalf("DeleteTaskWithStrategy() error = %v", err)
+			}
+			if store.TaskExists(child.ID) {
+				t.Errorf("task still exists after delete")
+			}
+		})
+	}
+}
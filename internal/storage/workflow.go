@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// checkTransition returns ErrTransitionNotAllowed when the current workflow
+// has no transition from the stored task's status to the new one, and
+// ErrGuardFailed when one of the transition's guards doesn't pass
+func checkTransition(get func(id string) (*models.Task, error), current *models.Task, to models.TaskStatus) error {
+	transition, ok := models.CurrentWorkflow().Transition(current.Status, to)
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrTransitionNotAllowed, current.Status, to)
+	}
+
+	for _, guard := range transition.Guards {
+		var open []string
+		switch guard {
+		case models.GuardChildrenDone:
+			for _, id := range current.Children {
+				child, err := get(id)
+				if err == nil && !models.IsDoneStatus(child.Status) {
+					open = append(open, id)
+				}
+			}
+		case models.GuardBlockersDone:
+			open = openBlockers(get, current)
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("%w: %s needs %s done first", ErrGuardFailed, guard, strings.Join(open, ", "))
+		}
+	}
+	return nil
+}
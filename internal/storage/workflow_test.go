@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestUpdateTask_Workflow(t *testing.T) {
+	workflow, err := models.ParseWorkflow([]byte(`{
+		"initial": "todo",
+		"statuses": [
+			{"id": "todo", "name": "To Do", "category": "todo"},
+			{"id": "doing", "name": "Doing", "category": "in_progress"},
+			{"id": "done", "name": "Done", "category": "done"}
+		],
+		"transitions": [
+			{"from": ["todo"], "to": "doing"},
+			{"from": ["doing"], "to": "done", "guards": ["children_done", "blockers_done"]},
+			{"from": ["*"], "to": "todo"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow() error = %v", err)
+	}
+	models.SetWorkflow(workflow)
+	t.Cleanup(func() { models.SetWorkflow(nil) })
+
+	setStatus := func(store Storage, id string, status models.TaskStatus) error {
+		task, err := store.GetTask(id)
+		if err != nil {
+			t.Fatalf("GetTask() error = %v", err)
+		}
+		task.Status = status
+		return store.UpdateTask(task)
+	}
+
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			tasks := createTasks(t, store, "Epic", "Story")
+			epic, story := tasks[0], tasks[1]
+			epic.Type = models.TypeEpic
+			if err := store.UpdateTask(epic); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			story.Type = models.TypeStory
+			story.ParentID = epic.ID
+			if err := store.UpdateTask(story); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+
+			if err := setStatus(store, epic.ID, "done"); !errors.Is(err, ErrTransitionNotAllowed) {
+				t.Errorf("todo to done error = %v, want ErrTransitionNotAllowed", err)
+			}
+			if err := setStatus(store, epic.ID, "doing"); err != nil {
+				t.Fatalf("todo to doing error = %v", err)
+			}
+			if err := setStatus(store, epic.ID, "done"); !errors.Is(err, ErrGuardFailed) {
+				t.Errorf("done with open children error = %v, want ErrGuardFailed", err)
+			}
+
+			if err := setStatus(store, story.ID, "doing"); err != nil {
+				t.Fatalf("story to doing error = %v", err)
+			}
+			if err := setStatus(store, story.ID, "done"); err != nil {
+				t.Fatalf("story to done error = %v", err)
+			}
+			if err := setStatus(store, epic.ID, "done"); err != nil {
+				t.Errorf("done with children done error = %v", err)
+			}
+			if err := setStatus(store, epic.ID, "todo"); err != nil {
+				t.Errorf("reopen error = %v", err)
+			}
+
+			// Edits that leave the status alone aren't checked
+			task, _ := store.GetTask(epic.ID)
+			task.Title = "Renamed epic"
+			if err := store.UpdateTask(task); err != nil {
+				t.Errorf("UpdateTask() without a status change error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateTask_CompletedAt(t *testing.T) {
+	actual := func(store Storage, id string) models.Estimate {
+		task, err := store.GetTask(id)
+		if err != nil {
+			t.Fatalf("GetTask() error = %v", err)
+		}
+		report := models.NewAccuracyReport([]*models.Task{task})
+		if len(report.Tasks) != 1 {
+			t.Fatalf("NewAccuracyReport() tasks = %d, want 1", len(report.Tasks))
+		}
+		return report.Tasks[0].Actual
+	}
+
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			task := createTasks(t, store, "Finish me")[0]
+			started := time.Now().Add(-2 * time.Hour)
+			task.StartedAt = &started
+			task.OriginalEstimate = models.Estimate(time.Hour)
+			task.Status = models.StatusInProgress
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("Setup failed: %v", err)
+			}
+			if task.CompletedAt != nil {
+				t.Errorf("UpdateTask() in progress completed_at = %v, want nil", task.CompletedAt)
+			}
+
+			task.Status = models.StatusDone
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() to done error = %v", err)
+			}
+			if task.CompletedAt == nil {
+				t.Fatal("UpdateTask() to done didn't record completed_at")
+			}
+			completed := *task.CompletedAt
+			want := actual(store, task.ID)
+
+			// A later edit to a finished task doesn't move its completion
+			task.Priority = models.PriorityHigh
+			task.CompletedAt = nil
+			task.UpdatedAt = time.Now().Add(time.Hour)
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() on done task error = %v", err)
+			}
+			if got := actual(store, task.ID); got != want {
+				t.Errorf("actual after edit = %s, want %s", got, want)
+			}
+			stored, _ := store.GetTask(task.ID)
+			if stored.CompletedAt == nil || !stored.CompletedAt.Equal(completed) {
+				t.Errorf("completed_at after edit = %v, want %v", stored.CompletedAt, completed)
+			}
+
+			stored.Status = models.StatusTodo
+			if err := store.UpdateTask(stored); err != nil {
+				t.Fatalf("UpdateTask() reopen error = %v", err)
+			}
+			if stored, _ = store.GetTask(task.ID); stored.CompletedAt != nil {
+				t.Errorf("completed_at after reopen = %v, want nil", stored.CompletedAt)
+			}
+
+			created := models.NewTask("Already done", "")
+			created.Status = models.StatusDone
+			if err := store.CreateTask(created); err != nil {
+				t.Fatalf("CreateTask() done error = %v", err)
+			}
+			if created.CompletedAt == nil {
+				t.Error("CreateTask() done didn't record completed_at")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+// WorkLogQuery selects work logs. Empty fields match every work log.
+type WorkLogQuery struct {
+	TaskID string
+	UserID string
+	// Running selects only running timers when true and only finished work
+	// when false
+	Running *bool
+}
+
+// matches reports whether log is selected by q
+func (q WorkLogQuery) matches(log *models.WorkLog) bool {
+	if q.TaskID != "" && log.TaskID != q.TaskID {
+		return false
+	}
+	if q.UserID != "" && log.UserID != q.UserID {
+		return false
+	}
+	if q.Running != nil && log.IsRunning() != *q.Running {
+		return false
+	}
+	return true
+}
+
+// checkWorkLog checks log refers to an existing task and user and fills in
+// its end from its duration, or its duration from its end
+func checkWorkLog(taskExists, userExists func(id string) bool, log *models.WorkLog) error {
+	if !taskExists(log.TaskID) {
+		return fmt.Errorf("task not found: %s", log.TaskID)
+	}
+	if !userExists(log.UserID) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, log.UserID)
+	}
+	if log.Start.IsZero() {
+		return fmt.Errorf("%w: start is required", ErrInvalidWorkLog)
+	}
+	if log.Duration < 0 {
+		return fmt.Errorf("%w: duration must not be negative", ErrInvalidWorkLog)
+	}
+
+	switch {
+	case log.End != nil:
+		if log.End.Before(log.Start) {
+			return fmt.Errorf("%w: end is before start", ErrInvalidWorkLog)
+		}
+		log.Duration = models.Estimate(log.End.Sub(log.Start))
+	case log.Duration > 0:
+		end := log.Start.Add(log.Duration.Duration())
+		log.End = &end
+	}
+	return nil
+}
+
+// findRunningTimer returns the running timer among logs that belongs to
+// userID, or nil
+func findRunningTimer(logs []*models.WorkLog, userID string) *models.WorkLog {
+	for _, log := range logs {
+		if log.UserID == userID && log.IsRunning() {
+			return log
+		}
+	}
+	return nil
+}
+
+// sortWorkLogs orders work logs by start, then ID
+func sortWorkLogs(logs []*models.WorkLog) {
+	sort.Slice(logs, func(i, j int) bool {
+		if !logs[i].Start.Equal(logs[j].Start) {
+			return logs[i].Start.Before(logs[j].Start)
+		}
+		return logs[i].ID < logs[j].ID
+	})
+}
+
+// SummarizeTime totals the time logged against a task and its descendants
+// and compares it with the task's estimates and actual duration
+func SummarizeTime(s Storage, taskID string) (*models.TimeSummary, error) {
+	task, err := s.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.ListTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	logs, err := s.ListWorkLogs(WorkLogQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list work logs: %w", err)
+	}
+
+	children := make(map[string][]string)
+	for _, t := range tasks {
+		if t.ParentID != "" {
+			children[t.ParentID] = append(children[t.ParentID], t.ID)
+		}
+	}
+	subtree := make(map[string]bool)
+	pending := append([]string{}, children[task.ID]...)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if subtree[id] || id == task.ID {
+			continue
+		}
+		subtree[id] = true
+		pending = append(pending, children[id]...)
+	}
+
+	return models.NewTimeSummary(task, subtree, logs, time.Now()), nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aykay76/projectflow/internal/models"
+)
+
+func TestWorkLogs(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := models.NewUser("Alice", models.UserHuman)
+			if err := store.CreateUser(alice); err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+			tasks := createTasks(t, store, "First", "Second")
+			first, second := tasks[0], tasks[1]
+			start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+			// A duration gives the end and an end gives the duration
+			byDuration := models.NewWorkLog(first.ID, alice.ID)
+			byDuration.Start = start
+			byDuration.Duration = models.Estimate(90 * time.Minute)
+			if err := store.CreateWorkLog(byDuration); err != nil {
+				t.Fatalf("CreateWorkLog() error = %v", err)
+			}
+			if byDuration.End == nil || !byDuration.End.Equal(start.Add(90*time.Minute)) {
+				t.Errorf("CreateWorkLog() end = %v, want 10:30", byDuration.End)
+			}
+			byEnd := models.NewWorkLog(second.ID, alice.ID)
+			byEnd.Start = start.Add(-time.Hour)
+			end := start
+			byEnd.End = &end
+			if err := store.CreateWorkLog(byEnd); err != nil {
+				t.Fatalf("CreateWorkLog() error = %v", err)
+			}
+			if byEnd.Duration.String() != "1h" {
+				t.Errorf("CreateWorkLog() duration = %s, want 1h", byEnd.Duration)
+			}
+
+			invalid := models.NewWorkLog(first.ID, alice.ID)
+			before := invalid.Start.Add(-time.Minute)
+			invalid.End = &before
+			if err := store.CreateWorkLog(invalid); !errors.Is(err, ErrInvalidWorkLog) {
+				t.Errorf("CreateWorkLog() ending before it starts error = %v, want ErrInvalidWorkLog", err)
+			}
+			if err := store.CreateWorkLog(models.NewWorkLog(first.ID, "missing")); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("CreateWorkLog() with unknown user error = %v, want ErrUserNotFound", err)
+			}
+			if err := store.CreateWorkLog(models.NewWorkLog("missing", alice.ID)); err == nil {
+				t.Error("CreateWorkLog() with unknown task expected error")
+			}
+
+			logs, err := store.ListWorkLogs(WorkLogQuery{})
+			if err != nil {
+				t.Fatalf("ListWorkLogs() error = %v", err)
+			}
+			if len(logs) != 2 || logs[0].ID != byEnd.ID || logs[1].ID != byDuration.ID {
+				t.Errorf("ListWorkLogs() = %v, want the two logs by start", logs)
+			}
+			logs, err = store.ListWorkLogs(WorkLogQuery{TaskID: first.ID})
+			if err != nil {
+				t.Fatalf("ListWorkLogs() error = %v", err)
+			}
+			if len(logs) != 1 || logs[0].ID != byDuration.ID {
+				t.Errorf("ListWorkLogs(task) = %v, want only the first task's log", logs)
+			}
+
+			if err := store.DeleteWorkLog(byEnd.ID); err != nil {
+				t.Fatalf("DeleteWorkLog() error = %v", err)
+			}
+			if _, err := store.GetWorkLog(byEnd.ID); !errors.Is(err, ErrWorkLogNotFound) {
+				t.Errorf("GetWorkLog() after delete error = %v, want ErrWorkLogNotFound", err)
+			}
+			if err := store.DeleteWorkLog("missing"); !errors.Is(err, ErrWorkLogNotFound) {
+				t.Errorf("DeleteWorkLog(missing) error = %v, want ErrWorkLogNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTimers(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			alice := models.NewUser("Alice", models.UserHuman)
+			bob := models.NewUser("Bob", models.UserHuman)
+			for _, user := range []*models.User{alice, bob} {
+				if err := store.CreateUser(user); err != nil {
+					t.Fatalf("CreateUser() error = %v", err)
+				}
+			}
+			tasks := createTasks(t, store, "First", "Second")
+			first, second := tasks[0], tasks[1]
+
+			if _, err := store.StopTimer(alice.ID, time.Now()); !errors.Is(err, ErrNoTimerRunning) {
+				t.Errorf("StopTimer() without a timer error = %v, want ErrNoTimerRunning", err)
+			}
+
+			timer := models.NewWorkLog(first.ID, alice.ID)
+			timer.Start = time.Now().Add(-time.Hour)
+			if err := store.CreateWorkLog(timer); err != nil {
+				t.Fatalf("CreateWorkLog() error = %v", err)
+			}
+
+			// One running timer per user, but other users can run their own
+			if err := store.CreateWorkLog(models.NewWorkLog(second.ID, alice.ID)); !errors.Is(err, ErrTimerRunning) {
+				t.Errorf("CreateWorkLog() with a timer running error = %v, want ErrTimerRunning", err)
+			}
+			if err := store.CreateWorkLog(models.NewWorkLog(second.ID, bob.ID)); err != nil {
+				t.Errorf("CreateWorkLog() for another user error = %v", err)
+			}
+
+			running := true
+			logs, err := store.ListWorkLogs(WorkLogQuery{UserID: alice.ID, Running: &running})
+			if err != nil {
+				t.Fatalf("ListWorkLogs() error = %v", err)
+			}
+			if len(logs) != 1 || logs[0].ID != timer.ID {
+				t.Errorf("ListWorkLogs(running) = %v, want Alice's timer", logs)
+			}
+
+			stopped, err := store.StopTimer(alice.ID, timer.Start.Add(45*time.Minute))
+			if err != nil {
+				t.Fatalf("StopTimer() error = %v", err)
+			}
+			if stopped.ID != timer.ID || stopped.IsRunning() || stopped.Duration.String() != "45m" {
+				t.Errorf("StopTimer() = %+v, want the timer stopped after 45m", stopped)
+			}
+			if err := store.CreateWorkLog(models.NewWorkLog(second.ID, alice.ID)); err != nil {
+				t.Errorf("CreateWorkLog() after stopping error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSummarizeTime(t *testing.T) {
+	for name, store := range storageBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newDeleteFixture(t, store)
+			alice := models.NewUser("Alice", models.UserHuman)
+			if err := store.CreateUser(alice); err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+
+			logWork := func(taskID string, duration time.Duration) {
+				log := models.NewWorkLog(taskID, alice.ID)
+				log.Duration = models.Estimate(duration)
+				if err := store.CreateWorkLog(log); err != nil {
+					t.Fatalf("CreateWorkLog() error = %v", err)
+				}
+			}
+			logWork(f.parent.ID, time.Hour)
+			logWork(f.child.ID, 2*time.Hour)
+			logWork(f.grandchild.ID, 30*time.Minute)
+			logWork(f.sibling.ID, 4*time.Hour)
+
+			summary, err := SummarizeTime(store, f.parent.ID)
+			if err != nil {
+				t.Fatalf("SummarizeTime() error = %v", err)
+			}
+			if summary.Logged.String() != "1h" || summary.SubtreeLogged.String() != "3h30m" {
+				t.Errorf("SummarizeTime() logged %s, subtree %s, want 1h and 3h30m", summary.Logged, summary.SubtreeLogged)
+			}
+
+			summary, err = SummarizeTime(store, f.root.ID)
+			if err != nil {
+				t.Fatalf("SummarizeTime() error = %v", err)
+			}
+			if summary.Logged != 0 || summary.SubtreeLogged.String() != "7h30m" {
+				t.Errorf("SummarizeTime() logged %s, subtree %s, want 0m and 7h30m", summary.Logged, summary.SubtreeLogged)
+			}
+		})
+	}
+}
@@ -2,6 +2,11 @@
 let currentEditingTask = null;
 let currentView = 'kanban';
 let hierarchyData = [];
+let workflow = null;
+
+// Sent with every change so the task history records it as made in the web
+// interface
+const CLIENT_HEADER = { 'X-ProjectFlow-Client': 'web' };
 
 // DOM elements
 const modal = document.getElementById('task-modal');
@@ -22,8 +27,39 @@ document.addEventListener('DOMContentLoaded', function() {
     initializeEventListeners();
     initializeTimelineControls();
     updateOverdueIndicators();
+    loadWorkflow();
 });
 
+// Load the project's workflow so moves it doesn't allow can be refused before
+// asking the server
+async function loadWorkflow() {
+    try {
+        const response = await fetch('/api/workflow');
+        if (response.ok) {
+            workflow = await response.json();
+        }
+    } catch (error) {
+        console.error('Error loading workflow:', error);
+    }
+}
+
+// statusCategory returns todo, in_progress or done for a workflow status
+function statusCategory(status) {
+    if (workflow) {
+        const definition = workflow.statuses.find(s => s.id === status);
+        return definition ? definition.category : '';
+    }
+    return status === 'done' || status === 'in_progress' ? status : 'todo';
+}
+
+// isTransitionAllowed mirrors the server's check; guards are left to the server
+function isTransitionAllowed(from, to) {
+    if (!workflow || !workflow.transitions || workflow.transitions.length === 0) {
+        return true;
+    }
+    return workflow.transitions.some(t => t.to === to && t.from.some(f => f === from || f === '*'));
+}
+
 function initializeEventListeners() {
     // View switching
     kanbanViewBtn.addEventListener('click', () => switchToView('kanban'));
@@ -106,7 +142,11 @@ function populateForm(task) {
     document.getElementById('task-description').value = task.description || '';
     document.getElementById('task-type').value = task.type || 'task';
     document.getElementById('task-priority').value = task.priority || 'medium';
-    document.getElementById('task-status').value = task.status || 'todo';
+    document.getElementById('task-status').value = task.status || (workflow ? workflow.initial : 'todo');
+    document.getElementById('task-assignee').value = task.assignee_id || '';
+    document.getElementById('task-story-points').value = task.story_points || '';
+    document.getElementById('task-original-estimate').value = task.original_estimate || '';
+    document.getElementById('task-remaining-estimate').value = task.remaining_estimate || '';
     document.getElementById('task-due-date').value = task.due_date ? task.due_date.split('T')[0] : '';
     
     // Handle start date - convert from RFC3339 to datetime-local format
@@ -133,6 +173,10 @@ async function handleTaskSubmit(event) {
         type: formData.get('type'),
         priority: formData.get('priority'),
         status: formData.get('status'),
+        assignee_id: formData.get('assignee_id') || null,
+        story_points: formData.get('story_points') ? Number(formData.get('story_points')) : null,
+        original_estimate: formData.get('original_estimate') || null,
+        remaining_estimate: formData.get('remaining_estimate') || null,
         due_date: formData.get('due_date') || null,
         started_at: formData.get('started_at') ? new Date(formData.get('started_at')).toISOString() : null
     };
@@ -141,10 +185,14 @@ async function handleTaskSubmit(event) {
         let response;
         if (currentEditingTask) {
             // Update existing task
+            // A merge patch so emptied fields are cleared. The save is refused
+            // if someone else changed the task since it was loaded.
             response = await fetch(`/api/tasks/${currentEditingTask.id}`, {
-                method: 'PUT',
+                method: 'PATCH',
                 headers: {
-                    'Content-Type': 'application/json'
+                    ...CLIENT_HEADER,
+                    'Content-Type': 'application/merge-patch+json',
+                    'If-Match': `"${currentEditingTask.version}"`
                 },
                 body: JSON.stringify(taskData)
             });
@@ -153,6 +201,7 @@ async function handleTaskSubmit(event) {
             response = await fetch('/api/tasks', {
                 method: 'POST',
                 headers: {
+                    ...CLIENT_HEADER,
                     'Content-Type': 'application/json'
                 },
                 body: JSON.stringify(taskData)
@@ -163,6 +212,8 @@ async function handleTaskSubmit(event) {
             closeTaskModal();
             window.location.reload(); // Simple refresh for now
             showMessage('Task saved successfully!', 'success');
+        } else if (response.status === 412) {
+            showMessage('This task was changed by someone else. Reload the page to see their changes.', 'error');
         } else {
             const error = await response.text();
             showMessage(`Error: ${error}`, 'error');
@@ -195,15 +246,19 @@ async function deleteTask(taskId) {
 
     try {
         const response = await fetch(`/api/tasks/${taskId}`, {
-            method: 'DELETE'
+            method: 'DELETE',
+            headers: CLIENT_HEADER
         });
 
         if (response.ok) {
-            // Remove the task card from the DOM
-            const taskCard = document.querySelector(`[data-id="${taskId}"]`);
-            if (taskCard) {
-                taskCard.remove();
-            }
+            // Remove the cards of the task and every deleted descendant
+            const result = await response.json();
+            result.deleted.forEach(id => {
+                const taskCard = document.querySelector(`[data-id="${id}"]`);
+                if (taskCard) {
+                    taskCard.remove();
+                }
+            });
             showMessage('Task deleted successfully!', 'success');
         } else {
             showMessage('Failed to delete task.', 'error');
@@ -268,10 +323,16 @@ function handleDrop(event) {
     
     const taskId = event.dataTransfer.getData('text/plain');
     const newStatus = column ? column.getAttribute('data-status') : null;
+    const sourceColumn = document.querySelector(`.task-card[data-id="${taskId}"]`)?.closest('.column');
+    const oldStatus = sourceColumn ? sourceColumn.getAttribute('data-status') : null;
     
     console.log('Drop event:', { taskId, newStatus, column });
     
-    if (taskId && newStatus) {
+    if (taskId && newStatus && oldStatus === newStatus) {
+        // Dropped back on its own column
+    } else if (taskId && newStatus && !isTransitionAllowed(oldStatus, newStatus)) {
+        showMessage(`The workflow doesn't allow moving a task from ${oldStatus} to ${newStatus}`, 'error');
+    } else if (taskId && newStatus) {
         updateTaskStatus(taskId, newStatus);
     } else {
         console.error('Missing taskId or newStatus:', { taskId, newStatus });
@@ -291,6 +352,7 @@ async function updateTaskStatus(taskId, newStatus) {
         const updateResponse = await fetch(`/api/tasks/${taskId}`, {
             method: 'PUT',
             headers: {
+                ...CLIENT_HEADER,
                 'Content-Type': 'application/json'
             },
             body: JSON.stringify({ status: newStatus })
@@ -338,14 +400,14 @@ function updateOverdueIndicators() {
             const dueDate = new Date(dueDateText);
             dueDate.setHours(0, 0, 0, 0);
             
-            // Get task status from the parent column
+            // Get the status category from the parent column
             const column = card.closest('.column');
-            const status = column ? column.getAttribute('data-status') : '';
+            const done = column && column.getAttribute('data-category') === 'done';
             
             // Only mark as overdue if not done and past due date
-            if (status !== 'done' && dueDate < today) {
+            if (!done && dueDate < today) {
                 card.classList.add('overdue');
-            } else if (status !== 'done' && dueDate <= new Date(today.getTime() + (3 * 24 * 60 * 60 * 1000))) {
+            } else if (!done && dueDate <= new Date(today.getTime() + (3 * 24 * 60 * 60 * 1000))) {
                 // Due within 3 days
                 card.classList.add('due-soon');
             }
@@ -465,6 +527,7 @@ function extractNumericPrefix(title) {
 function createHierarchyElement(hierarchyTask, level) {
     const task = hierarchyTask.task || hierarchyTask; // Handle both old and new format
     const childTasks = hierarchyTask.child_tasks || [];
+    const rollup = hierarchyTask.rollup;
     
     const item = document.createElement('div');
     item.className = `hierarchy-item ${task.type}`;
@@ -480,6 +543,7 @@ function createHierarchyElement(hierarchyTask, level) {
                     ${toggleSymbol}
                 </button>
                 <span class="hierarchy-badge ${task.type}">${task.type.toUpperCase()}</span>
+                ${task.key ? `<span class="task-key">${task.key}</span>` : ''}
                 <h4 class="hierarchy-title">${task.title}</h4>
                 <div class="hierarchy-meta">
                     <span class="hierarchy-badge status-${task.status}">${task.status.replace('_', ' ')}</span>
@@ -487,6 +551,7 @@ function createHierarchyElement(hierarchyTask, level) {
                     ${task.started_at ? `<span class="task-started-at">Started: ${new Date(task.started_at).toLocaleDateString()}</span>` : ''}
                     ${task.due_date ? `<span class="task-due-date">Due: ${new Date(task.due_date).toLocaleDateString()}</span>` : ''}
                     ${hasChildren ? `<span>${childTasks.length} child${childTasks.length !== 1 ? 'ren' : ''}</span>` : ''}
+                    ${rollup && rollup.total_points ? `<span class="task-points" title="Completed of total story points">${rollup.completed_points}/${rollup.total_points} pts</span>` : ''}
                 </div>
             </div>
             <div class="hierarchy-actions">
@@ -541,11 +606,11 @@ let timelineMode = 'due'; // Default to due dates mode
 async function loadTimelineView() {
     try {
         console.log('Loading timeline view...');
-        const response = await fetch('/api/tasks');
+        const response = await fetch('/api/timeline');
         if (response.ok) {
-            const tasks = await response.json();
-            console.log('Loaded tasks for timeline:', tasks.length);
-            renderTimelineView(tasks);
+            const timeline = await response.json();
+            console.log('Loaded tasks for timeline:', timeline.tasks.length);
+            renderTimelineView(timeline.tasks, timeline.milestones);
         } else {
             console.error('Failed to load tasks, status:', response.status);
             showMessage('Failed to load timeline view.', 'error');
@@ -556,7 +621,7 @@ async function loadTimelineView() {
     }
 }
 
-function renderTimelineView(tasks) {
+function renderTimelineView(tasks, milestones = []) {
     console.log('Rendering timeline view with tasks:', tasks);
     const container = document.querySelector('.timeline-container');
     
@@ -568,7 +633,7 @@ function renderTimelineView(tasks) {
     
     container.innerHTML = '';
     
-    if (tasks.length === 0) {
+    if (tasks.length === 0 && milestones.length === 0) {
         container.innerHTML = '<p>No tasks found. Create your first task to get started!</p>';
         return;
     }
@@ -597,7 +662,14 @@ function renderTimelineView(tasks) {
     
     console.log(`Tasks with ${timelineMode} dates in range:`, filteredTasks);
     
-    if (filteredTasks.length === 0) {
+    // Milestones are marked by target date
+    const visibleMilestones = milestones.filter(milestone => {
+        const target = new Date(milestone.target_date);
+        target.setHours(0, 0, 0, 0);
+        return target >= today && target <= endDate;
+    });
+    
+    if (filteredTasks.length === 0 && visibleMilestones.length === 0) {
         const modeText = timelineMode === 'due' ? 'due dates' : 'start dates';
         container.innerHTML = `<p>No tasks with ${modeText} found in the next ${timelineRange} days. Add ${modeText} to tasks to see them in timeline view.</p>`;
         return;
@@ -607,6 +679,10 @@ function renderTimelineView(tasks) {
     const timelineScale = createTimelineScale(today, endDate);
     container.appendChild(timelineScale);
     
+    if (visibleMilestones.length > 0) {
+        container.appendChild(createTimelineMilestones(visibleMilestones, today, endDate));
+    }
+    
     // Create timeline tasks with proper lane assignment
     const timelineTasksContainer = document.createElement('div');
     timelineTasksContainer.className = 'timeline-tasks';
@@ -620,7 +696,7 @@ function renderTimelineView(tasks) {
     });
     
     // Set the height of the timeline container based on the number of lanes used
-    const maxLane = Math.max(...lanes);
+    const maxLane = Math.max(0, ...lanes);
     timelineTasksContainer.style.minHeight = `${(maxLane + 1) * 140}px`;
     
     container.appendChild(timelineTasksContainer);
@@ -647,6 +723,40 @@ function createTimelineScale(startDate, endDate) {
     return scale;
 }
 
+// Create the row of milestone markers, flagging those at risk
+function createTimelineMilestones(milestones, startDate, endDate) {
+    const row = document.createElement('div');
+    row.className = 'timeline-milestones';
+    
+    const totalDays = Math.ceil((endDate - startDate) / (24 * 60 * 60 * 1000));
+    milestones.forEach(milestone => {
+        const target = new Date(milestone.target_date);
+        const daysFromStart = Math.ceil((target - startDate) / (24 * 60 * 60 * 1000));
+        const progress = milestone.progress;
+        
+        const marker = document.createElement('div');
+        marker.className = `timeline-milestone${progress.at_risk ? ' at-risk' : ''}`;
+        marker.style.left = `${(daysFromStart / totalDays) * 100}%`;
+        
+        let title = `${milestone.name}: ${Math.round(progress.percent)}% of ${progress.total} tasks done, target ${target.toLocaleDateString()}`;
+        if (progress.projected_date) {
+            title += `, projected ${new Date(progress.projected_date).toLocaleDateString()}`;
+        }
+        if (progress.overdue.length > 0) {
+            title += `, ${progress.overdue.length} overdue`;
+        }
+        marker.title = title;
+        
+        const label = document.createElement('span');
+        label.className = 'timeline-milestone-label';
+        label.textContent = `${milestone.name} (${Math.round(progress.percent)}%)`;
+        marker.appendChild(label);
+        row.appendChild(marker);
+    });
+    
+    return row;
+}
+
 // Assign lanes to timeline tasks to prevent overlapping
 function assignTimelineLanes(tasks, startDate, endDate) {
     const totalDays = Math.ceil((endDate - startDate) / (24 * 60 * 60 * 1000));
@@ -706,7 +816,7 @@ function createTimelineTaskElement(task, startDate, endDate, lane = 0) {
     const position = (daysFromStart / totalDays) * 100;
     
     // Check if task is overdue (only applies to due date mode)
-    const isOverdue = timelineMode === 'due' && new Date() > primaryDate && task.status !== 'done';
+    const isOverdue = timelineMode === 'due' && new Date() > primaryDate && statusCategory(task.status) !== 'done';
     if (isOverdue) {
         taskElement.classList.add('overdue');
     }
@@ -715,13 +825,12 @@ function createTimelineTaskElement(task, startDate, endDate, lane = 0) {
     taskElement.style.left = `${Math.max(0, Math.min(100, position))}%`;
     taskElement.style.top = `${lane * 140}px`; // 140px spacing between lanes
     
-    // Calculate progress based on status
+    // Calculate progress based on the status category
     let progress = 0;
-    switch (task.status) {
-        case 'todo': progress = 0; break;
+    switch (statusCategory(task.status)) {
+        case 'todo': progress = task.status === 'blocked' ? 25 : 0; break;
         case 'in_progress': progress = 50; break;
         case 'done': progress = 100; break;
-        case 'blocked': progress = 25; break;
     }
     
     // Build date display based on mode and available dates